@@ -116,6 +116,26 @@ func TestSessionKeyCachePrefixes(t *testing.T) {
 	}
 }
 
+// TestSessionKeyCachePrefixPerForeignModel verifies every foreign model with
+// encrypted metadata gets its own cache prefix, so a folder, tag or comment
+// can never be served the session key of a resource with the same ID
+func TestSessionKeyCachePrefixPerForeignModel(t *testing.T) {
+	seen := map[string]ForeignModelTypes{}
+	for _, model := range []ForeignModelTypes{ForeignModelTypesResource, ForeignModelTypesFolder, ForeignModelTypesTag, ForeignModelTypesComment} {
+		prefix := sessionKeyCachePrefix(model)
+		if other, ok := seen[prefix]; ok {
+			t.Errorf("%v and %v share cache prefix %q", model, other, prefix)
+		}
+		if prefix == sessionKeyCachePrefixMetaKey {
+			t.Errorf("%v uses the metadata key cache prefix", model)
+		}
+		seen[prefix] = model
+	}
+	if sessionKeyCachePrefix(ForeignModelTypesResource) != sessionKeyCachePrefixResource {
+		t.Error("resource session keys must keep using the resource prefix")
+	}
+}
+
 // TestSessionKeyCacheOperations tests cache get/set operations with correct prefixes
 func TestSessionKeyCacheOperations(t *testing.T) {
 	// Create a minimal client with just the cache fields initialized
@@ -23,6 +23,12 @@ const (
 	sessionKeyCachePrefixResource = "resource:"
 	// sessionKeyCachePrefixMetaKey is used for fallback session keys keyed by metadata key ID
 	sessionKeyCachePrefixMetaKey = "metakey:"
+	// sessionKeyCachePrefixFolder is used for per-folder METADATA session keys
+	sessionKeyCachePrefixFolder = "folder:"
+	// sessionKeyCachePrefixTag is used for per-tag METADATA session keys
+	sessionKeyCachePrefixTag = "tag:"
+	// sessionKeyCachePrefixComment is used for per-comment METADATA session keys
+	sessionKeyCachePrefixComment = "comment:"
 )
 
 // Client is a Client struct for the Passbolt api.
@@ -649,6 +655,35 @@ func (c *Client) SetSessionKeyByResourceID(resourceID string, sessionKey *crypto
 	c.sessionKeyCache[sessionKeyCachePrefixResource+resourceID] = sessionKey
 }
 
+// GetSessionKeyByForeignID retrieves a cached session key for a foreign model (Resource, Folder, ...) by its ID.
+// Returns a clone of the cached key to prevent callers from modifying the cache.
+func (c *Client) GetSessionKeyByForeignID(foreignModel ForeignModelTypes, foreignID string) *crypto.SessionKey {
+	c.sessionKeyCacheMu.RLock()
+	defer c.sessionKeyCacheMu.RUnlock()
+	return cloneSessionKey(c.sessionKeyCache[sessionKeyCachePrefix(foreignModel)+foreignID])
+}
+
+// SetSessionKeyByForeignID stores a session key for a foreign model (Resource, Folder, ...) by its ID
+func (c *Client) SetSessionKeyByForeignID(foreignModel ForeignModelTypes, foreignID string, sessionKey *crypto.SessionKey) {
+	c.sessionKeyCacheMu.Lock()
+	defer c.sessionKeyCacheMu.Unlock()
+	c.sessionKeyCache[sessionKeyCachePrefix(foreignModel)+foreignID] = sessionKey
+}
+
+// sessionKeyCachePrefix returns the session key cache prefix used for a foreign model
+func sessionKeyCachePrefix(foreignModel ForeignModelTypes) string {
+	switch foreignModel {
+	case ForeignModelTypesFolder:
+		return sessionKeyCachePrefixFolder
+	case ForeignModelTypesTag:
+		return sessionKeyCachePrefixTag
+	case ForeignModelTypesComment:
+		return sessionKeyCachePrefixComment
+	default:
+		return sessionKeyCachePrefixResource
+	}
+}
+
 // GetSessionKeyByMetadataKeyID retrieves a cached session key by metadata key ID.
 // These session keys are extracted during decrypt and cached as fallback.
 // Returns a clone of the cached key to prevent callers from modifying the cache.
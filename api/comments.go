@@ -20,12 +20,27 @@ type Comment struct {
 	Modified     *Time     `json:"modified,omitempty"`
 	ModifiedBy   string    `json:"modified_by,omitempty"`
 	Children     []Comment `json:"children,omitempty"`
+	Creator      *User     `json:"creator,omitempty"`
+	Modifier     *User     `json:"modifier,omitempty"`
+
+	MetadataKeyID   string          `json:"metadata_key_id,omitempty"`
+	MetadataKeyType MetadataKeyType `json:"metadata_key_type,omitempty"`
+	Metadata        string          `json:"metadata,omitempty"`
+}
+
+// CommentMetadata is the cleartext content of a v5 Comment's encrypted Metadata
+type CommentMetadata struct {
+	// ObjectType Must always be PASSBOLT_COMMENT_METADATA
+	ObjectType string `json:"object_type"`
+	Content    string `json:"content"`
 }
 
 // GetCommentsOptions are all available query parameters
 type GetCommentsOptions struct {
-	ContainCreator  bool `url:"contain[creator],omitempty"`
-	ContainModifier bool `url:"contain[modifier],omitempty"`
+	ContainCreator         bool `url:"contain[creator],omitempty"`
+	ContainCreatorProfile  bool `url:"contain[creator.profile],omitempty"`
+	ContainModifier        bool `url:"contain[modifier],omitempty"`
+	ContainModifierProfile bool `url:"contain[modifier.profile],omitempty"`
 }
 
 // GetComments gets all Passbolt Comments an The Specified Resource
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// DeleteDryRunBlockers are the entities that would be left without an owner or manager by deleting a User or Group
+type DeleteDryRunBlockers struct {
+	Resources SoleOwnerResources `json:"resources,omitempty"`
+	Folders   SoleOwnerFolders   `json:"folders,omitempty"`
+	Groups    SoleManagerGroups  `json:"groups,omitempty"`
+}
+
+// SoleOwnerResources are Resources owned only by the deleted User or Group
+type SoleOwnerResources struct {
+	SoleOwner []Resource `json:"sole_owner,omitempty"`
+}
+
+// SoleOwnerFolders are Folders owned only by the deleted User or Group
+type SoleOwnerFolders struct {
+	SoleOwner []Folder `json:"sole_owner,omitempty"`
+}
+
+// SoleManagerGroups are Groups managed only by the deleted User
+type SoleManagerGroups struct {
+	SoleManager []Group `json:"sole_manager,omitempty"`
+}
+
+// Empty returns true if nothing blocks the deletion
+func (b DeleteDryRunBlockers) Empty() bool {
+	return len(b.Resources.SoleOwner) == 0 && len(b.Folders.SoleOwner) == 0 && len(b.Groups.SoleManager) == 0
+}
+
+// DeleteTransfer hands over ownership and group management when deleting a User or Group
+type DeleteTransfer struct {
+	Owners   []TransferOwner   `json:"owners,omitempty"`
+	Managers []TransferManager `json:"managers,omitempty"`
+}
+
+// TransferOwner promotes an existing Permission to owner
+type TransferOwner struct {
+	// ID of the Permission to promote
+	ID            string `json:"id"`
+	ACOForeignKey string `json:"aco_foreign_key"`
+}
+
+// TransferManager promotes an existing Group Membership to group manager
+type TransferManager struct {
+	// ID of the Group Membership to promote
+	ID      string `json:"id"`
+	GroupID string `json:"group_id"`
+}
+
+type deleteTransferRequest struct {
+	Transfer DeleteTransfer `json:"transfer"`
+}
+
+// GetDeleteDryRunBlockers extracts the blocking entities from the error of a failed delete dry-run,
+// returns false if err does not contain any
+func GetDeleteDryRunBlockers(err error) (*DeleteDryRunBlockers, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Body == "" {
+		return nil, false
+	}
+
+	var body struct {
+		Errors DeleteDryRunBlockers `json:"errors"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) != nil || body.Errors.Empty() {
+		return nil, false
+	}
+	return &body.Errors, true
+}
+
+// deleteDryRunBlockers turns the error of a delete dry-run into what blocks the deletion, empty if nothing does
+func deleteDryRunBlockers(err error) (*DeleteDryRunBlockers, error) {
+	if err == nil {
+		return &DeleteDryRunBlockers{}, nil
+	}
+	blockers, ok := GetDeleteDryRunBlockers(err)
+	if !ok {
+		return nil, err
+	}
+	return blockers, nil
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// A blocked deletion is reported as an error whose body lists the entities
+// that would lose their last owner; that list is what callers act on.
+
+func writeDryRunBlocked(t *testing.T, w http.ResponseWriter, body string) {
+	t.Helper()
+	env := APIResponse{
+		Header: APIHeader{Status: "error", Code: 400, Message: "The user cannot be deleted."},
+		Body:   json.RawMessage(body),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(env); err != nil {
+		t.Fatalf("encode envelope: %v", err)
+	}
+}
+
+func TestDeleteUserDryrunBlockers(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t, route{
+		method: "DELETE", path: "/users/" + validUUID + "/dry-run.json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			writeDryRunBlocked(t, w, `{"errors": {
+				"resources": {"sole_owner": [{"id": "`+otherUUID+`", "permissions": [{"id": "p1", "aro": "User"}]}]},
+				"folders": {"sole_owner": [{"id": "f1"}]},
+				"groups": {"sole_manager": [{"id": "g1"}]}
+			}}`)
+		},
+	})
+
+	blockers, err := client.DeleteUserDryrunBlockers(bg(), validUUID)
+	if err != nil {
+		t.Fatalf("DeleteUserDryrunBlockers: %v", err)
+	}
+	if len(blockers.Resources.SoleOwner) != 1 || blockers.Resources.SoleOwner[0].ID != otherUUID {
+		t.Errorf("resources = %+v", blockers.Resources.SoleOwner)
+	}
+	if len(blockers.Resources.SoleOwner[0].Permissions) != 1 {
+		t.Errorf("resource permissions not parsed")
+	}
+	if len(blockers.Folders.SoleOwner) != 1 || len(blockers.Groups.SoleManager) != 1 {
+		t.Errorf("folders = %+v, groups = %+v", blockers.Folders.SoleOwner, blockers.Groups.SoleManager)
+	}
+}
+
+func TestDeleteUserDryrunBlockers_Deletable(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t, route{
+		method: "DELETE", path: "/users/" + validUUID + "/dry-run.json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			writeAPIResponse(t, w, nil)
+		},
+	})
+
+	blockers, err := client.DeleteUserDryrunBlockers(bg(), validUUID)
+	if err != nil {
+		t.Fatalf("DeleteUserDryrunBlockers: %v", err)
+	}
+	if !blockers.Empty() {
+		t.Errorf("blockers = %+v, want none", blockers)
+	}
+}
+
+func TestDeleteUserDryrunBlockers_OtherError(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t, route{
+		method: "DELETE", path: "/users/" + validUUID + "/dry-run.json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			writeAPIError(t, w, 403, "forbidden")
+		},
+	})
+
+	_, err := client.DeleteUserDryrunBlockers(bg(), validUUID)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDeleteUserWithTransfer_SendsTransfer(t *testing.T) {
+	t.Parallel()
+
+	var seen struct {
+		Transfer DeleteTransfer `json:"transfer"`
+	}
+	_, client := newTestClient(t, route{
+		method: "DELETE", path: "/users/" + validUUID + ".json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			readJSONBody(t, r, &seen)
+			writeAPIResponse(t, w, nil)
+		},
+	})
+
+	err := client.DeleteUserWithTransfer(bg(), validUUID, DeleteTransfer{
+		Owners:   []TransferOwner{{ID: "p1", ACOForeignKey: otherUUID}},
+		Managers: []TransferManager{{ID: "m1", GroupID: "g1"}},
+	})
+	if err != nil {
+		t.Fatalf("DeleteUserWithTransfer: %v", err)
+	}
+	if len(seen.Transfer.Owners) != 1 || seen.Transfer.Owners[0].ACOForeignKey != otherUUID {
+		t.Errorf("owners = %+v", seen.Transfer.Owners)
+	}
+	if len(seen.Transfer.Managers) != 1 || seen.Transfer.Managers[0].GroupID != "g1" {
+		t.Errorf("managers = %+v", seen.Transfer.Managers)
+	}
+}
+
+func TestDeleteGroupDryrunBlockers(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t, route{
+		method: "DELETE", path: "/groups/" + validUUID + "/dry-run.json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			writeDryRunBlocked(t, w, `{"errors": {"resources": {"sole_owner": [{"id": "`+otherUUID+`"}]}}}`)
+		},
+	})
+
+	blockers, err := client.DeleteGroupDryrunBlockers(bg(), validUUID)
+	if err != nil {
+		t.Fatalf("DeleteGroupDryrunBlockers: %v", err)
+	}
+	if len(blockers.Resources.SoleOwner) != 1 || blockers.Resources.SoleOwner[0].ID != otherUUID {
+		t.Errorf("resources = %+v", blockers.Resources.SoleOwner)
+	}
+	if len(blockers.Folders.SoleOwner) != 0 || len(blockers.Groups.SoleManager) != 0 {
+		t.Errorf("unexpected blockers = %+v", blockers)
+	}
+}
+
+func TestDeleteGroupWithTransfer_InvalidUUID(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t)
+	err := client.DeleteGroupWithTransfer(bg(), "not-a-uuid", DeleteTransfer{})
+	if err == nil {
+		t.Fatal("expected error for invalid ID")
+	}
+}
@@ -18,6 +18,19 @@ type Folder struct {
 	Personal          bool         `json:"personal,omitempty"`
 	ChildrenResources []Resource   `json:"children_resources,omitempty"`
 	ChildrenFolders   []Folder     `json:"children_folders,omitempty"`
+
+	MetadataKeyID   string          `json:"metadata_key_id,omitempty"`
+	MetadataKeyType MetadataKeyType `json:"metadata_key_type,omitempty"`
+	Metadata        string          `json:"metadata,omitempty"`
+}
+
+// FolderMetadata is the cleartext content of a v5 Folder's encrypted Metadata
+type FolderMetadata struct {
+	// ObjectType Must always be PASSBOLT_FOLDER_METADATA
+	ObjectType  string `json:"object_type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Color       string `json:"color,omitempty"`
 }
 
 // GetFoldersOptions are all available query parameters
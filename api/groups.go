@@ -135,3 +135,25 @@ func (c *Client) DeleteGroup(ctx context.Context, groupID string) error {
 	}
 	return doDelete(ctx, c, "/groups/"+groupID+".json")
 }
+
+// DeleteGroupDryrun Check if a Passbolt Group is Deleteable
+func (c *Client) DeleteGroupDryrun(ctx context.Context, groupID string) error {
+	if err := checkUUIDFormat(groupID); err != nil {
+		return fmt.Errorf("checking ID format: %w", err)
+	}
+	return doDelete(ctx, c, "/groups/"+groupID+"/dry-run.json")
+}
+
+// DeleteGroupDryrunBlockers Checks if a Passbolt Group is Deleteable and returns the Resources and Folders blocking the deletion
+func (c *Client) DeleteGroupDryrunBlockers(ctx context.Context, groupID string) (*DeleteDryRunBlockers, error) {
+	return deleteDryRunBlockers(c.DeleteGroupDryrun(ctx, groupID))
+}
+
+// DeleteGroupWithTransfer Deletes a Passbolt Group and hands over the ownership of its Resources and Folders
+func (c *Client) DeleteGroupWithTransfer(ctx context.Context, groupID string, transfer DeleteTransfer) error {
+	if err := checkUUIDFormat(groupID); err != nil {
+		return fmt.Errorf("checking ID format: %w", err)
+	}
+	_, err := c.DoCustomRequest(ctx, "DELETE", "/groups/"+groupID+".json", "v2", deleteTransferRequest{Transfer: transfer}, nil)
+	return err
+}
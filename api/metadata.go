@@ -8,6 +8,9 @@ import (
 
 const PassboltObjectTypeResourceMetadata = "PASSBOLT_RESOURCE_METADATA"
 const PassboltObjectTypeSecretData = "PASSBOLT_SECRET_DATA"
+const PassboltObjectTypeFolderMetadata = "PASSBOLT_FOLDER_METADATA"
+const PassboltObjectTypeTagMetadata = "PASSBOLT_TAG_METADATA"
+const PassboltObjectTypeCommentMetadata = "PASSBOLT_COMMENT_METADATA"
 
 // DecryptMetadata decrypts metadata using the provided key.
 // For session key caching, use DecryptMetadataWithKeyID instead.
@@ -59,21 +62,28 @@ func (c *Client) DecryptMetadataWithKeyID(metadataKeyID string, metadataKey *cry
 // This function provides the best performance when PreFetchCaches() has been called.
 // This method is thread-safe: multiple goroutines can call this method concurrently with the same metadataKey.
 func (c *Client) DecryptMetadataWithResourceID(resourceID, metadataKeyID string, metadataKey *crypto.Key, armoredCiphertext string) (string, error) {
-	// 1. First, check for pre-fetched session key by resource ID (returns a clone)
-	if resourceID != "" {
-		if sessionKeyClone := c.GetSessionKeyByResourceID(resourceID); sessionKeyClone != nil {
+	return c.DecryptMetadataWithForeignID(ForeignModelTypesResource, resourceID, metadataKeyID, metadataKey, armoredCiphertext)
+}
+
+// DecryptMetadataWithForeignID is DecryptMetadataWithResourceID for any foreign model that carries
+// encrypted metadata (Resource, Folder, Tag, Comment). Session keys are cached and queued for saving
+// under the given foreign model so they do not collide between models.
+func (c *Client) DecryptMetadataWithForeignID(foreignModel ForeignModelTypes, foreignID, metadataKeyID string, metadataKey *crypto.Key, armoredCiphertext string) (string, error) {
+	// 1. First, check for pre-fetched session key by foreign ID (returns a clone)
+	if foreignID != "" {
+		if sessionKeyClone := c.GetSessionKeyByForeignID(foreignModel, foreignID); sessionKeyClone != nil {
 			message, err := c.DecryptMessageWithSessionKey(sessionKeyClone, armoredCiphertext)
 			if err == nil {
-				c.log("Metadata session key cache HIT for resource %v", resourceID)
+				c.log("Metadata session key cache HIT for %v %v", foreignModel, foreignID)
 				return message, nil
 			}
 			// If failed, fall through to other cache strategies
-			c.log("Resource session key cache decrypt FAILED for resource %v: %v", resourceID, err)
+			c.log("Session key cache decrypt FAILED for %v %v: %v", foreignModel, foreignID, err)
 		} else {
 			c.sessionKeyCacheMu.RLock()
 			cacheSize := len(c.sessionKeyCache)
 			c.sessionKeyCacheMu.RUnlock()
-			c.log("Resource session key cache MISS for resource %v (cache size: %d)", resourceID, cacheSize)
+			c.log("Session key cache MISS for %v %v (cache size: %d)", foreignModel, foreignID, cacheSize)
 		}
 	}
 
@@ -95,13 +105,13 @@ func (c *Client) DecryptMetadataWithResourceID(resourceID, metadataKeyID string,
 		return "", fmt.Errorf("decrypting Metadata: %w", err)
 	}
 
-	// Cache the session key by resource ID if available
+	// Cache the session key by foreign ID if available
 	if newSessionKey != nil {
 		clonedSessionKey := crypto.NewSessionKeyFromToken(newSessionKey.Key, newSessionKey.Algo)
-		if resourceID != "" {
-			c.SetSessionKeyByResourceID(resourceID, clonedSessionKey)
+		if foreignID != "" {
+			c.SetSessionKeyByForeignID(foreignModel, foreignID, clonedSessionKey)
 			// Also add to pending session keys for saving to server
-			c.AddPendingSessionKey(ForeignModelTypesResource, resourceID, newSessionKey)
+			c.AddPendingSessionKey(foreignModel, foreignID, newSessionKey)
 		} else if metadataKeyID != "" {
 			c.SetSessionKeyByMetadataKeyID(metadataKeyID, clonedSessionKey)
 		}
@@ -38,7 +38,7 @@ type MetadataSessionKeyDataElement struct {
 // PendingSessionKey represents a session key that was extracted during decryption
 // and is pending to be saved to the server
 type PendingSessionKey struct {
-	ForeignModel ForeignModelTypes // "Resource", "Folder", "Tag"
+	ForeignModel ForeignModelTypes // "Resource", "Folder", "Tag", "Comment"
 	ForeignID    string            // UUID of the resource/folder/tag
 	SessionKey   string            // Format: "9:HEXHEX..." (algorithm:hex-encoded key)
 	Modified     Time              // When this session key was extracted
@@ -107,10 +107,12 @@ func (c *Client) FetchAndCacheSessionKeys(ctx context.Context) (int, error) {
 			continue
 		}
 
-		// Cache each session key by resource ID
+		// Cache each session key by its foreign model and ID
 		for _, element := range sessionKeyData.SessionKeys {
-			// Only process Resource foreign model for now
-			if element.ForeignModel != ForeignModelTypesResource {
+			// Only process foreign models we decrypt metadata for
+			switch element.ForeignModel {
+			case ForeignModelTypesResource, ForeignModelTypesFolder, ForeignModelTypesTag, ForeignModelTypesComment:
+			default:
 				continue
 			}
 
@@ -151,8 +153,8 @@ func (c *Client) FetchAndCacheSessionKeys(ctx context.Context) (int, error) {
 			// Create a crypto.SessionKey with the appropriate algorithm
 			cryptoSessionKey := crypto.NewSessionKeyFromToken(sessionKeyBytes, algo)
 
-			// Cache by resource ID
-			c.SetSessionKeyByResourceID(element.ForeignID, cryptoSessionKey)
+			// Cache by foreign model and ID
+			c.SetSessionKeyByForeignID(element.ForeignModel, element.ForeignID, cryptoSessionKey)
 			totalCached++
 		}
 	}
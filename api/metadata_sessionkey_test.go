@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"net/http"
 	"strings"
 	"sync/atomic"
@@ -201,6 +202,51 @@ func TestFetchAndCacheSessionKeys_HandlesEmptyServerResponse(t *testing.T) {
 	}
 }
 
+// TestFetchAndCacheSessionKeys_CachesFolderKeys verifies folder session
+// keys from the bundle land in the folder cache, so v5 folder names can
+// be decrypted without asymmetric crypto. Models we don't decrypt
+// metadata for yet are skipped rather than cached under a wrong prefix.
+func TestFetchAndCacheSessionKeys_CachesFolderKeys(t *testing.T) {
+	t.Parallel()
+
+	var bundle string
+	_, client := newTestClientWithKey(t, route{
+		method: "GET", path: "/metadata/session-keys.json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			writeAPIResponse(t, w, []MetadataSessionKey{{ID: validUUID, Data: bundle}})
+		},
+	})
+
+	data, err := json.Marshal(MetadataSessionKeyData{
+		ObjectType: "PASSBOLT_SESSION_KEYS",
+		SessionKeys: []MetadataSessionKeyDataElement{
+			{ForeignModel: ForeignModelTypesFolder, ForeignID: validUUID, SessionKey: FormatSessionKey(sessionKeyForTest())},
+			{ForeignModel: "Unknown", ForeignID: otherUUID, SessionKey: FormatSessionKey(sessionKeyForTest())},
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal bundle: %v", err)
+	}
+	bundle, err = client.EncryptMessage(string(data))
+	if err != nil {
+		t.Fatalf("EncryptMessage: %v", err)
+	}
+
+	got, err := client.FetchAndCacheSessionKeys(bg())
+	if err != nil {
+		t.Fatalf("FetchAndCacheSessionKeys: %v", err)
+	}
+	if got != 1 {
+		t.Errorf("cached %d session keys, want 1", got)
+	}
+	if sk := client.GetSessionKeyByForeignID(ForeignModelTypesFolder, validUUID); sk == nil {
+		t.Error("folder session key was not cached")
+	}
+	if sk := client.GetSessionKeyByResourceID(validUUID); sk != nil {
+		t.Error("folder session key was cached as a resource session key")
+	}
+}
+
 // TestSavePendingSessionKeys_NoPendingIsNoOp verifies the
 // short-circuit that avoids a network round-trip when nothing has
 // changed. We assert this via call counting (zero hits = it actually
@@ -166,6 +166,54 @@ func TestDecryptMetadataWithResourceID_UsesResourceCacheFirst(t *testing.T) {
 	}
 }
 
+// TestDecryptMetadataWithForeignID_FolderDoesNotCollideWithResource
+// verifies folder session keys are cached and queued under the Folder
+// foreign model. Caching them under the resource prefix would let a
+// folder and resource sharing an ID serve each other's session key,
+// and would save the key to the server with the wrong foreign_model.
+func TestDecryptMetadataWithForeignID_FolderDoesNotCollideWithResource(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClientWithKey(t)
+	metaKey, err := client.GetUserPrivateKeyCopy()
+	if err != nil {
+		t.Fatalf("GetUserPrivateKeyCopy: %v", err)
+	}
+
+	want := `{"object_type":"PASSBOLT_FOLDER_METADATA","name":"Infra"}`
+	armored, err := client.EncryptMetadata(metaKey, want)
+	if err != nil {
+		t.Fatalf("EncryptMetadata: %v", err)
+	}
+
+	got, err := client.DecryptMetadataWithForeignID(ForeignModelTypesFolder, validUUID, "key-1", metaKey, armored)
+	if err != nil {
+		t.Fatalf("first DecryptMetadataWithForeignID: %v", err)
+	}
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if sk := client.GetSessionKeyByForeignID(ForeignModelTypesFolder, validUUID); sk == nil {
+		t.Fatal("folder session key was not cached after first decrypt")
+	}
+	if sk := client.GetSessionKeyByResourceID(validUUID); sk != nil {
+		t.Error("folder session key leaked into the resource cache")
+	}
+
+	pending := client.GetPendingSessionKeys()
+	if len(pending) != 1 || pending[0].ForeignModel != ForeignModelTypesFolder {
+		t.Errorf("pending = %+v, want one Folder entry", pending)
+	}
+
+	got2, err := client.DecryptMetadataWithForeignID(ForeignModelTypesFolder, validUUID, "", nil, armored)
+	if err != nil {
+		t.Fatalf("second DecryptMetadataWithForeignID (cache path): %v", err)
+	}
+	if got2 != want {
+		t.Errorf("got %q, want %q", got2, want)
+	}
+}
+
 // TestEncryptMetadata_FailsWithoutClientKey confirms the encryption path
 // needs the *client's* private key (for signing) — not just the metadata
 // recipient key. A regression that omitted the signing key would
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -48,3 +49,24 @@ func getDefaultPasswordExpirySettings() PasswordExpirySettings {
 		CreatedBy:                "default",
 	}
 }
+
+// PasswordExpiryResource sets when the password of a Resource expires, a nil Expired clears the expiry
+type PasswordExpiryResource struct {
+	ID      string `json:"id"`
+	Expired *Time  `json:"expired"`
+}
+
+// UpdatePasswordExpiryResources sets the expiry date of the passwords of multiple Resources
+func (c *Client) UpdatePasswordExpiryResources(ctx context.Context, resources []PasswordExpiryResource) error {
+	for _, resource := range resources {
+		err := checkUUIDFormat(resource.ID)
+		if err != nil {
+			return fmt.Errorf("checking ID format: %w", err)
+		}
+	}
+	_, err := c.DoCustomRequestV5(ctx, "POST", "/password-expiry/resources.json", resources, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
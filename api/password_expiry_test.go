@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Clearing an expiry only works if the server sees an explicit null;
+// an omitted field would leave the old expiry date in place.
+
+func TestUpdatePasswordExpiryResources_SendsExplicitNull(t *testing.T) {
+	t.Parallel()
+
+	var seen []map[string]json.RawMessage
+	_, client := newTestClient(t, route{
+		method: "POST", path: "/password-expiry/resources.json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			readJSONBody(t, r, &seen)
+			writeAPIResponse(t, w, nil)
+		},
+	})
+
+	expiry := &Time{Time: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)}
+	err := client.UpdatePasswordExpiryResources(bg(), []PasswordExpiryResource{
+		{ID: validUUID, Expired: expiry},
+		{ID: otherUUID},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePasswordExpiryResources: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("server saw %d resources, want 2", len(seen))
+	}
+	if string(seen[0]["expired"]) != `"2030-01-02T03:04:05Z"` {
+		t.Errorf("expired = %s", seen[0]["expired"])
+	}
+	if raw, ok := seen[1]["expired"]; !ok || string(raw) != "null" {
+		t.Errorf("cleared expiry sent as %s, want null", raw)
+	}
+}
+
+func TestUpdatePasswordExpiryResources_InvalidUUID(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t)
+	err := client.UpdatePasswordExpiryResources(bg(), []PasswordExpiryResource{{ID: "not-a-uuid"}})
+	if err == nil {
+		t.Fatal("expected error for invalid ID")
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// PasswordPolicies are the organization's rules for generating passwords and passphrases
+type PasswordPolicies struct {
+	// DefaultGenerator is either "password" or "passphrase"
+	DefaultGenerator            string                      `json:"default_generator"`
+	Source                      string                      `json:"source,omitempty"`
+	PasswordGeneratorSettings   PasswordGeneratorSettings   `json:"password_generator_settings"`
+	PassphraseGeneratorSettings PassphraseGeneratorSettings `json:"passphrase_generator_settings"`
+	ExternalDictionaryCheck     bool                        `json:"external_dictionary_check"`
+}
+
+// PasswordGeneratorSettings configure the length and character masks of generated passwords
+type PasswordGeneratorSettings struct {
+	Length                int  `json:"length"`
+	MinLength             int  `json:"min_length,omitempty"`
+	MaxLength             int  `json:"max_length,omitempty"`
+	MaskUpper             bool `json:"mask_upper"`
+	MaskLower             bool `json:"mask_lower"`
+	MaskDigit             bool `json:"mask_digit"`
+	MaskParenthesis       bool `json:"mask_parenthesis"`
+	MaskEmoji             bool `json:"mask_emoji"`
+	MaskChar1             bool `json:"mask_char1"`
+	MaskChar2             bool `json:"mask_char2"`
+	MaskChar3             bool `json:"mask_char3"`
+	MaskChar4             bool `json:"mask_char4"`
+	MaskChar5             bool `json:"mask_char5"`
+	ExcludeLookAlikeChars bool `json:"exclude_look_alike_chars"`
+}
+
+// PassphraseGeneratorSettings configure generated passphrases
+type PassphraseGeneratorSettings struct {
+	Words         int    `json:"words"`
+	MinWords      int    `json:"min_words,omitempty"`
+	MaxWords      int    `json:"max_words,omitempty"`
+	WordSeparator string `json:"word_separator"`
+	// WordCase is one of "lowercase", "uppercase" or "camelcase"
+	WordCase string `json:"word_case"`
+}
+
+// GetPasswordPolicies gets the organization's password policies
+func (c *Client) GetPasswordPolicies(ctx context.Context) (*PasswordPolicies, error) {
+	msg, err := c.DoCustomRequestV5(ctx, "GET", "/password-policies/settings.json", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var passwordPolicies PasswordPolicies
+	err = json.Unmarshal(msg.Body, &passwordPolicies)
+	if err != nil {
+		return nil, err
+	}
+	return &passwordPolicies, nil
+}
+
+// DefaultPasswordPolicies returns the policies Passbolt uses when the password policies plugin is not installed
+func DefaultPasswordPolicies() PasswordPolicies {
+	return PasswordPolicies{
+		DefaultGenerator: "password",
+		Source:           "default",
+		PasswordGeneratorSettings: PasswordGeneratorSettings{
+			Length:                18,
+			MinLength:             8,
+			MaxLength:             128,
+			MaskUpper:             true,
+			MaskLower:             true,
+			MaskDigit:             true,
+			MaskParenthesis:       true,
+			MaskChar1:             true,
+			MaskChar2:             true,
+			MaskChar3:             true,
+			MaskChar4:             true,
+			MaskChar5:             true,
+			ExcludeLookAlikeChars: true,
+		},
+		PassphraseGeneratorSettings: PassphraseGeneratorSettings{
+			Words:         9,
+			MinWords:      4,
+			MaxWords:      40,
+			WordSeparator: " ",
+			WordCase:      "lowercase",
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetPasswordPolicies(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t, route{
+		method: "GET", path: "/password-policies/settings.json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			writeAPIResponse(t, w, map[string]any{
+				"default_generator": "passphrase",
+				"password_generator_settings": map[string]any{
+					"length": 24, "mask_upper": true, "mask_emoji": false, "exclude_look_alike_chars": true,
+				},
+				"passphrase_generator_settings": map[string]any{
+					"words": 7, "word_separator": "_", "word_case": "camelcase",
+				},
+			})
+		},
+	})
+
+	policies, err := client.GetPasswordPolicies(bg())
+	if err != nil {
+		t.Fatalf("GetPasswordPolicies: %v", err)
+	}
+	if policies.DefaultGenerator != "passphrase" || policies.PasswordGeneratorSettings.Length != 24 || !policies.PasswordGeneratorSettings.ExcludeLookAlikeChars {
+		t.Errorf("password settings = %+v", policies)
+	}
+	if policies.PassphraseGeneratorSettings.Words != 7 || policies.PassphraseGeneratorSettings.WordCase != "camelcase" {
+		t.Errorf("passphrase settings = %+v", policies.PassphraseGeneratorSettings)
+	}
+}
@@ -35,13 +35,10 @@ type Resource struct {
 	Secrets []Secret `json:"secrets,omitempty"`
 	Tags    []Tag    `json:"tags,omitempty"`
 	Expired *Time    `json:"expired,omitempty"`
-}
 
-// Tag is a Passbolt Password Tag
-type Tag struct {
-	ID       string `json:"id,omitempty"`
-	Slug     string `json:"slug,omitempty"`
-	IsShared bool   `json:"is_shared,omitempty"`
+	// Permissions of all Users and Groups, returned with ContainPermissionsUserProfile or ContainPermissionsGroup.
+	// ContainPermissions only fills Permission, the current User's own Permission
+	Permissions []Permission `json:"permissions,omitempty"`
 }
 
 // GetResourcesOptions are all available query parameters
@@ -24,8 +24,8 @@ type SecretDataTOTP struct {
 	Period    int    `json:"period"`
 }
 
-// Deprecated: marshal/unmarshal secret data via map[string]any with helper.GetResourceFieldMaps
-// or helper.CreateResourceGeneric instead. Will be removed in a future major version.
+// Deprecated: use helper.V4PasswordAndDescriptionSecret with helper.CreateTyped and helper.GetTyped instead.
+// Will be removed in a future major version.
 //
 // SecretDataTypePasswordAndDescription is the format a secret of resource type "password-and-description" is stored in
 type SecretDataTypePasswordAndDescription struct {
@@ -33,16 +33,16 @@ type SecretDataTypePasswordAndDescription struct {
 	Description string `json:"description,omitempty"`
 }
 
-// Deprecated: marshal/unmarshal secret data via map[string]any with helper.GetResourceFieldMaps
-// or helper.CreateResourceGeneric instead. Will be removed in a future major version.
+// Deprecated: use helper.V4TOTPSecret with helper.CreateTyped and helper.GetTyped instead.
+// Will be removed in a future major version.
 //
 // SecretDataTypeTOTP is the format a secret of resource type "totp" is stored in
 type SecretDataTypeTOTP struct {
 	TOTP SecretDataTOTP `json:"totp"`
 }
 
-// Deprecated: marshal/unmarshal secret data via map[string]any with helper.GetResourceFieldMaps
-// or helper.CreateResourceGeneric instead. Will be removed in a future major version.
+// Deprecated: use helper.V4PasswordDescriptionTOTPSecret with helper.CreateTyped and helper.GetTyped instead.
+// Will be removed in a future major version.
 //
 // SecretDataTypePasswordDescriptionTOTP is the format a secret of resource type "password-description-totp" is stored in
 type SecretDataTypePasswordDescriptionTOTP struct {
@@ -51,8 +51,8 @@ type SecretDataTypePasswordDescriptionTOTP struct {
 	TOTP        SecretDataTOTP `json:"totp"`
 }
 
-// Deprecated: marshal/unmarshal secret data via map[string]any with helper.GetResourceFieldMaps
-// or helper.CreateResourceGeneric instead. Will be removed in a future major version.
+// Deprecated: use helper.V5DefaultSecret with helper.CreateTyped and helper.GetTyped instead.
+// Will be removed in a future major version.
 //
 // SecretDataTypeV5Default represents the secret data for a V5 default resource.
 type SecretDataTypeV5Default struct {
@@ -62,8 +62,8 @@ type SecretDataTypeV5Default struct {
 	Description    string `json:"description,omitempty"`
 }
 
-// Deprecated: marshal/unmarshal secret data via map[string]any with helper.GetResourceFieldMaps
-// or helper.CreateResourceGeneric instead. Will be removed in a future major version.
+// Deprecated: use helper.V5DefaultWithTOTPSecret with helper.CreateTyped and helper.GetTyped instead.
+// Will be removed in a future major version.
 //
 // SecretDataTypeV5DefaultWithTOTP represents the secret data for a V5 default resource with TOTP.
 type SecretDataTypeV5DefaultWithTOTP struct {
@@ -74,14 +74,14 @@ type SecretDataTypeV5DefaultWithTOTP struct {
 	TOTP           SecretDataTOTP `json:"totp"`
 }
 
-// Deprecated: marshal/unmarshal secret data via map[string]any with helper.GetResourceFieldMaps
-// or helper.CreateResourceGeneric instead. Will be removed in a future major version.
+// Deprecated: use helper.V5PasswordStringSecret with helper.CreateTyped and helper.GetTyped instead.
+// Will be removed in a future major version.
 //
 // SecretDataTypeV5PasswordString is just the password directly.
 type SecretDataTypeV5PasswordString string
 
-// Deprecated: marshal/unmarshal secret data via map[string]any with helper.GetResourceFieldMaps
-// or helper.CreateResourceGeneric instead. Will be removed in a future major version.
+// Deprecated: use helper.V5TOTPStandaloneSecret with helper.CreateTyped and helper.GetTyped instead.
+// Will be removed in a future major version.
 //
 // SecretDataTypeV5TOTPStandalone represents the secret data for a V5 standalone TOTP resource.
 type SecretDataTypeV5TOTPStandalone struct {
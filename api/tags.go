@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tag is a Passbolt Password Tag
+type Tag struct {
+	ID       string `json:"id,omitempty"`
+	Slug     string `json:"slug,omitempty"`
+	IsShared bool   `json:"is_shared,omitempty"`
+
+	MetadataKeyID   string          `json:"metadata_key_id,omitempty"`
+	MetadataKeyType MetadataKeyType `json:"metadata_key_type,omitempty"`
+	Metadata        string          `json:"metadata,omitempty"`
+}
+
+// TagMetadata is the cleartext content of a v5 Tag's encrypted Metadata
+type TagMetadata struct {
+	// ObjectType Must always be PASSBOLT_TAG_METADATA
+	ObjectType string `json:"object_type"`
+	Slug       string `json:"slug"`
+}
+
+// GetTagsOptions are all available query parameters
+type GetTagsOptions struct {
+	FilterSearch string `url:"filter[search],omitempty"`
+}
+
+// GetTags gets all Tags the current User can see
+func (c *Client) GetTags(ctx context.Context, opts *GetTagsOptions) ([]Tag, error) {
+	return doList[Tag](ctx, c, "/tags.json", opts)
+}
+
+// SetResourceTags replaces all Tags of a Resource with v4 cleartext Tags, slugs starting with # are shared Tags
+func (c *Client) SetResourceTags(ctx context.Context, resourceID string, slugs []string) ([]Tag, error) {
+	if err := checkUUIDFormat(resourceID); err != nil {
+		return nil, fmt.Errorf("checking ID format: %w", err)
+	}
+	body := struct {
+		Tags []string `json:"tags"`
+	}{
+		Tags: slugs,
+	}
+	tags, err := doInto[[]Tag](ctx, c, "POST", "/tags/"+resourceID+".json", body, nil)
+	if err != nil {
+		return nil, err
+	}
+	return *tags, nil
+}
+
+// SetResourceTagsV5 replaces all Tags of a Resource, existing Tags are referenced by ID and new Tags carry encrypted Metadata
+func (c *Client) SetResourceTagsV5(ctx context.Context, resourceID string, tags []Tag) ([]Tag, error) {
+	if err := checkUUIDFormat(resourceID); err != nil {
+		return nil, fmt.Errorf("checking ID format: %w", err)
+	}
+	body := struct {
+		Tags []Tag `json:"tags"`
+	}{
+		Tags: tags,
+	}
+	result, err := doInto[[]Tag](ctx, c, "POST", "/tags/"+resourceID+".json", body, nil)
+	if err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+// UpdateTag Updates a existing Tag, this renames it for every Resource it is attached to
+func (c *Client) UpdateTag(ctx context.Context, tagID string, tag Tag) (*Tag, error) {
+	if err := checkUUIDFormat(tagID); err != nil {
+		return nil, fmt.Errorf("checking ID format: %w", err)
+	}
+	return doSave(ctx, c, "PUT", "/tags/"+tagID+".json", tag)
+}
+
+// DeleteTag Deletes a Tag, this removes it from every Resource it is attached to
+func (c *Client) DeleteTag(ctx context.Context, tagID string) error {
+	if err := checkUUIDFormat(tagID); err != nil {
+		return fmt.Errorf("checking ID format: %w", err)
+	}
+	return doDelete(ctx, c, "/tags/"+tagID+".json")
+}
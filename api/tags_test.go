@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// The tag endpoints share one URL for two wire formats: v4 clients
+// send a flat array of slugs, v5 clients send tag objects carrying
+// encrypted metadata. Mixing them up would make the server create a
+// tag literally named after a JSON object, or drop the encrypted tag.
+
+func TestSetResourceTags_SendsSlugArray(t *testing.T) {
+	t.Parallel()
+
+	var seen map[string]json.RawMessage
+	_, client := newTestClient(t, route{
+		method: "POST", path: "/tags/" + validUUID + ".json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			readJSONBody(t, r, &seen)
+			writeAPIResponse(t, w, []Tag{{ID: otherUUID, Slug: "#prod", IsShared: true}})
+		},
+	})
+
+	got, err := client.SetResourceTags(bg(), validUUID, []string{"#prod", "mine"})
+	if err != nil {
+		t.Fatalf("SetResourceTags: %v", err)
+	}
+	if string(seen["tags"]) != `["#prod","mine"]` {
+		t.Errorf("server saw tags=%s, want slug array", seen["tags"])
+	}
+	if len(got) != 1 || !got[0].IsShared {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestSetResourceTagsV5_SendsTagObjects(t *testing.T) {
+	t.Parallel()
+
+	var seen struct {
+		Tags []Tag `json:"tags"`
+	}
+	_, client := newTestClient(t, route{
+		method: "POST", path: "/tags/" + validUUID + ".json",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			readJSONBody(t, r, &seen)
+			writeAPIResponse(t, w, seen.Tags)
+		},
+	})
+
+	_, err := client.SetResourceTagsV5(bg(), validUUID, []Tag{
+		{ID: otherUUID},
+		{Metadata: "-----BEGIN PGP MESSAGE-----", MetadataKeyID: otherUUID, MetadataKeyType: MetadataKeyTypeSharedKey},
+	})
+	if err != nil {
+		t.Fatalf("SetResourceTagsV5: %v", err)
+	}
+	if len(seen.Tags) != 2 {
+		t.Fatalf("server saw %d tags, want 2", len(seen.Tags))
+	}
+	if seen.Tags[0].ID != otherUUID || seen.Tags[0].Metadata != "" {
+		t.Errorf("existing tag should only be referenced by ID, got %+v", seen.Tags[0])
+	}
+	if seen.Tags[1].Slug != "" || seen.Tags[1].MetadataKeyType != MetadataKeyTypeSharedKey {
+		t.Errorf("new v5 tag must not leak a cleartext slug, got %+v", seen.Tags[1])
+	}
+}
+
+func TestTagEndpoints_RejectInvalidUUIDs(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestClient(t)
+	if _, err := client.SetResourceTags(bg(), "not-a-uuid", nil); err == nil {
+		t.Error("SetResourceTags accepted an invalid resource ID")
+	}
+	if _, err := client.UpdateTag(bg(), "not-a-uuid", Tag{}); err == nil {
+		t.Error("UpdateTag accepted an invalid tag ID")
+	}
+	if err := client.DeleteTag(bg(), "not-a-uuid"); err == nil {
+		t.Error("DeleteTag accepted an invalid tag ID")
+	}
+}
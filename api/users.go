@@ -107,3 +107,17 @@ func (c *Client) DeleteUserDryrun(ctx context.Context, userID string) error {
 	}
 	return doDelete(ctx, c, "/users/"+userID+"/dry-run.json")
 }
+
+// DeleteUserDryrunBlockers Checks if a Passbolt User is Deleteable and returns the Resources, Folders and Groups blocking the deletion
+func (c *Client) DeleteUserDryrunBlockers(ctx context.Context, userID string) (*DeleteDryRunBlockers, error) {
+	return deleteDryRunBlockers(c.DeleteUserDryrun(ctx, userID))
+}
+
+// DeleteUserWithTransfer Deletes a Passbolt User and hands over the ownership of its Resources and Folders and the management of its Groups
+func (c *Client) DeleteUserWithTransfer(ctx context.Context, userID string, transfer DeleteTransfer) error {
+	if err := checkUUIDFormat(userID); err != nil {
+		return fmt.Errorf("checking ID format: %w", err)
+	}
+	_, err := c.DoCustomRequest(ctx, "DELETE", "/users/"+userID+".json", "v2", deleteTransferRequest{Transfer: transfer}, nil)
+	return err
+}
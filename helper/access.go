@@ -0,0 +1,254 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// AccessSource is how a permission reaches a User
+type AccessSource string
+
+const (
+	// AccessSourceDirect is a permission for the User itself
+	AccessSourceDirect AccessSource = "direct"
+	// AccessSourceGroup is a permission for a Group the User is a member of
+	AccessSourceGroup AccessSource = "group"
+	// AccessSourceFolder is a permission inherited from a parent Folder with the same permission,
+	// for the User itself or, if GroupID is set, for one of its Groups
+	AccessSourceFolder AccessSource = "folder"
+)
+
+// AccessGrant is a single permission that gives a User access
+type AccessGrant struct {
+	// Type of Permission: 1 = Read, 7 = can Update, 15 = Owner
+	Type   int          `json:"type"`
+	Source AccessSource `json:"source"`
+	// GroupID is the Group the permission is for, empty for permissions of the User itself
+	GroupID string `json:"group_id,omitempty"`
+	// FolderID is the Folder the permission is inherited from, only set for AccessSourceFolder
+	FolderID string `json:"folder_id,omitempty"`
+}
+
+// UserAccess is the effective permission of a User on a Resource or Folder
+type UserAccess struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	// Type is the highest permission Type of all Grants
+	Type   int           `json:"type"`
+	Grants []AccessGrant `json:"grants"`
+}
+
+// ResourceAccess is the effective permission of a User on a Resource
+type ResourceAccess struct {
+	ResourceID     string `json:"resource_id"`
+	Name           string `json:"name"`
+	FolderParentID string `json:"folder_parent_id,omitempty"`
+	// Type is the highest permission Type of all Grants
+	Type   int           `json:"type"`
+	Grants []AccessGrant `json:"grants"`
+}
+
+// accessIndex is what is needed to resolve permissions to Users
+type accessIndex struct {
+	members    map[string][]string
+	groupNames map[string]string
+	folders    map[string]api.Folder
+	usernames  map[string]string
+}
+
+// GetResourceAccess returns every User that can access a Resource with their effective permission and how it was granted
+func GetResourceAccess(ctx context.Context, c *api.Client, resourceID string) ([]UserAccess, error) {
+	resource, err := c.GetResource(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource: %w", err)
+	}
+	permissions, err := c.GetResourcePermissions(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Permissions: %w", err)
+	}
+	index, err := getAccessIndex(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return index.effectiveAccess(permissions, resource.FolderParentID), nil
+}
+
+// GetFolderAccess returns every User that can access a Folder with their effective permission and how it was granted
+func GetFolderAccess(ctx context.Context, c *api.Client, folderID string) ([]UserAccess, error) {
+	folder, err := c.GetFolder(ctx, folderID, &api.GetFolderOptions{
+		ContainPermissions: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Folder: %w", err)
+	}
+	index, err := getAccessIndex(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return index.effectiveAccess(folder.Permissions, folder.FolderParentID), nil
+}
+
+// GetUserResourceAccess returns every Resource a User can access with the effective permission and how it was granted.
+// Only Resources the client can see are included
+func GetUserResourceAccess(ctx context.Context, c *api.Client, userID string) ([]ResourceAccess, error) {
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		ContainPermissionsUserProfile: true,
+		ContainPermissionsGroup:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+	index, err := getAccessIndex(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	access := index.userResourceAccess(resources, userID)
+	for i := range access {
+		for _, r := range resources {
+			if r.ID == access[i].ResourceID {
+				access[i].Name, _ = resourceNameAndURI(c, r, rTypes)
+				break
+			}
+		}
+	}
+	sort.SliceStable(access, func(i, j int) bool { return access[i].Name < access[j].Name })
+	return access, nil
+}
+
+func getAccessIndex(ctx context.Context, c *api.Client) (*accessIndex, error) {
+	groups, err := c.GetGroups(ctx, &api.GetGroupsOptions{
+		ContainGroupsUsers: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Groups: %w", err)
+	}
+	folders, err := c.GetFolders(ctx, &api.GetFoldersOptions{
+		ContainPermissions: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Folders: %w", err)
+	}
+	users, err := c.GetUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Users: %w", err)
+	}
+	return newAccessIndex(groups, folders, users), nil
+}
+
+func newAccessIndex(groups []api.Group, folders []api.Folder, users []api.User) *accessIndex {
+	index := &accessIndex{
+		members:    map[string][]string{},
+		groupNames: map[string]string{},
+		folders:    map[string]api.Folder{},
+		usernames:  map[string]string{},
+	}
+	for _, g := range groups {
+		index.groupNames[g.ID] = g.Name
+		for _, m := range g.GroupUsers {
+			index.members[g.ID] = append(index.members[g.ID], m.UserID)
+		}
+	}
+	for _, f := range folders {
+		index.folders[f.ID] = f
+	}
+	for _, u := range users {
+		index.usernames[u.ID] = u.Username
+	}
+	return index
+}
+
+// effectiveAccess resolves permissions on an item in folderParentID to the Users they reach
+func (index *accessIndex) effectiveAccess(permissions []api.Permission, folderParentID string) []UserAccess {
+	byUser := map[string]*UserAccess{}
+	for _, p := range permissions {
+		grant := AccessGrant{Type: p.Type, Source: AccessSourceDirect}
+		if folderID := index.inheritedFrom(p, folderParentID); folderID != "" {
+			grant.Source = AccessSourceFolder
+			grant.FolderID = folderID
+		}
+
+		var userIDs []string
+		switch p.ARO {
+		case "User":
+			userIDs = []string{p.AROForeignKey}
+		case "Group":
+			grant.GroupID = p.AROForeignKey
+			if grant.Source == AccessSourceDirect {
+				grant.Source = AccessSourceGroup
+			}
+			userIDs = index.members[p.AROForeignKey]
+		default:
+			continue
+		}
+
+		for _, userID := range userIDs {
+			access, ok := byUser[userID]
+			if !ok {
+				access = &UserAccess{UserID: userID, Username: index.usernames[userID]}
+				byUser[userID] = access
+			}
+			access.Grants = append(access.Grants, grant)
+			access.Type = max(access.Type, grant.Type)
+		}
+	}
+
+	result := []UserAccess{}
+	for _, access := range byUser {
+		result = append(result, *access)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Username != result[j].Username {
+			return result[i].Username < result[j].Username
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	return result
+}
+
+// userResourceAccess is the reverse of effectiveAccess, the Resources userID can access
+func (index *accessIndex) userResourceAccess(resources []api.Resource, userID string) []ResourceAccess {
+	result := []ResourceAccess{}
+	for _, r := range resources {
+		for _, access := range index.effectiveAccess(r.Permissions, r.FolderParentID) {
+			if access.UserID != userID {
+				continue
+			}
+			result = append(result, ResourceAccess{
+				ResourceID:     r.ID,
+				Name:           r.Name,
+				FolderParentID: r.FolderParentID,
+				Type:           access.Type,
+				Grants:         access.Grants,
+			})
+		}
+	}
+	return result
+}
+
+// inheritedFrom returns the nearest parent Folder with the same permission as p, empty if there is none.
+// Passbolt applies the permissions of a Folder to what is moved into or shared in it, this finds where they came from
+func (index *accessIndex) inheritedFrom(p api.Permission, folderParentID string) string {
+	seen := map[string]bool{}
+	for folderID := folderParentID; folderID != "" && !seen[folderID]; {
+		seen[folderID] = true
+		folder, ok := index.folders[folderID]
+		if !ok {
+			return ""
+		}
+		for _, fp := range folder.Permissions {
+			if fp.ARO == p.ARO && fp.AROForeignKey == p.AROForeignKey && fp.Type == p.Type {
+				return folderID
+			}
+		}
+		folderID = folder.FolderParentID
+	}
+	return ""
+}
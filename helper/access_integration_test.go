@@ -0,0 +1,94 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestResourceAccess(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	groupID, err := CreateGroup(ctx, client, "access", []GroupMembershipOperation{
+		{UserID: client.GetUserID(), IsGroupManager: true},
+	})
+	if err != nil {
+		t.Fatalf("Creating Group %v", err)
+	}
+	defer func() { _ = DeleteGroup(ctx, client, groupID) }()
+
+	id, err := CreateResource(ctx, client, "", "access", "", "", "secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+	err = ShareResourceWithUsersAndGroups(ctx, client, id, nil, []string{groupID}, 1)
+	if err != nil {
+		t.Fatalf("Sharing Resource %v", err)
+	}
+
+	access, err := GetResourceAccess(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource Access %v", err)
+	}
+	if len(access) != 1 || access[0].UserID != client.GetUserID() || access[0].Type != 15 {
+		t.Fatalf("access = %+v", access)
+	}
+	if !slices.ContainsFunc(access[0].Grants, func(g AccessGrant) bool { return g.Source == AccessSourceGroup && g.GroupID == groupID }) {
+		t.Errorf("group grant missing: %+v", access[0].Grants)
+	}
+
+	resources, err := GetUserResourceAccess(ctx, client, client.GetUserID())
+	if err != nil {
+		t.Fatalf("Getting User Resource Access %v", err)
+	}
+	if !slices.ContainsFunc(resources, func(r ResourceAccess) bool { return r.ResourceID == id && r.Name == "access" && r.Type == 15 }) {
+		t.Errorf("resource %v missing: %+v", id, resources)
+	}
+}
+
+func TestGetAccessMatrix(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	folderID, err := CreateFolder(ctx, client, "", "matrix")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+	id, err := CreateResource(ctx, client, folderID, "matrix resource", "", "", "secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	before, err := GetAccessMatrix(ctx, client)
+	if err != nil {
+		t.Fatalf("Getting Access Matrix %v", err)
+	}
+	if !slices.ContainsFunc(before.Entries, func(e AccessMatrixEntry) bool {
+		return e.ResourceID == id && e.ResourceName == "matrix resource" && e.FolderPath == "matrix" && e.Permission == "owner"
+	}) {
+		t.Errorf("resource %v missing: %+v", id, before.Entries)
+	}
+
+	err = DeleteResource(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Deleting Resource %v", err)
+	}
+	after, err := GetAccessMatrix(ctx, client)
+	if err != nil {
+		t.Fatalf("Getting Access Matrix %v", err)
+	}
+	diff := DiffAccessMatrix(before, after)
+	if len(diff.Revoked) != 1 || diff.Revoked[0].ResourceID != id {
+		t.Errorf("revoked = %+v", diff.Revoked)
+	}
+}
@@ -0,0 +1,282 @@
+package helper
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// AccessMatrixEntry is the effective permission of one User on one Resource. It never contains secrets
+type AccessMatrixEntry struct {
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	ResourceID   string `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+	// FolderPath is the "/" separated path of the parent Folders, empty for the root
+	FolderPath string `json:"folder_path"`
+	// Permission is "read", "update" or "owner"
+	Permission string `json:"permission"`
+	// Via lists how access is granted: "direct", "group <name>" or "folder <path>"
+	Via []string `json:"via"`
+}
+
+// AccessMatrix is a users by resources permission matrix
+type AccessMatrix struct {
+	Created time.Time           `json:"created"`
+	Entries []AccessMatrixEntry `json:"entries"`
+}
+
+// AccessMatrixChange is a permission that changed between two AccessMatrix snapshots
+type AccessMatrixChange struct {
+	Old AccessMatrixEntry `json:"old"`
+	New AccessMatrixEntry `json:"new"`
+}
+
+// AccessMatrixDiff is the difference between two AccessMatrix snapshots
+type AccessMatrixDiff struct {
+	Granted []AccessMatrixEntry  `json:"granted"`
+	Revoked []AccessMatrixEntry  `json:"revoked"`
+	Changed []AccessMatrixChange `json:"changed"`
+}
+
+// GetAccessMatrix builds the permission matrix of every Resource the client can see, with group permissions expanded to their members
+func GetAccessMatrix(ctx context.Context, c *api.Client) (*AccessMatrix, error) {
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		ContainPermissions:            true,
+		ContainPermissionsUserProfile: true,
+		ContainPermissionsGroup:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+	index, err := getAccessIndex(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	folderNames := map[string]string{}
+	for id, folder := range index.folders {
+		_, name, err := GetFolderFromData(ctx, c, folder)
+		if err != nil {
+			name = id
+		}
+		folderNames[id] = name
+	}
+
+	names := map[string]string{}
+	for _, r := range resources {
+		names[r.ID], _ = resourceNameAndURI(c, r, rTypes)
+	}
+
+	matrix := index.accessMatrix(resources, names, folderNames)
+	matrix.Created = time.Now()
+	return matrix, nil
+}
+
+// accessMatrix expands the permissions of resources with resource and folder names already resolved
+func (index *accessIndex) accessMatrix(resources []api.Resource, names, folderNames map[string]string) *AccessMatrix {
+	matrix := &AccessMatrix{Entries: []AccessMatrixEntry{}}
+	for _, r := range resources {
+		for _, access := range index.effectiveAccess(r.Permissions, r.FolderParentID) {
+			entry := AccessMatrixEntry{
+				UserID:       access.UserID,
+				Username:     access.Username,
+				ResourceID:   r.ID,
+				ResourceName: names[r.ID],
+				FolderPath:   index.folderPath(r.FolderParentID, folderNames),
+				Permission:   permissionTypeName(access.Type),
+				Via:          []string{},
+			}
+			for _, g := range access.Grants {
+				var via string
+				switch {
+				case g.Source == AccessSourceFolder:
+					via = "folder " + index.folderPath(g.FolderID, folderNames)
+				case g.GroupID != "":
+					via = "group " + index.groupNames[g.GroupID]
+				default:
+					via = string(AccessSourceDirect)
+				}
+				if !slices.Contains(entry.Via, via) {
+					entry.Via = append(entry.Via, via)
+				}
+			}
+			matrix.Entries = append(matrix.Entries, entry)
+		}
+	}
+	sortAccessMatrixEntries(matrix.Entries)
+	return matrix
+}
+
+// folderPath joins the names of folderID and its parents
+func (index *accessIndex) folderPath(folderID string, folderNames map[string]string) string {
+	path := []string{}
+	seen := map[string]bool{}
+	for folderID != "" && !seen[folderID] {
+		seen[folderID] = true
+		name, ok := folderNames[folderID]
+		if !ok {
+			name = folderID
+		}
+		path = append([]string{name}, path...)
+		folderID = index.folders[folderID].FolderParentID
+	}
+	return strings.Join(path, "/")
+}
+
+// WriteJSON writes the matrix as JSON, it can be read back with ReadAccessMatrixJSON
+func (m *AccessMatrix) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// ReadAccessMatrixJSON reads a matrix written by WriteJSON
+func ReadAccessMatrixJSON(r io.Reader) (*AccessMatrix, error) {
+	var m AccessMatrix
+	err := json.NewDecoder(r).Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("decoding Access Matrix: %w", err)
+	}
+	return &m, nil
+}
+
+// WriteCSV writes the matrix with one row per Resource and one column per User, cells contain the permission
+func (m *AccessMatrix) WriteCSV(w io.Writer) error {
+	type column struct{ id, username string }
+	columns := []column{}
+	rows := []AccessMatrixEntry{}
+	cells := map[[2]string]string{}
+	for _, e := range m.Entries {
+		if !slices.ContainsFunc(columns, func(c column) bool { return c.id == e.UserID }) {
+			columns = append(columns, column{e.UserID, e.Username})
+		}
+		if !slices.ContainsFunc(rows, func(r AccessMatrixEntry) bool { return r.ResourceID == e.ResourceID }) {
+			rows = append(rows, e)
+		}
+		cells[[2]string{e.ResourceID, e.UserID}] = e.Permission
+	}
+	sort.SliceStable(columns, func(i, j int) bool { return columns[i].username < columns[j].username })
+
+	cw := csv.NewWriter(w)
+	header := []string{"folder_path", "resource"}
+	for _, c := range columns {
+		header = append(header, c.username)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{r.FolderPath, r.ResourceName}
+		for _, c := range columns {
+			record = append(record, cells[[2]string{r.ResourceID, c.id}])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// DiffAccessMatrix returns the access granted, revoked and changed from old to new
+func DiffAccessMatrix(old, new *AccessMatrix) AccessMatrixDiff {
+	key := func(e AccessMatrixEntry) [2]string { return [2]string{e.ResourceID, e.UserID} }
+	oldEntries := map[[2]string]AccessMatrixEntry{}
+	for _, e := range old.Entries {
+		oldEntries[key(e)] = e
+	}
+
+	diff := AccessMatrixDiff{
+		Granted: []AccessMatrixEntry{},
+		Revoked: []AccessMatrixEntry{},
+		Changed: []AccessMatrixChange{},
+	}
+	seen := map[[2]string]bool{}
+	for _, e := range new.Entries {
+		seen[key(e)] = true
+		o, ok := oldEntries[key(e)]
+		switch {
+		case !ok:
+			diff.Granted = append(diff.Granted, e)
+		case o.Permission != e.Permission:
+			diff.Changed = append(diff.Changed, AccessMatrixChange{Old: o, New: e})
+		}
+	}
+	for _, e := range old.Entries {
+		if !seen[key(e)] {
+			diff.Revoked = append(diff.Revoked, e)
+		}
+	}
+	sortAccessMatrixEntries(diff.Granted)
+	sortAccessMatrixEntries(diff.Revoked)
+	return diff
+}
+
+// Empty returns true if nothing changed
+func (d AccessMatrixDiff) Empty() bool {
+	return len(d.Granted) == 0 && len(d.Revoked) == 0 && len(d.Changed) == 0
+}
+
+// WriteText writes the diff as a human readable list
+func (d AccessMatrixDiff) WriteText(w io.Writer) error {
+	line := func(e AccessMatrixEntry) string {
+		return fmt.Sprintf("%v: %v (%v)", e.Username, path.Join(e.FolderPath, e.ResourceName), e.Permission)
+	}
+	var b strings.Builder
+	for _, e := range d.Granted {
+		fmt.Fprintf(&b, "+ %v\n", line(e))
+	}
+	for _, e := range d.Revoked {
+		fmt.Fprintf(&b, "- %v\n", line(e))
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %v -> %v\n", line(c.Old), c.New.Permission)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortAccessMatrixEntries(entries []AccessMatrixEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.FolderPath != b.FolderPath {
+			return a.FolderPath < b.FolderPath
+		}
+		if a.ResourceName != b.ResourceName {
+			return a.ResourceName < b.ResourceName
+		}
+		if a.ResourceID != b.ResourceID {
+			return a.ResourceID < b.ResourceID
+		}
+		return a.Username < b.Username
+	})
+}
+
+// permissionTypeName returns the name of a Permission Type
+func permissionTypeName(t int) string {
+	switch t {
+	case 1:
+		return "read"
+	case 7:
+		return "update"
+	case 15:
+		return "owner"
+	default:
+		return strconv.Itoa(t)
+	}
+}
@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func testAccessMatrix() *AccessMatrix {
+	resources := []api.Resource{
+		{ID: "r1", FolderParentID: "child", Permissions: []api.Permission{
+			{ARO: "User", AROForeignKey: "ada", Type: 15},
+			{ARO: "Group", AROForeignKey: "ops", Type: 7},
+		}},
+		{ID: "r2", Permissions: []api.Permission{{ARO: "User", AROForeignKey: "eve", Type: 1}}},
+	}
+	names := map[string]string{"r1": "database", "r2": "wifi"}
+	folderNames := map[string]string{"root": "Team", "child": "Infra"}
+	return testAccessIndex().accessMatrix(resources, names, folderNames)
+}
+
+func TestAccessMatrix(t *testing.T) {
+	t.Parallel()
+
+	m := testAccessMatrix()
+	if len(m.Entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(m.Entries), m.Entries)
+	}
+	// Root resources sort before resources in folders
+	if e := m.Entries[0]; e.ResourceName != "wifi" || e.Username != "eve@example.com" || e.Permission != "read" || e.FolderPath != "" {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if e := m.Entries[1]; e.Username != "ada@example.com" || e.FolderPath != "Team/Infra" || e.Permission != "owner" || e.Via[0] != "folder Team/Infra" {
+		t.Errorf("entry 1 = %+v", e)
+	}
+	if e := m.Entries[2]; e.Username != "bob@example.com" || e.Permission != "update" || e.Via[0] != "group Ops" {
+		t.Errorf("entry 2 = %+v", e)
+	}
+}
+
+func TestAccessMatrix_WriteCSV(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := testAccessMatrix().WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	want := "folder_path,resource,ada@example.com,bob@example.com,eve@example.com\n" +
+		",wifi,,,read\n" +
+		"Team/Infra,database,owner,update,\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestAccessMatrix_JSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	m := testAccessMatrix()
+	if err := m.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if strings.Contains(buf.String(), "password") || strings.Contains(buf.String(), "secret") {
+		t.Errorf("matrix JSON mentions secrets: %s", buf.String())
+	}
+	read, err := ReadAccessMatrixJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadAccessMatrixJSON: %v", err)
+	}
+	if !DiffAccessMatrix(m, read).Empty() {
+		t.Errorf("round trip changed the matrix: %+v", DiffAccessMatrix(m, read))
+	}
+}
+
+func TestDiffAccessMatrix(t *testing.T) {
+	t.Parallel()
+
+	entry := func(user, resource, permission string) AccessMatrixEntry {
+		return AccessMatrixEntry{UserID: user, Username: user, ResourceID: resource, ResourceName: resource, Permission: permission}
+	}
+	old := &AccessMatrix{Entries: []AccessMatrixEntry{
+		entry("ada", "db", "owner"),
+		entry("bob", "db", "read"),
+		entry("eve", "wifi", "read"),
+	}}
+	current := &AccessMatrix{Entries: []AccessMatrixEntry{
+		entry("ada", "db", "owner"),
+		entry("bob", "db", "update"),
+		entry("mallory", "db", "read"),
+	}}
+
+	diff := DiffAccessMatrix(old, current)
+	if len(diff.Granted) != 1 || diff.Granted[0].UserID != "mallory" {
+		t.Errorf("granted = %+v", diff.Granted)
+	}
+	if len(diff.Revoked) != 1 || diff.Revoked[0].UserID != "eve" {
+		t.Errorf("revoked = %+v", diff.Revoked)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Old.Permission != "read" || diff.Changed[0].New.Permission != "update" {
+		t.Errorf("changed = %+v", diff.Changed)
+	}
+
+	var buf bytes.Buffer
+	if err := diff.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	want := "+ mallory: db (read)\n- eve: wifi (read)\n~ bob: db (read) -> update\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,105 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// Each user appears once with the highest permission, but every path that
+// grants access is kept so "why can they read this" has a complete answer.
+
+func testAccessIndex() *accessIndex {
+	return newAccessIndex(
+		[]api.Group{
+			{ID: "devs", Name: "Developers", GroupUsers: []api.GroupMembership{{UserID: "ada"}, {UserID: "bob"}}},
+			{ID: "ops", Name: "Ops", GroupUsers: []api.GroupMembership{{UserID: "bob"}}},
+		},
+		[]api.Folder{
+			{ID: "root", Permissions: []api.Permission{{ARO: "Group", AROForeignKey: "devs", Type: 1}}},
+			{ID: "child", FolderParentID: "root", Permissions: []api.Permission{{ARO: "User", AROForeignKey: "ada", Type: 15}}},
+		},
+		[]api.User{{ID: "ada", Username: "ada@example.com"}, {ID: "bob", Username: "bob@example.com"}, {ID: "eve", Username: "eve@example.com"}},
+	)
+}
+
+func TestEffectiveAccess(t *testing.T) {
+	t.Parallel()
+
+	permissions := []api.Permission{
+		{ARO: "User", AROForeignKey: "ada", Type: 15},
+		{ARO: "Group", AROForeignKey: "devs", Type: 1},
+		{ARO: "Group", AROForeignKey: "ops", Type: 7},
+		{ARO: "User", AROForeignKey: "eve", Type: 1},
+	}
+	access := testAccessIndex().effectiveAccess(permissions, "child")
+	if len(access) != 3 {
+		t.Fatalf("got %d users, want 3: %+v", len(access), access)
+	}
+
+	ada, bob, eve := access[0], access[1], access[2]
+	if ada.UserID != "ada" || ada.Type != 15 || len(ada.Grants) != 2 {
+		t.Fatalf("ada = %+v", ada)
+	}
+	if g := ada.Grants[0]; g.Source != AccessSourceFolder || g.FolderID != "child" || g.GroupID != "" {
+		t.Errorf("ada direct grant = %+v, want inherited from child", g)
+	}
+	if g := ada.Grants[1]; g.Source != AccessSourceFolder || g.FolderID != "root" || g.GroupID != "devs" {
+		t.Errorf("ada group grant = %+v, want devs inherited from root", g)
+	}
+
+	if bob.UserID != "bob" || bob.Type != 7 || len(bob.Grants) != 2 {
+		t.Fatalf("bob = %+v", bob)
+	}
+	if g := bob.Grants[1]; g.Source != AccessSourceGroup || g.GroupID != "ops" || g.FolderID != "" {
+		t.Errorf("bob ops grant = %+v", g)
+	}
+
+	if eve.Username != "eve@example.com" || eve.Type != 1 || eve.Grants[0].Source != AccessSourceDirect {
+		t.Errorf("eve = %+v", eve)
+	}
+}
+
+func TestEffectiveAccess_DifferentTypeIsNotInherited(t *testing.T) {
+	t.Parallel()
+
+	access := testAccessIndex().effectiveAccess([]api.Permission{{ARO: "Group", AROForeignKey: "devs", Type: 7}}, "child")
+	for _, a := range access {
+		if g := a.Grants[0]; g.Source != AccessSourceGroup || g.FolderID != "" {
+			t.Errorf("%v grant = %+v, want group without folder", a.UserID, g)
+		}
+	}
+}
+
+func TestEffectiveAccess_FolderCycle(t *testing.T) {
+	t.Parallel()
+
+	index := newAccessIndex(nil, []api.Folder{
+		{ID: "a", FolderParentID: "b"},
+		{ID: "b", FolderParentID: "a"},
+	}, nil)
+	access := index.effectiveAccess([]api.Permission{{ARO: "User", AROForeignKey: "ada", Type: 1}}, "a")
+	if len(access) != 1 || access[0].Grants[0].Source != AccessSourceDirect {
+		t.Errorf("access = %+v", access)
+	}
+}
+
+func TestUserResourceAccess(t *testing.T) {
+	t.Parallel()
+
+	resources := []api.Resource{
+		{ID: "r1", Permissions: []api.Permission{{ARO: "Group", AROForeignKey: "ops", Type: 7}}},
+		{ID: "r2", Permissions: []api.Permission{{ARO: "User", AROForeignKey: "ada", Type: 15}}},
+		{ID: "r3", FolderParentID: "root", Permissions: []api.Permission{{ARO: "Group", AROForeignKey: "devs", Type: 1}}},
+	}
+	access := testAccessIndex().userResourceAccess(resources, "bob")
+	if len(access) != 2 {
+		t.Fatalf("got %d resources, want 2: %+v", len(access), access)
+	}
+	if access[0].ResourceID != "r1" || access[0].Type != 7 || access[0].Grants[0].GroupID != "ops" {
+		t.Errorf("r1 = %+v", access[0])
+	}
+	if access[1].ResourceID != "r3" || access[1].Grants[0].Source != AccessSourceFolder || access[1].Grants[0].FolderID != "root" {
+		t.Errorf("r3 = %+v", access[1])
+	}
+}
@@ -0,0 +1,389 @@
+package helper
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// DefaultAuditMinEntropy is the entropy in bits below which the audit reports a password as weak if AuditOptions.MinEntropy is not set
+const DefaultAuditMinEntropy = 80
+
+// AuditOptions configure AuditVault
+type AuditOptions struct {
+	// MinEntropy in bits below which a password is weak, defaults to DefaultAuditMinEntropy
+	MinEntropy float64
+	// ModifiedBefore reports resources not modified since, the zero time disables the check
+	ModifiedBefore time.Time
+	// SensitiveURIs are host glob patterns like "*.bank.example" for which a TOTP is expected,
+	// either in the resource itself or in another resource for the same host
+	SensitiveURIs []string
+	// MaxSharedUsers reports resources more users have access to, 0 disables the check
+	MaxSharedUsers int
+	// BreachProvider reports resources whose password is in a breach corpus, nil disables the check
+	BreachProvider BreachRangeProvider
+}
+
+// AuditResource identifies a resource in an AuditReport. It never contains secrets
+type AuditResource struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	URI      string    `json:"uri,omitempty"`
+	Modified *api.Time `json:"modified,omitempty"`
+	Expired  *api.Time `json:"expired,omitempty"`
+}
+
+// AuditWeakPassword is a resource whose password has low entropy
+type AuditWeakPassword struct {
+	AuditResource
+	Entropy float64 `json:"entropy"`
+}
+
+// AuditSharedResource is a resource more users than AuditOptions.MaxSharedUsers have access to
+type AuditSharedResource struct {
+	AuditResource
+	Users int `json:"users"`
+}
+
+// AuditError is a resource that could not be audited
+type AuditError struct {
+	ResourceID string `json:"resource_id"`
+	Error      string `json:"error"`
+}
+
+// AuditReport is the result of AuditVault
+type AuditReport struct {
+	Created       time.Time           `json:"created"`
+	ResourceCount int                 `json:"resource_count"`
+	Weak          []AuditWeakPassword `json:"weak"`
+	// Reused are groups of resources sharing the same password
+	Reused      [][]AuditResource     `json:"reused"`
+	Old         []AuditResource       `json:"old"`
+	Expired     []AuditResource       `json:"expired"`
+	MissingTOTP []AuditResource       `json:"missing_totp"`
+	OverShared  []AuditSharedResource `json:"over_shared"`
+	// Breached are the IDs of resources whose password is in a breach corpus
+	Breached []string     `json:"breached"`
+	Errors   []AuditError `json:"errors"`
+}
+
+// auditEntry is what the audit keeps of a decrypted resource, the password only as entropy and keyed hash
+type auditEntry struct {
+	resource       AuditResource
+	hosts          []string
+	hasPassword    bool
+	entropy        float64
+	passwordDigest string
+	hasTOTP        bool
+	users          int
+	breached       bool
+	// breachErr is set if the breach check failed, the other checks still apply
+	breachErr error
+}
+
+// AuditVault decrypts every resource the user has access to and reports weak, reused, old,
+// expired, over shared and sensitive resources without TOTP.
+// Passwords are compared by a keyed hash with a random key discarded afterwards, the report never contains secrets
+func AuditVault(ctx context.Context, c *api.Client, opts AuditOptions) (*AuditReport, error) {
+	// The Permissions are only needed to count the Users with access, groups are expanded locally
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		ContainSecret:                 true,
+		ContainPermissionsUserProfile: opts.MaxSharedUsers > 0,
+		ContainPermissionsGroup:       opts.MaxSharedUsers > 0,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	var index *accessIndex
+	if opts.MaxSharedUsers > 0 {
+		index, err = getAccessIndex(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+
+	if opts.BreachProvider != nil {
+		opts.BreachProvider = newCachedRangeProvider(opts.BreachProvider)
+	}
+
+	hashKey := make([]byte, 32)
+	_, err = rand.Read(hashKey)
+	if err != nil {
+		return nil, fmt.Errorf("generating hash key: %w", err)
+	}
+
+	entries := []auditEntry{}
+	auditErrors := []AuditError{}
+	for _, resource := range resources {
+		entry, err := auditResource(ctx, c, resource, rTypes, hashKey, index, opts)
+		if err != nil {
+			auditErrors = append(auditErrors, AuditError{ResourceID: resource.ID, Error: err.Error()})
+			continue
+		}
+		if entry.breachErr != nil {
+			auditErrors = append(auditErrors, AuditError{ResourceID: resource.ID, Error: entry.breachErr.Error()})
+		}
+		entries = append(entries, *entry)
+	}
+
+	report := buildAuditReport(entries, opts, time.Now())
+	report.ResourceCount = len(resources)
+	report.Errors = auditErrors
+	return report, nil
+}
+
+// auditResource decrypts a resource and reduces it to an auditEntry, index is only needed if MaxSharedUsers is set
+func auditResource(ctx context.Context, c *api.Client, resource api.Resource, rTypes []api.ResourceType, hashKey []byte, index *accessIndex, opts AuditOptions) (*auditEntry, error) {
+	rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.ID == resource.ResourceTypeID }, ErrResourceTypeSlugNotFound, resource.ResourceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	var secret api.Secret
+	if len(resource.Secrets) > 0 {
+		secret = resource.Secrets[0]
+	}
+
+	_, metadataFields, secretFields, err := GetResourceFieldMaps(c, resource, secret, *rType, true)
+	if err != nil {
+		return nil, err
+	}
+
+	uris := resourceURIs(metadataFields)
+	entry := auditEntry{
+		resource: AuditResource{
+			ID:       resource.ID,
+			Name:     GetStringField(metadataFields, "name"),
+			Modified: resource.Modified,
+			Expired:  resource.Expired,
+		},
+	}
+	if len(uris) > 0 {
+		entry.resource.URI = uris[0]
+	}
+	for _, uri := range uris {
+		if host := uriHost(uri); host != "" {
+			entry.hosts = append(entry.hosts, host)
+		}
+	}
+
+	if password := GetStringField(secretFields, "password"); password != "" {
+		entry.hasPassword = true
+		entry.entropy = PasswordEntropy(password)
+		mac := hmac.New(sha256.New, hashKey)
+		mac.Write([]byte(password))
+		entry.passwordDigest = string(mac.Sum(nil))
+
+		if opts.BreachProvider != nil {
+			entry.breached, entry.breachErr = IsPasswordBreached(ctx, opts.BreachProvider, password)
+		}
+	}
+	if totp, ok := secretFields["totp"].(map[string]any); ok {
+		entry.hasTOTP = GetStringField(totp, "secret_key") != ""
+	}
+
+	if index != nil {
+		entry.users = len(index.effectiveAccess(resource.Permissions, resource.FolderParentID))
+	}
+	return &entry, nil
+}
+
+// buildAuditReport applies the checks to already reduced entries
+func buildAuditReport(entries []auditEntry, opts AuditOptions, now time.Time) *AuditReport {
+	minEntropy := opts.MinEntropy
+	if minEntropy == 0 {
+		minEntropy = DefaultAuditMinEntropy
+	}
+
+	report := &AuditReport{
+		Created:       now,
+		ResourceCount: len(entries),
+		Weak:          []AuditWeakPassword{},
+		Reused:        [][]AuditResource{},
+		Old:           []AuditResource{},
+		Expired:       []AuditResource{},
+		MissingTOTP:   []AuditResource{},
+		OverShared:    []AuditSharedResource{},
+		Breached:      []string{},
+		Errors:        []AuditError{},
+	}
+
+	byDigest := map[string][]AuditResource{}
+	digests := []string{}
+	totpHosts := map[string]bool{}
+	for _, entry := range entries {
+		if entry.hasTOTP {
+			for _, host := range entry.hosts {
+				totpHosts[host] = true
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.hasPassword {
+			if entry.entropy < minEntropy {
+				report.Weak = append(report.Weak, AuditWeakPassword{AuditResource: entry.resource, Entropy: entry.entropy})
+			}
+			if _, ok := byDigest[entry.passwordDigest]; !ok {
+				digests = append(digests, entry.passwordDigest)
+			}
+			byDigest[entry.passwordDigest] = append(byDigest[entry.passwordDigest], entry.resource)
+		}
+		if !opts.ModifiedBefore.IsZero() && entry.resource.Modified != nil && entry.resource.Modified.Before(opts.ModifiedBefore) {
+			report.Old = append(report.Old, entry.resource)
+		}
+		if entry.resource.Expired != nil && !entry.resource.Expired.After(now) {
+			report.Expired = append(report.Expired, entry.resource)
+		}
+		if !entry.hasTOTP && isSensitive(entry.hosts, opts.SensitiveURIs) && !anyHost(entry.hosts, totpHosts) {
+			report.MissingTOTP = append(report.MissingTOTP, entry.resource)
+		}
+		if entry.breached {
+			report.Breached = append(report.Breached, entry.resource.ID)
+		}
+		if opts.MaxSharedUsers > 0 && entry.users > opts.MaxSharedUsers {
+			report.OverShared = append(report.OverShared, AuditSharedResource{AuditResource: entry.resource, Users: entry.users})
+		}
+	}
+
+	for _, digest := range digests {
+		if group := byDigest[digest]; len(group) > 1 {
+			report.Reused = append(report.Reused, group)
+		}
+	}
+	sort.SliceStable(report.Weak, func(i, j int) bool { return report.Weak[i].Entropy < report.Weak[j].Entropy })
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *AuditReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report in a human readable form
+func (r *AuditReport) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Vault audit of %d resources at %v\n", r.ResourceCount, r.Created.Format(time.RFC3339))
+
+	fmt.Fprintf(&b, "\nWeak passwords (%d):\n", len(r.Weak))
+	for _, weak := range r.Weak {
+		fmt.Fprintf(&b, "  %v: %.0f bits\n", weak.AuditResource, weak.Entropy)
+	}
+	fmt.Fprintf(&b, "\nReused passwords (%d groups):\n", len(r.Reused))
+	for _, group := range r.Reused {
+		fmt.Fprintf(&b, "  %d resources:\n", len(group))
+		for _, resource := range group {
+			fmt.Fprintf(&b, "    %v\n", resource)
+		}
+	}
+	fmt.Fprintf(&b, "\nNot modified since cutoff (%d):\n", len(r.Old))
+	for _, resource := range r.Old {
+		fmt.Fprintf(&b, "  %v: modified %v\n", resource, formatAuditTime(resource.Modified))
+	}
+	fmt.Fprintf(&b, "\nExpired (%d):\n", len(r.Expired))
+	for _, resource := range r.Expired {
+		fmt.Fprintf(&b, "  %v: expired %v\n", resource, formatAuditTime(resource.Expired))
+	}
+	fmt.Fprintf(&b, "\nSensitive without TOTP (%d):\n", len(r.MissingTOTP))
+	for _, resource := range r.MissingTOTP {
+		fmt.Fprintf(&b, "  %v\n", resource)
+	}
+	fmt.Fprintf(&b, "\nShared with too many users (%d):\n", len(r.OverShared))
+	for _, shared := range r.OverShared {
+		fmt.Fprintf(&b, "  %v: %d users\n", shared.AuditResource, shared.Users)
+	}
+	fmt.Fprintf(&b, "\nBreached passwords (%d):\n", len(r.Breached))
+	for _, id := range r.Breached {
+		fmt.Fprintf(&b, "  %v\n", id)
+	}
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(&b, "\nNot audited (%d):\n", len(r.Errors))
+		for _, auditErr := range r.Errors {
+			fmt.Fprintf(&b, "  %v: %v\n", auditErr.ResourceID, auditErr.Error)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// String formats the resource for text reports
+func (r AuditResource) String() string {
+	if r.URI == "" {
+		return fmt.Sprintf("%v (%v)", r.Name, r.ID)
+	}
+	return fmt.Sprintf("%v <%v> (%v)", r.Name, r.URI, r.ID)
+}
+
+func formatAuditTime(t *api.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format(time.DateOnly)
+}
+
+// resourceURIs returns all URIs of decrypted metadata, v4 "uri" or v5 "uris"
+func resourceURIs(metadataFields map[string]any) []string {
+	uris := []string{}
+	if list, ok := metadataFields["uris"].([]any); ok {
+		for _, uri := range list {
+			if s, ok := uri.(string); ok && s != "" {
+				uris = append(uris, s)
+			}
+		}
+	}
+	if len(uris) == 0 {
+		if uri := GetStringField(metadataFields, "uri"); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// uriHost returns the lowercase host of a URI, which may lack a scheme
+func uriHost(uri string) string {
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func isSensitive(hosts []string, patterns []string) bool {
+	for _, host := range hosts {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyHost(hosts []string, set map[string]bool) bool {
+	for _, host := range hosts {
+		if set[host] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+//go:build integration
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestAuditVault creates two resources sharing a weak password and checks both are reported without leaking it.
+func TestAuditVault(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	const password = "hunter2-audit"
+	var ids []string
+	for _, name := range []string{"audit-a", "audit-b"} {
+		id, err := CreateResource(ctx, client, "", name, "username", "https://url.lan", password, "")
+		if err != nil {
+			t.Fatalf("Creating Resource %v", err)
+		}
+		defer func() { _ = DeleteResource(ctx, client, id) }()
+		ids = append(ids, id)
+	}
+
+	report, err := AuditVault(ctx, client, AuditOptions{})
+	if err != nil {
+		t.Fatalf("Auditing Vault %v", err)
+	}
+
+	found := false
+	for _, group := range report.Reused {
+		if len(group) == 2 && (group[0].ID == ids[0] || group[1].ID == ids[0]) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Reused passwords are %+v", report.Reused)
+	}
+
+	var buf bytes.Buffer
+	_ = report.WriteJSON(&buf)
+	_ = report.WriteText(&buf)
+	if strings.Contains(buf.String(), password) {
+		t.Error("Report contains a plaintext password")
+	}
+}
+
+// TestAuditVaultOverShared shares a resource with a group and a user, the owner is counted once.
+func TestAuditVaultOverShared(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	auditor, err := pb.CreateUser(ctx, "auditor@passbolt.com", "Audit", "Reader", "user", "auditor@passbolt.com")
+	if err != nil {
+		t.Fatalf("Creating User %v", err)
+	}
+	groupID, err := CreateGroup(ctx, client, "audit", []GroupMembershipOperation{
+		{UserID: client.GetUserID(), IsGroupManager: true},
+	})
+	if err != nil {
+		t.Fatalf("Creating Group %v", err)
+	}
+	defer func() { _ = DeleteGroup(ctx, client, groupID) }()
+	id, err := CreateResource(ctx, client, "", "audit-shared", "", "", "audit-shared-secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+	err = ShareResourceWithUsersAndGroups(ctx, client, id, []string{auditor.UserID}, []string{groupID}, 1)
+	if err != nil {
+		t.Fatalf("Sharing Resource %v", err)
+	}
+
+	report, err := AuditVault(ctx, client, AuditOptions{MaxSharedUsers: 1})
+	if err != nil {
+		t.Fatalf("Auditing Vault %v", err)
+	}
+	for _, shared := range report.OverShared {
+		if shared.ID == id {
+			if shared.Users != 2 {
+				t.Errorf("got %v Users, want 2", shared.Users)
+			}
+			return
+		}
+	}
+	t.Errorf("OverShared is %+v", report.OverShared)
+}
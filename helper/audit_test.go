@@ -0,0 +1,126 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// buildAuditReport decides what ends up in front of the security team. The
+// entries here stand in for decrypted resources; only the entropy and a
+// digest of each password ever reach it.
+
+func TestBuildAuditReport(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := &api.Time{Time: now.AddDate(-2, 0, 0)}
+	recent := &api.Time{Time: now.AddDate(0, -1, 0)}
+
+	entries := []auditEntry{
+		{resource: AuditResource{ID: "weak", Name: "weak", Modified: recent}, hasPassword: true, entropy: 30, passwordDigest: "a"},
+		{resource: AuditResource{ID: "reuse-1", Name: "reuse-1", Modified: recent}, hasPassword: true, entropy: 120, passwordDigest: "b"},
+		{resource: AuditResource{ID: "reuse-2", Name: "reuse-2", Modified: old}, hasPassword: true, entropy: 120, passwordDigest: "b"},
+		{resource: AuditResource{ID: "expired", Name: "expired", Expired: &api.Time{Time: now.Add(-time.Hour)}}, users: 12},
+		{resource: AuditResource{ID: "bank", Name: "bank"}, hosts: []string{"login.bank.example"}},
+		{resource: AuditResource{ID: "bank-otp", Name: "bank otp"}, hosts: []string{"otp.bank.example"}, hasTOTP: true},
+		{resource: AuditResource{ID: "bank-covered", Name: "bank covered"}, hosts: []string{"otp.bank.example"}},
+	}
+
+	report := buildAuditReport(entries, AuditOptions{
+		ModifiedBefore: now.AddDate(-1, 0, 0),
+		SensitiveURIs:  []string{"*.BANK.example"},
+		MaxSharedUsers: 10,
+	}, now)
+
+	ids := func(resources []AuditResource) string {
+		out := []string{}
+		for _, r := range resources {
+			out = append(out, r.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if len(report.Weak) != 1 || report.Weak[0].ID != "weak" {
+		t.Errorf("Weak = %+v", report.Weak)
+	}
+	if len(report.Reused) != 1 || ids(report.Reused[0]) != "reuse-1,reuse-2" {
+		t.Errorf("Reused = %+v", report.Reused)
+	}
+	if got := ids(report.Old); got != "reuse-2" {
+		t.Errorf("Old = %v", got)
+	}
+	if got := ids(report.Expired); got != "expired" {
+		t.Errorf("Expired = %v", got)
+	}
+	if got := ids(report.MissingTOTP); got != "bank" {
+		t.Errorf("MissingTOTP = %v", got)
+	}
+	if len(report.OverShared) != 1 || report.OverShared[0].Users != 12 {
+		t.Errorf("OverShared = %+v", report.OverShared)
+	}
+}
+
+func TestAuditReportOutput(t *testing.T) {
+	t.Parallel()
+
+	report := buildAuditReport([]auditEntry{
+		{resource: AuditResource{ID: "id-1", Name: "mail", URI: "https://mail.example"}, hasPassword: true, entropy: 20, passwordDigest: "digest"},
+		{resource: AuditResource{ID: "id-2", Name: "chat"}, hasPassword: true, entropy: 20, passwordDigest: "digest"},
+	}, AuditOptions{}, time.Now())
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	if !strings.Contains(text.String(), "mail <https://mail.example> (id-1): 20 bits") {
+		t.Errorf("text report:\n%v", text.String())
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if strings.Contains(buf.String(), "digest") {
+		t.Error("JSON report contains the password digest")
+	}
+	var decoded AuditReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding JSON report: %v", err)
+	}
+	if len(decoded.Reused) != 1 || len(decoded.Weak) != 2 {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+}
+
+func TestURIHost(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"https://Login.Example.com:8443/path": "login.example.com",
+		"example.com/login":                   "example.com",
+		"":                                    "",
+	}
+	for uri, want := range tests {
+		if got := uriHost(uri); got != want {
+			t.Errorf("uriHost(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestBuildAuditReport_Breached(t *testing.T) {
+	t.Parallel()
+
+	report := buildAuditReport([]auditEntry{
+		{resource: AuditResource{ID: "safe"}, hasPassword: true, entropy: 100, passwordDigest: "a"},
+		{resource: AuditResource{ID: "breached"}, hasPassword: true, entropy: 100, passwordDigest: "b", breached: true},
+	}, AuditOptions{}, time.Now())
+
+	if len(report.Breached) != 1 || report.Breached[0] != "breached" {
+		t.Errorf("Breached = %v", report.Breached)
+	}
+}
@@ -0,0 +1,495 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// VaultBackupVersion is the version of the vault backup format written by WriteVaultBackup
+const VaultBackupVersion = 1
+
+// VaultBackup is everything a User can see, with metadata and secrets decrypted and principals referenced by name
+// so it can be restored on another server
+type VaultBackup struct {
+	Version   int                   `json:"version"`
+	Created   time.Time             `json:"created"`
+	Folders   []VaultBackupFolder   `json:"folders"`
+	Resources []VaultBackupResource `json:"resources"`
+}
+
+// VaultBackupFolder is a Folder of a VaultBackup
+type VaultBackupFolder struct {
+	ID string `json:"id"`
+	// FolderParentID is empty for Folders in the root or whose parent is not part of the backup
+	FolderParentID string                  `json:"folder_parent_id,omitempty"`
+	Name           string                  `json:"name"`
+	Permissions    []VaultBackupPermission `json:"permissions"`
+}
+
+// VaultBackupResource is a Resource of a VaultBackup
+type VaultBackupResource struct {
+	ID             string `json:"id"`
+	FolderParentID string `json:"folder_parent_id,omitempty"`
+	// ResourceType is the slug of the Resource Type
+	ResourceType string                  `json:"resource_type"`
+	Metadata     map[string]any          `json:"metadata"`
+	Secret       map[string]any          `json:"secret"`
+	Tags         []string                `json:"tags"`
+	Comments     []VaultBackupComment    `json:"comments"`
+	Permissions  []VaultBackupPermission `json:"permissions"`
+}
+
+// VaultBackupComment is a Comment of a VaultBackupResource
+type VaultBackupComment struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id,omitempty"`
+	Content  string `json:"content"`
+	// Author is the username of the creator, restored Comments are created by the restoring User
+	Author  string    `json:"author,omitempty"`
+	Created *api.Time `json:"created,omitempty"`
+}
+
+// VaultBackupPermission is a permission of a User or Group, referenced by username or Group name
+type VaultBackupPermission struct {
+	User  string `json:"user,omitempty"`
+	Group string `json:"group,omitempty"`
+	// Type of Permission: 1 = Read, 7 = can Update, 15 = Owner
+	Type int `json:"type"`
+}
+
+// VaultRestoreOptions configure RestoreVault
+type VaultRestoreOptions struct {
+	// FolderParentID is the Folder the backup is restored in, empty for the root
+	FolderParentID string
+}
+
+// VaultRestoreResult is the outcome for one Folder or Resource
+type VaultRestoreResult struct {
+	// BackupID is the ID in the backup, ID the one on the target server. ID is empty if creating failed
+	BackupID string `json:"backup_id"`
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	// Error is set if creating, sharing, tagging or commenting failed
+	Error string `json:"error,omitempty"`
+}
+
+// VaultRestoreReport is the result of RestoreVault
+type VaultRestoreReport struct {
+	Folders   []VaultRestoreResult `json:"folders"`
+	Resources []VaultRestoreResult `json:"resources"`
+	// Unmapped are the Users ("user <username>") and Groups ("group <name>") of the backup that don't exist on the target,
+	// their permissions were not restored
+	Unmapped []string `json:"unmapped"`
+}
+
+// BackupVault writes a backup of everything the client can see to w, encrypted to the recovery keys and signed with the client's key
+func BackupVault(ctx context.Context, c *api.Client, w io.Writer, recipients []*crypto.Key) (*VaultBackup, error) {
+	backup, err := GetVaultBackup(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := c.GetUserPrivateKeyCopy()
+	if err != nil {
+		return nil, fmt.Errorf("getting Private Key: %w", err)
+	}
+	defer signingKey.ClearPrivateParams()
+
+	err = WriteVaultBackup(w, backup, signingKey, recipients)
+	if err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// GetVaultBackup collects the Folders and Resources the client can see with their secrets, Tags, Comments and permissions
+func GetVaultBackup(ctx context.Context, c *api.Client) (*VaultBackup, error) {
+	users, err := c.GetUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Users: %w", err)
+	}
+	groups, err := c.GetGroups(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Groups: %w", err)
+	}
+	usernames := map[string]string{}
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+	}
+	groupNames := map[string]string{}
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
+	}
+
+	folders, err := c.GetFolders(ctx, &api.GetFoldersOptions{
+		ContainPermissions: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Folders: %w", err)
+	}
+	folderIDs := map[string]bool{}
+	for _, f := range folders {
+		folderIDs[f.ID] = true
+	}
+
+	backup := &VaultBackup{
+		Version:   VaultBackupVersion,
+		Created:   time.Now(),
+		Folders:   []VaultBackupFolder{},
+		Resources: []VaultBackupResource{},
+	}
+	for _, f := range folders {
+		_, name, err := GetFolderFromData(ctx, c, f)
+		if err != nil {
+			return nil, fmt.Errorf("folder %v: %w", f.ID, err)
+		}
+		folder := VaultBackupFolder{
+			ID:          f.ID,
+			Name:        name,
+			Permissions: vaultBackupPermissions(f.Permissions, usernames, groupNames),
+		}
+		if folderIDs[f.FolderParentID] {
+			folder.FolderParentID = f.FolderParentID
+		}
+		backup.Folders = append(backup.Folders, folder)
+	}
+
+	// contain[permission] only returns the client's own permission, the others need the permissions.* contains
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		ContainSecret:                 true,
+		ContainPermissions:            true,
+		ContainPermissionsUserProfile: true,
+		ContainPermissionsGroup:       true,
+		ContainTags:                   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+	for _, r := range resources {
+		resource, err := getVaultBackupResource(ctx, c, r, rTypes, usernames, groupNames)
+		if err != nil {
+			return nil, fmt.Errorf("resource %v: %w", r.ID, err)
+		}
+		if folderIDs[r.FolderParentID] {
+			resource.FolderParentID = r.FolderParentID
+		}
+		backup.Resources = append(backup.Resources, *resource)
+	}
+	return backup, nil
+}
+
+func getVaultBackupResource(ctx context.Context, c *api.Client, r api.Resource, rTypes []api.ResourceType, usernames, groupNames map[string]string) (*VaultBackupResource, error) {
+	rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.ID == r.ResourceTypeID }, ErrResourceTypeSlugNotFound, r.ResourceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Secrets) == 0 {
+		return nil, ErrSecretNotFound
+	}
+	metadataFields, secretFields, err := resourceFieldMaps(c, r, r.Secrets[0], *rType, true)
+	if err != nil {
+		return nil, err
+	}
+	// Set again for the target's Resource Type when restoring
+	delete(metadataFields, "object_type")
+	delete(metadataFields, "resource_type_id")
+	delete(secretFields, "object_type")
+
+	tags, err := decryptTags(ctx, c, r.Tags)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := GetComments(ctx, c, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &VaultBackupResource{
+		ID:           r.ID,
+		ResourceType: rType.Slug,
+		Metadata:     metadataFields,
+		Secret:       secretFields,
+		Tags:         tagSlugs(tags),
+		Comments:     []VaultBackupComment{},
+		Permissions:  vaultBackupPermissions(r.Permissions, usernames, groupNames),
+	}
+	for _, comment := range comments {
+		resource.Comments = append(resource.Comments, VaultBackupComment{
+			ID:       comment.ID,
+			ParentID: comment.ParentID,
+			Content:  comment.Content,
+			Author:   usernames[comment.CreatedBy],
+			Created:  comment.Created,
+		})
+	}
+	return resource, nil
+}
+
+// vaultBackupPermissions references the permissions' Users and Groups by name, permissions of unknown principals are dropped
+func vaultBackupPermissions(permissions []api.Permission, usernames, groupNames map[string]string) []VaultBackupPermission {
+	result := []VaultBackupPermission{}
+	for _, p := range permissions {
+		switch {
+		case p.ARO == "User" && usernames[p.AROForeignKey] != "":
+			result = append(result, VaultBackupPermission{User: usernames[p.AROForeignKey], Type: p.Type})
+		case p.ARO == "Group" && groupNames[p.AROForeignKey] != "":
+			result = append(result, VaultBackupPermission{Group: groupNames[p.AROForeignKey], Type: p.Type})
+		}
+	}
+	return result
+}
+
+// WriteVaultBackup encrypts the backup to the recovery keys and signs it with signingKey, the result is an armored OpenPGP message
+func WriteVaultBackup(w io.Writer, backup *VaultBackup, signingKey *crypto.Key, recipients []*crypto.Key) error {
+	if len(recipients) == 0 {
+		return ErrBackupNoRecipients
+	}
+	keyRing, err := crypto.NewKeyRing(nil)
+	if err != nil {
+		return fmt.Errorf("new Keyring: %w", err)
+	}
+	for _, recipient := range recipients {
+		err = keyRing.AddKey(recipient)
+		if err != nil {
+			return fmt.Errorf("adding recovery key: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return fmt.Errorf("marshaling backup: %w", err)
+	}
+	// Copy the key so clearing the Encryptor leaves the caller's key usable
+	signingKey, err = signingKey.Copy()
+	if err != nil {
+		return fmt.Errorf("copy Private Key: %w", err)
+	}
+	encHandle, err := crypto.PGP().Encryption().Recipients(keyRing).SigningKey(signingKey).Compress().New()
+	if err != nil {
+		return fmt.Errorf("new Encryptor: %w", err)
+	}
+	defer encHandle.ClearPrivateParams()
+
+	message, err := encHandle.Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("encrypting backup: %w", err)
+	}
+	armored, err := message.ArmorBytes()
+	if err != nil {
+		return fmt.Errorf("armoring backup: %w", err)
+	}
+	_, err = w.Write(armored)
+	return err
+}
+
+// ReadVaultBackup decrypts a backup with one of the recovery keys. The signature must be made by one of the verification keys
+func ReadVaultBackup(r io.Reader, decryptionKey *crypto.Key, verificationKeys []*crypto.Key) (*VaultBackup, error) {
+	keyRing, err := crypto.NewKeyRing(nil)
+	if err != nil {
+		return nil, fmt.Errorf("new Keyring: %w", err)
+	}
+	for _, key := range verificationKeys {
+		err = keyRing.AddKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("adding verification key: %w", err)
+		}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading backup: %w", err)
+	}
+	decryptionKey, err = decryptionKey.Copy()
+	if err != nil {
+		return nil, fmt.Errorf("copy Private Key: %w", err)
+	}
+	decHandle, err := crypto.PGP().Decryption().DecryptionKey(decryptionKey).VerificationKeys(keyRing).New()
+	if err != nil {
+		return nil, fmt.Errorf("new Decryptor: %w", err)
+	}
+	defer decHandle.ClearPrivateParams()
+
+	res, err := decHandle.Decrypt(data, crypto.Auto)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting backup: %w", err)
+	}
+	if err := res.SignatureError(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBackupSignature, err)
+	}
+
+	var version struct {
+		Version int `json:"version"`
+	}
+	err = json.Unmarshal(res.Bytes(), &version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBackupFormat, err)
+	}
+	if version.Version < 1 || version.Version > VaultBackupVersion {
+		return nil, fmt.Errorf("%w: %v", ErrBackupVersion, version.Version)
+	}
+
+	backup := &VaultBackup{}
+	err = json.Unmarshal(res.Bytes(), backup)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBackupFormat, err)
+	}
+	return backup, nil
+}
+
+// RestoreVault recreates the Folders and Resources of a backup, with their Tags, Comments and permissions.
+// Users and Groups are matched by username and name, secrets are encrypted for every User the Resources are shared with.
+// What can't be restored is listed in the report and does not stop the restore
+func RestoreVault(ctx context.Context, c *api.Client, backup *VaultBackup, opts VaultRestoreOptions) (*VaultRestoreReport, error) {
+	users, err := c.GetUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Users: %w", err)
+	}
+	groups, err := c.GetGroups(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Groups: %w", err)
+	}
+	userIDs := map[string]string{}
+	for _, u := range users {
+		userIDs[u.Username] = u.ID
+	}
+	groupIDs := map[string]string{}
+	for _, g := range groups {
+		groupIDs[g.Name] = g.ID
+	}
+
+	report := &VaultRestoreReport{Folders: []VaultRestoreResult{}, Resources: []VaultRestoreResult{}, Unmapped: []string{}}
+	unmapped := map[string]bool{}
+	share := func(permissions []VaultBackupPermission) []ShareOperation {
+		changes, missing := vaultRestoreShareOperations(permissions, userIDs, groupIDs, c.GetUserID())
+		for _, m := range missing {
+			unmapped[m] = true
+		}
+		return changes
+	}
+
+	folderIDs := map[string]string{}
+	parentID := func(backupID string) string {
+		if id, ok := folderIDs[backupID]; ok {
+			return id
+		}
+		return opts.FolderParentID
+	}
+	for _, folder := range vaultBackupFolderOrder(backup.Folders) {
+		result := VaultRestoreResult{BackupID: folder.ID, Name: folder.Name}
+		id, err := CreateFolder(ctx, c, parentID(folder.FolderParentID), folder.Name)
+		if err != nil {
+			result.Error = err.Error()
+			report.Folders = append(report.Folders, result)
+			continue
+		}
+		result.ID, folderIDs[folder.ID] = id, id
+		if changes := share(folder.Permissions); len(changes) > 0 {
+			err = ShareFolder(ctx, c, id, changes)
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+		report.Folders = append(report.Folders, result)
+	}
+
+	for _, resource := range backup.Resources {
+		result := VaultRestoreResult{BackupID: resource.ID, Name: GetStringField(resource.Metadata, "name")}
+		id, err := CreateResourceGeneric(ctx, c, resource.ResourceType, parentID(resource.FolderParentID), maps.Clone(resource.Metadata), maps.Clone(resource.Secret))
+		if err != nil {
+			result.Error = err.Error()
+			report.Resources = append(report.Resources, result)
+			continue
+		}
+		result.ID = id
+
+		errs := []error{}
+		if len(resource.Tags) > 0 {
+			errs = append(errs, AddResourceTags(ctx, c, id, resource.Tags...))
+		}
+		commentIDs := map[string]string{}
+		for _, comment := range resource.Comments {
+			commentID, err := CreateComment(ctx, c, id, commentIDs[comment.ParentID], comment.Content)
+			commentIDs[comment.ID] = commentID
+			errs = append(errs, err)
+		}
+		if changes := share(resource.Permissions); len(changes) > 0 {
+			errs = append(errs, ShareResource(ctx, c, id, changes))
+		}
+		if err := errors.Join(errs...); err != nil {
+			result.Error = err.Error()
+		}
+		report.Resources = append(report.Resources, result)
+	}
+
+	for m := range unmapped {
+		report.Unmapped = append(report.Unmapped, m)
+	}
+	sort.Strings(report.Unmapped)
+	return report, nil
+}
+
+// vaultRestoreShareOperations maps backup permissions to the target's Users and Groups. The restoring User is skipped,
+// it owns what it creates. The principals that don't exist on the target are returned as well
+func vaultRestoreShareOperations(permissions []VaultBackupPermission, userIDs, groupIDs map[string]string, me string) ([]ShareOperation, []string) {
+	changes := []ShareOperation{}
+	unmapped := []string{}
+	for _, p := range permissions {
+		switch {
+		case p.User != "":
+			id, ok := userIDs[p.User]
+			if !ok {
+				unmapped = append(unmapped, "user "+p.User)
+			} else if id != me {
+				changes = append(changes, ShareOperation{Type: p.Type, ARO: "User", AROID: id})
+			}
+		case p.Group != "":
+			id, ok := groupIDs[p.Group]
+			if !ok {
+				unmapped = append(unmapped, "group "+p.Group)
+			} else {
+				changes = append(changes, ShareOperation{Type: p.Type, ARO: "Group", AROID: id})
+			}
+		}
+	}
+	return changes, unmapped
+}
+
+// vaultBackupFolderOrder sorts Folders so parents come before their children. Folders in a cycle are moved to the root
+func vaultBackupFolderOrder(folders []VaultBackupFolder) []VaultBackupFolder {
+	ids := map[string]bool{}
+	for _, f := range folders {
+		ids[f.ID] = true
+	}
+	placed := map[string]bool{}
+	ordered := []VaultBackupFolder{}
+	for len(placed) < len(ids) {
+		progress := false
+		for _, f := range folders {
+			if !placed[f.ID] && (!ids[f.FolderParentID] || placed[f.FolderParentID]) {
+				ordered = append(ordered, f)
+				placed[f.ID], progress = true, true
+			}
+		}
+		if !progress {
+			i := slices.IndexFunc(folders, func(f VaultBackupFolder) bool { return !placed[f.ID] })
+			folder := folders[i]
+			folder.FolderParentID = ""
+			ordered = append(ordered, folder)
+			placed[folder.ID] = true
+		}
+	}
+	return ordered
+}
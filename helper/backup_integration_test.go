@@ -0,0 +1,131 @@
+//go:build integration
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+func TestBackupRestoreVault(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	groupID, err := CreateGroup(ctx, client, "backup", []GroupMembershipOperation{
+		{UserID: client.GetUserID(), IsGroupManager: true},
+	})
+	if err != nil {
+		t.Fatalf("Creating Group %v", err)
+	}
+	defer func() { _ = DeleteGroup(ctx, client, groupID) }()
+
+	folderID, err := CreateFolder(ctx, client, "", "backup")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+	id, err := CreateResource(ctx, client, folderID, "backup", "backup-user", "https://backup.example.com", "backup-secret", "desc")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+	err = AddResourceTags(ctx, client, id, "#backup")
+	if err != nil {
+		t.Fatalf("Tagging Resource %v", err)
+	}
+	_, err = CreateComment(ctx, client, id, "", "backed up")
+	if err != nil {
+		t.Fatalf("Creating Comment %v", err)
+	}
+	err = ShareResourceWithUsersAndGroups(ctx, client, id, nil, []string{groupID}, 1)
+	if err != nil {
+		t.Fatalf("Sharing Resource %v", err)
+	}
+
+	recovery, err := crypto.PGP().KeyGeneration().AddUserId("recovery", "recovery@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("Generating key %v", err)
+	}
+	var buf bytes.Buffer
+	_, err = BackupVault(ctx, client, &buf, []*crypto.Key{recovery})
+	if err != nil {
+		t.Fatalf("Backing up %v", err)
+	}
+
+	privateKey, err := client.GetUserPrivateKeyCopy()
+	if err != nil {
+		t.Fatalf("Getting Private Key %v", err)
+	}
+	signer, err := privateKey.ToPublic()
+	if err != nil {
+		t.Fatalf("Getting Public Key %v", err)
+	}
+	backup, err := ReadVaultBackup(&buf, recovery, []*crypto.Key{signer})
+	if err != nil {
+		t.Fatalf("Reading backup %v", err)
+	}
+
+	i := slices.IndexFunc(backup.Resources, func(r VaultBackupResource) bool { return r.ID == id })
+	if i == -1 {
+		t.Fatalf("Resource missing in backup")
+	}
+	resource := backup.Resources[i]
+	if resource.FolderParentID != folderID || GetStringField(resource.Secret, "password") != "backup-secret" {
+		t.Fatalf("got %+v", resource)
+	}
+	if !slices.Equal(resource.Tags, []string{"#backup"}) || len(resource.Comments) != 1 || resource.Comments[0].Content != "backed up" {
+		t.Fatalf("got tags %v and comments %+v", resource.Tags, resource.Comments)
+	}
+	if !slices.Contains(resource.Permissions, VaultBackupPermission{Group: "backup", Type: 1}) {
+		t.Fatalf("got permissions %+v", resource.Permissions)
+	}
+
+	// Restore only the test data, next to a principal that doesn't exist
+	resource.Permissions = append(resource.Permissions, VaultBackupPermission{User: "nobody@example.com", Type: 7})
+	backup.Resources = []VaultBackupResource{resource}
+	backup.Folders = slices.DeleteFunc(backup.Folders, func(f VaultBackupFolder) bool { return f.ID != folderID })
+
+	restoreID, err := CreateFolder(ctx, client, "", "restore")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, restoreID) }()
+	report, err := RestoreVault(ctx, client, backup, VaultRestoreOptions{FolderParentID: restoreID})
+	if err != nil {
+		t.Fatalf("Restoring %v", err)
+	}
+	for _, result := range report.Resources {
+		defer func() { _ = DeleteResource(ctx, client, result.ID) }()
+	}
+	for _, result := range report.Folders {
+		defer func() { _ = DeleteFolder(ctx, client, result.ID) }()
+	}
+	if len(report.Folders) != 1 || report.Folders[0].Error != "" || len(report.Resources) != 1 || report.Resources[0].Error != "" {
+		t.Fatalf("got %+v", report)
+	}
+	if !slices.Equal(report.Unmapped, []string{"user nobody@example.com"}) {
+		t.Fatalf("got unmapped %v", report.Unmapped)
+	}
+
+	restored := report.Resources[0].ID
+	folderParentID, name, username, _, password, _, err := GetResource(ctx, client, restored)
+	if err != nil {
+		t.Fatalf("Getting restored Resource %v", err)
+	}
+	if folderParentID != report.Folders[0].ID || name != "backup" || username != "backup-user" || password != "backup-secret" {
+		t.Fatalf("got %q %q %q %q", folderParentID, name, username, password)
+	}
+	access, err := GetResourceAccess(ctx, client, restored)
+	if err != nil {
+		t.Fatalf("Getting restored Resource Access %v", err)
+	}
+	if len(access) != 1 || !slices.ContainsFunc(access[0].Grants, func(g AccessGrant) bool { return g.GroupID == groupID }) {
+		t.Fatalf("group permission not restored: %+v", access)
+	}
+}
@@ -0,0 +1,148 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func generateTestKey(t *testing.T, name string) *crypto.Key {
+	t.Helper()
+	key, err := crypto.PGP().KeyGeneration().AddUserId(name, name+"@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	return key
+}
+
+var testVaultBackup = &VaultBackup{
+	Version: VaultBackupVersion,
+	Created: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	Folders: []VaultBackupFolder{
+		{ID: "f1", Name: "Ops", Permissions: []VaultBackupPermission{{Group: "Ops", Type: 15}}},
+	},
+	Resources: []VaultBackupResource{{
+		ID:             "r1",
+		FolderParentID: "f1",
+		ResourceType:   "v5-default",
+		Metadata:       map[string]any{"name": "db", "uris": []any{"https://db"}},
+		Secret:         map[string]any{"password": "s3cret", "description": "primary"},
+		Tags:           []string{"#prod"},
+		Comments:       []VaultBackupComment{{ID: "c1", Content: "rotated", Author: "alice@example.com"}},
+		Permissions:    []VaultBackupPermission{{User: "alice@example.com", Type: 15}, {Group: "Ops", Type: 1}},
+	}},
+}
+
+func TestWriteVaultBackupRoundTrip(t *testing.T) {
+	signer, recovery, other := generateTestKey(t, "signer"), generateTestKey(t, "recovery"), generateTestKey(t, "other")
+
+	var buf bytes.Buffer
+	err := WriteVaultBackup(&buf, testVaultBackup, signer, []*crypto.Key{recovery, other})
+	if err != nil {
+		t.Fatalf("WriteVaultBackup: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("s3cret")) || !strings.HasPrefix(buf.String(), "-----BEGIN PGP MESSAGE-----") {
+		t.Fatalf("backup is not an encrypted armored message")
+	}
+	archive := buf.Bytes()
+
+	// Every recovery key can decrypt it
+	for _, key := range []*crypto.Key{recovery, other} {
+		backup, err := ReadVaultBackup(bytes.NewReader(archive), key, []*crypto.Key{signer})
+		if err != nil {
+			t.Fatalf("ReadVaultBackup: %v", err)
+		}
+		if !reflect.DeepEqual(backup, testVaultBackup) {
+			t.Errorf("got %+v\nwant %+v", backup, testVaultBackup)
+		}
+	}
+
+	_, err = ReadVaultBackup(bytes.NewReader(archive), recovery, []*crypto.Key{other})
+	if !errors.Is(err, ErrBackupSignature) {
+		t.Errorf("wrong signer: got %v, want ErrBackupSignature", err)
+	}
+	_, err = ReadVaultBackup(bytes.NewReader(archive), signer, []*crypto.Key{signer})
+	if err == nil {
+		t.Errorf("decrypted with a key that is not a recipient")
+	}
+
+	err = WriteVaultBackup(&buf, testVaultBackup, signer, nil)
+	if !errors.Is(err, ErrBackupNoRecipients) {
+		t.Errorf("got %v, want ErrBackupNoRecipients", err)
+	}
+}
+
+func TestReadVaultBackupVersion(t *testing.T) {
+	signer, recovery := generateTestKey(t, "signer"), generateTestKey(t, "recovery")
+	for _, version := range []int{0, VaultBackupVersion + 1} {
+		var buf bytes.Buffer
+		err := WriteVaultBackup(&buf, &VaultBackup{Version: version}, signer, []*crypto.Key{recovery})
+		if err != nil {
+			t.Fatalf("WriteVaultBackup: %v", err)
+		}
+		_, err = ReadVaultBackup(&buf, recovery, []*crypto.Key{signer})
+		if !errors.Is(err, ErrBackupVersion) {
+			t.Errorf("version %v: got %v, want ErrBackupVersion", version, err)
+		}
+	}
+}
+
+func TestVaultBackupPermissions(t *testing.T) {
+	permissions := []api.Permission{
+		{ARO: "User", AROForeignKey: "u1", Type: 15},
+		{ARO: "Group", AROForeignKey: "g1", Type: 7},
+		{ARO: "User", AROForeignKey: "deleted", Type: 1},
+	}
+	got := vaultBackupPermissions(permissions, map[string]string{"u1": "alice"}, map[string]string{"g1": "Ops"})
+	want := []VaultBackupPermission{{User: "alice", Type: 15}, {Group: "Ops", Type: 7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestVaultRestoreShareOperations(t *testing.T) {
+	permissions := []VaultBackupPermission{
+		{User: "me", Type: 15},
+		{User: "alice", Type: 7},
+		{User: "bob", Type: 1},
+		{Group: "Ops", Type: 1},
+		{Group: "Gone", Type: 15},
+	}
+	changes, unmapped := vaultRestoreShareOperations(permissions, map[string]string{"me": "u0", "alice": "u1"}, map[string]string{"Ops": "g1"}, "u0")
+	wantChanges := []ShareOperation{{Type: 7, ARO: "User", AROID: "u1"}, {Type: 1, ARO: "Group", AROID: "g1"}}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("got %+v, want %+v", changes, wantChanges)
+	}
+	if !reflect.DeepEqual(unmapped, []string{"user bob", "group Gone"}) {
+		t.Errorf("got unmapped %v", unmapped)
+	}
+}
+
+func TestVaultBackupFolderOrder(t *testing.T) {
+	folders := []VaultBackupFolder{
+		{ID: "c", FolderParentID: "b"},
+		{ID: "b", FolderParentID: "a"},
+		{ID: "a"},
+		{ID: "x", FolderParentID: "y"},
+		{ID: "y", FolderParentID: "x"},
+	}
+	ids := []string{}
+	parents := map[string]string{}
+	for _, f := range vaultBackupFolderOrder(folders) {
+		ids = append(ids, f.ID)
+		parents[f.ID] = f.FolderParentID
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "b", "c", "x", "y"}) {
+		t.Errorf("got order %v", ids)
+	}
+	// The cycle is broken at its first Folder
+	if parents["x"] != "" || parents["y"] != "x" {
+		t.Errorf("got parents %v", parents)
+	}
+}
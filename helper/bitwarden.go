@@ -0,0 +1,360 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/passbolt/go-passbolt/api"
+	"golang.org/x/crypto/argon2"
+)
+
+// Bitwarden item types
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+	bitwardenSSHKey     = 5
+)
+
+// Bitwarden custom field types
+const (
+	bitwardenFieldText    = 0
+	bitwardenFieldHidden  = 1
+	bitwardenFieldBoolean = 2
+	bitwardenFieldLinked  = 3
+)
+
+// Bitwarden key derivation functions of password protected exports
+const (
+	bitwardenKDFPBKDF2   = 0
+	bitwardenKDFArgon2id = 1
+)
+
+type bitwardenExport struct {
+	Encrypted         bool   `json:"encrypted"`
+	PasswordProtected bool   `json:"passwordProtected"`
+	Salt              string `json:"salt"`
+	KDFType           int    `json:"kdfType"`
+	KDFIterations     int    `json:"kdfIterations"`
+	KDFMemory         int    `json:"kdfMemory"`
+	KDFParallelism    int    `json:"kdfParallelism"`
+	EncKeyValidation  string `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string `json:"data"`
+
+	Folders     []bitwardenFolder `json:"folders"`
+	Collections []bitwardenFolder `json:"collections"`
+	Items       []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	ID            string           `json:"id"`
+	FolderID      string           `json:"folderId"`
+	CollectionIDs []string         `json:"collectionIds"`
+	Type          int              `json:"type"`
+	Name          string           `json:"name"`
+	Notes         string           `json:"notes"`
+	Fields        []bitwardenField `json:"fields"`
+	Login         *struct {
+		URIs []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+		Username         string            `json:"username"`
+		Password         string            `json:"password"`
+		TOTP             string            `json:"totp"`
+		FIDO2Credentials []json.RawMessage `json:"fido2Credentials"`
+	} `json:"login"`
+	Card     map[string]any `json:"card"`
+	Identity map[string]any `json:"identity"`
+	SSHKey   map[string]any `json:"sshKey"`
+}
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+// bitwardenSection are the properties of a card, identity or SSH key item in display order,
+// they become custom fields. Sensitive properties become password fields
+type bitwardenSection []struct {
+	property  string
+	key       string
+	sensitive bool
+}
+
+var bitwardenCardFields = bitwardenSection{
+	{"cardholderName", "Cardholder name", false},
+	{"brand", "Brand", false},
+	{"number", "Number", true},
+	{"expMonth", "Expiration month", false},
+	{"expYear", "Expiration year", false},
+	{"code", "Security code", true},
+}
+
+var bitwardenIdentityFields = bitwardenSection{
+	{"title", "Title", false},
+	{"firstName", "First name", false},
+	{"middleName", "Middle name", false},
+	{"lastName", "Last name", false},
+	{"username", "Username", false},
+	{"company", "Company", false},
+	{"email", "Email", false},
+	{"phone", "Phone", false},
+	{"address1", "Address 1", false},
+	{"address2", "Address 2", false},
+	{"address3", "Address 3", false},
+	{"city", "City", false},
+	{"state", "State", false},
+	{"postalCode", "Postal code", false},
+	{"country", "Country", false},
+	{"ssn", "Social security number", true},
+	{"passportNumber", "Passport number", true},
+	{"licenseNumber", "License number", true},
+}
+
+var bitwardenSSHKeyFields = bitwardenSection{
+	{"privateKey", "Private key", true},
+	{"publicKey", "Public key", false},
+	{"keyFingerprint", "Fingerprint", false},
+}
+
+// ImportBitwarden reads a Bitwarden JSON export and imports it with ImportCredentials.
+// Items or parts of items that can't be represented are listed in the report's warnings
+func ImportBitwarden(ctx context.Context, c *api.Client, r io.Reader, password string, opts ImportOptions) (*ImportReport, error) {
+	credentials, warnings, err := ReadBitwardenJSON(r, password)
+	if err != nil {
+		return nil, err
+	}
+	report, err := ImportCredentials(ctx, c, credentials, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.Warnings = append(warnings, report.Warnings...)
+	return report, nil
+}
+
+// ReadBitwardenJSON parses a plain or password protected Bitwarden JSON export, password is only used for the latter.
+// Logins, secure notes, cards, identities and SSH keys are read, card, identity and SSH key details become custom fields.
+// Folders become the folder path, items of organization exports use their first collection instead
+func ReadBitwardenJSON(r io.Reader, password string) ([]Credential, []ImportWarning, error) {
+	var export bitwardenExport
+	err := json.NewDecoder(r).Decode(&export)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrBitwardenFormat, err)
+	}
+
+	if export.Encrypted {
+		if !export.PasswordProtected {
+			return nil, nil, ErrBitwardenAccountEncrypted
+		}
+		data, err := decryptBitwardenExport(export, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		export = bitwardenExport{}
+		err = json.Unmarshal(data, &export)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: decrypted data: %w", ErrBitwardenFormat, err)
+		}
+	}
+
+	folders := map[string]string{}
+	for _, f := range export.Folders {
+		folders[f.ID] = f.Name
+	}
+	for _, f := range export.Collections {
+		folders[f.ID] = f.Name
+	}
+
+	credentials := []Credential{}
+	warnings := []ImportWarning{}
+	for _, item := range export.Items {
+		warn := func(format string, args ...any) {
+			warnings = append(warnings, ImportWarning{Name: item.Name, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		credential := Credential{
+			FolderPath:   []string{},
+			Name:         item.Name,
+			URIs:         []string{},
+			Description:  item.Notes,
+			CustomFields: CustomFields{},
+		}
+		folderID := item.FolderID
+		if folderID == "" && len(item.CollectionIDs) > 0 {
+			folderID = item.CollectionIDs[0]
+			if len(item.CollectionIDs) > 1 {
+				warn("only imported into the first of %v collections", len(item.CollectionIDs))
+			}
+		}
+		if folderID != "" {
+			credential.FolderPath = splitFolderPath(folders[folderID], "/")
+		}
+
+		switch item.Type {
+		case bitwardenLogin:
+			if item.Login == nil {
+				break
+			}
+			credential.Username = item.Login.Username
+			credential.Password = item.Login.Password
+			for _, uri := range item.Login.URIs {
+				if uri.URI != "" {
+					credential.URIs = append(credential.URIs, uri.URI)
+				}
+			}
+			if strings.HasPrefix(item.Login.TOTP, "steam://") {
+				warn("Steam Guard TOTPs are not supported, not imported as TOTP")
+				credential.CustomFields = append(credential.CustomFields, CustomField{Key: "TOTP", Type: CustomFieldTypePassword, Value: item.Login.TOTP})
+			} else if item.Login.TOTP != "" {
+				credential.TOTP, err = parseTOTPValue(item.Login.TOTP)
+				if err != nil {
+					warn("TOTP %v, not imported as TOTP", err)
+					credential.CustomFields = append(credential.CustomFields, CustomField{Key: "TOTP", Type: CustomFieldTypePassword, Value: item.Login.TOTP})
+				}
+			}
+			if len(item.Login.FIDO2Credentials) > 0 {
+				warn("passkeys are not supported")
+			}
+		case bitwardenSecureNote:
+		case bitwardenCard:
+			credential.CustomFields = append(credential.CustomFields, bitwardenSectionFields(item.Card, bitwardenCardFields)...)
+		case bitwardenIdentity:
+			credential.CustomFields = append(credential.CustomFields, bitwardenSectionFields(item.Identity, bitwardenIdentityFields)...)
+		case bitwardenSSHKey:
+			credential.CustomFields = append(credential.CustomFields, bitwardenSectionFields(item.SSHKey, bitwardenSSHKeyFields)...)
+		default:
+			warn("skipped, unknown item type %v", item.Type)
+			continue
+		}
+
+		for _, f := range item.Fields {
+			switch f.Type {
+			case bitwardenFieldText:
+				credential.CustomFields = append(credential.CustomFields, CustomField{Key: f.Name, Type: CustomFieldTypeText, Value: f.Value})
+			case bitwardenFieldHidden:
+				credential.CustomFields = append(credential.CustomFields, CustomField{Key: f.Name, Type: CustomFieldTypePassword, Value: f.Value})
+			case bitwardenFieldBoolean:
+				credential.CustomFields = append(credential.CustomFields, CustomField{Key: f.Name, Type: CustomFieldTypeBoolean, Value: f.Value == "true"})
+			default:
+				warn("custom field %q: linked fields are not supported", f.Name)
+			}
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, warnings, nil
+}
+
+// bitwardenSectionFields returns the non empty properties of a card, identity or SSH key as custom fields
+func bitwardenSectionFields(section map[string]any, properties bitwardenSection) CustomFields {
+	fields := CustomFields{}
+	for _, p := range properties {
+		value, ok := section[p.property].(string)
+		if !ok || value == "" {
+			continue
+		}
+		fieldType := CustomFieldTypeText
+		if p.sensitive {
+			fieldType = CustomFieldTypePassword
+		}
+		fields = append(fields, CustomField{Key: p.key, Type: fieldType, Value: value})
+	}
+	return fields
+}
+
+// decryptBitwardenExport derives the export key from password and decrypts the export data
+func decryptBitwardenExport(export bitwardenExport, password string) ([]byte, error) {
+	var key []byte
+	var err error
+	switch export.KDFType {
+	case bitwardenKDFPBKDF2:
+		if export.KDFIterations <= 0 || export.KDFIterations > 10_000_000 {
+			return nil, fmt.Errorf("%w: PBKDF2 iterations %v", ErrBitwardenFormat, export.KDFIterations)
+		}
+		key, err = pbkdf2.Key(sha256.New, password, []byte(export.Salt), export.KDFIterations, 32)
+		if err != nil {
+			return nil, fmt.Errorf("deriving key: %w", err)
+		}
+	case bitwardenKDFArgon2id:
+		// Bitwarden allows at most 10 iterations, more would also wrap around as uint32
+		if export.KDFIterations <= 0 || export.KDFIterations > 10 || export.KDFMemory < 16 || export.KDFMemory > 1024 || export.KDFParallelism <= 0 || export.KDFParallelism > 16 {
+			return nil, fmt.Errorf("%w: Argon2id parameters out of range", ErrBitwardenFormat)
+		}
+		salt := sha256.Sum256([]byte(export.Salt))
+		key = argon2.IDKey([]byte(password), salt[:], uint32(export.KDFIterations), uint32(export.KDFMemory)*1024, uint8(export.KDFParallelism), 32)
+	default:
+		return nil, fmt.Errorf("%w: key derivation function %v", ErrBitwardenFormat, export.KDFType)
+	}
+
+	encKey, err := hkdf.Expand(sha256.New, key, "enc", 32)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+	macKey, err := hkdf.Expand(sha256.New, key, "mac", 32)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+
+	_, err = decryptBitwardenString(export.EncKeyValidation, encKey, macKey)
+	if err != nil {
+		return nil, err
+	}
+	return decryptBitwardenString(export.Data, encKey, macKey)
+}
+
+// decryptBitwardenString decrypts an AES-256-CBC HMAC-SHA256 encrypted string of the form "2.iv|data|mac"
+func decryptBitwardenString(encrypted string, encKey, macKey []byte) ([]byte, error) {
+	encType, rest, ok := strings.Cut(encrypted, ".")
+	parts := strings.Split(rest, "|")
+	if !ok || encType != "2" || len(parts) != 3 {
+		return nil, fmt.Errorf("%w: unsupported encrypted string", ErrBitwardenFormat)
+	}
+	decoded := make([][]byte, 3)
+	for i, part := range parts {
+		var err error
+		decoded[i], err = base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: encrypted string: %w", ErrBitwardenFormat, err)
+		}
+	}
+	iv, data, mac := decoded[0], decoded[1], decoded[2]
+
+	h := hmac.New(sha256.New, macKey)
+	h.Write(iv)
+	h.Write(data)
+	if !hmac.Equal(h.Sum(nil), mac) {
+		return nil, ErrBitwardenInvalidPassword
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: invalid encrypted data length", ErrBitwardenFormat)
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, fmt.Errorf("%w: invalid padding", ErrBitwardenFormat)
+	}
+	return plain[:len(plain)-padding], nil
+}
@@ -0,0 +1,46 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestImportBitwarden(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	folderID, err := CreateFolder(ctx, client, "", "bitwarden import")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+
+	f, err := os.Open("testdata/bitwarden-pbkdf2.json")
+	if err != nil {
+		t.Fatalf("Opening fixture %v", err)
+	}
+	defer f.Close()
+	report, err := ImportBitwarden(ctx, client, f, "bitwarden", ImportOptions{FolderParentID: folderID})
+	if report != nil {
+		defer cleanupImport(ctx, report)
+	}
+	if err != nil {
+		t.Fatalf("Importing Bitwarden %v", err)
+	}
+	if report.Count(ImportActionCreate) != 7 || len(report.Folders) != 2 || len(report.Warnings) != 5 {
+		t.Fatalf("got %+v, want 7 Resources, 2 Folders and 5 warnings", report)
+	}
+
+	folderParentID, name, username, _, password, _, err := GetResource(ctx, client, report.Results[0].ResourceID)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if folderParentID == folderID || name != "Mail" || username != "alice@example.com" || password != "m4il-p@ss" {
+		t.Fatalf("got %q %q %q %q", folderParentID, name, username, password)
+	}
+}
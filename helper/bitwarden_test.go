@@ -0,0 +1,154 @@
+package helper
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func readBitwardenFixture(t *testing.T, path, password string) ([]Credential, []ImportWarning) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening fixture: %v", err)
+	}
+	defer f.Close()
+	credentials, warnings, err := ReadBitwardenJSON(f, password)
+	if err != nil {
+		t.Fatalf("ReadBitwardenJSON: %v", err)
+	}
+	return credentials, warnings
+}
+
+func TestReadBitwardenJSON(t *testing.T) {
+	credentials, warnings := readBitwardenFixture(t, "testdata/bitwarden.json", "")
+	if len(credentials) != 7 {
+		t.Fatalf("got %v credentials, want 7", len(credentials))
+	}
+
+	want := Credential{
+		FolderPath:  []string{"Work"},
+		Name:        "Mail",
+		Username:    "alice@example.com",
+		Password:    "m4il-p@ss",
+		URIs:        []string{"https://mail.example.com", "https://webmail.example.com"},
+		Description: "shared mailbox",
+		TOTP:        &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DPEHPK3PXP", Digits: 6, Period: 30},
+		CustomFields: CustomFields{
+			{Key: "Recovery email", Type: CustomFieldTypeText, Value: "backup@example.com"},
+			{Key: "PIN", Type: CustomFieldTypePassword, Value: "1234"},
+			{Key: "Admin", Type: CustomFieldTypeBoolean, Value: true},
+		},
+	}
+	if !reflect.DeepEqual(credentials[0], want) {
+		t.Errorf("got %+v\nwant %+v", credentials[0], want)
+	}
+
+	router := credentials[1]
+	if !reflect.DeepEqual(router.FolderPath, []string{"Work", "Servers"}) || router.TOTP == nil || router.TOTP.SecretKey != "GEZDGNBVGY3TQOJQ" {
+		t.Errorf("got %+v", router)
+	}
+
+	// Steam TOTPs are kept as custom field
+	steam := credentials[2]
+	if steam.TOTP != nil || len(steam.CustomFields) != 1 || steam.CustomFields[0].Value != "steam://ABCDEFGH" {
+		t.Errorf("got %+v", steam)
+	}
+
+	note := credentials[3]
+	if note.Description != "SSID: office\nKey: hunter2" || note.Password != "" || len(note.CustomFields) != 0 {
+		t.Errorf("got %+v", note)
+	}
+
+	card := credentials[4]
+	if len(card.CustomFields) != 6 || card.CustomFields[2].Key != "Number" || card.CustomFields[2].Type != CustomFieldTypePassword {
+		t.Errorf("got %+v", card.CustomFields)
+	}
+
+	// Empty and null identity properties are left out
+	identity := credentials[5]
+	var keys []string
+	for _, f := range identity.CustomFields {
+		keys = append(keys, f.Key)
+	}
+	if want := []string{"Title", "First name", "Last name", "Email", "Social security number"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got identity fields %v, want %v", keys, want)
+	}
+
+	sshKey := credentials[6]
+	if len(sshKey.CustomFields) != 3 || !strings.HasPrefix(sshKey.CustomFields[0].Value.(string), "-----BEGIN OPENSSH") || sshKey.CustomFields[0].Type != CustomFieldTypePassword {
+		t.Errorf("got %+v", sshKey.CustomFields)
+	}
+
+	wantWarnings := []ImportWarning{
+		{Name: "Mail", Reason: "passkeys are not supported"},
+		{Name: "Mail", Reason: `custom field "Linked user": linked fields are not supported`},
+		{Name: "Steam", Reason: "Steam Guard TOTPs are not supported, not imported as TOTP"},
+		{Name: "From the future", Reason: "skipped, unknown item type 42"},
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("got warnings %+v\nwant %+v", warnings, wantWarnings)
+	}
+}
+
+func TestReadBitwardenJSONEncrypted(t *testing.T) {
+	plain, plainWarnings := readBitwardenFixture(t, "testdata/bitwarden.json", "")
+	for _, path := range []string{"testdata/bitwarden-pbkdf2.json", "testdata/bitwarden-argon2id.json"} {
+		t.Run(path, func(t *testing.T) {
+			credentials, warnings := readBitwardenFixture(t, path, "bitwarden")
+			if !reflect.DeepEqual(credentials, plain) || !reflect.DeepEqual(warnings, plainWarnings) {
+				t.Errorf("decrypted export differs from the plain one")
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("opening fixture: %v", err)
+			}
+			defer f.Close()
+			_, _, err = ReadBitwardenJSON(f, "wrong")
+			if !errors.Is(err, ErrBitwardenInvalidPassword) {
+				t.Errorf("got %v, want ErrBitwardenInvalidPassword", err)
+			}
+		})
+	}
+}
+
+func TestReadBitwardenJSONErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want error
+	}{
+		{`not json`, ErrBitwardenFormat},
+		{`{"encrypted": true, "encKeyValidation_DO_NOT_EDIT": "2.a|b|c", "data": "2.a|b|c"}`, ErrBitwardenAccountEncrypted},
+		{`{"encrypted": true, "passwordProtected": true, "kdfType": 7}`, ErrBitwardenFormat},
+		{`{"encrypted": true, "passwordProtected": true, "kdfType": 1, "kdfIterations": 3, "kdfMemory": 4096, "kdfParallelism": 4}`, ErrBitwardenFormat},
+		{`{"encrypted": true, "passwordProtected": true, "kdfType": 1, "kdfIterations": 100000000, "kdfMemory": 16, "kdfParallelism": 1}`, ErrBitwardenFormat},
+		{`{"encrypted": true, "passwordProtected": true, "kdfType": 1, "kdfIterations": 4294967299, "kdfMemory": 16, "kdfParallelism": 1}`, ErrBitwardenFormat},
+		{`{"encrypted": true, "passwordProtected": true, "kdfType": 0, "kdfIterations": 1, "encKeyValidation_DO_NOT_EDIT": "0.abc"}`, ErrBitwardenFormat},
+	}
+	for _, tt := range tests {
+		_, _, err := ReadBitwardenJSON(strings.NewReader(tt.data), "pw")
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestBitwardenCredentialFields(t *testing.T) {
+	credentials, _ := readBitwardenFixture(t, "testdata/bitwarden.json", "")
+
+	// Cards without a password only have custom fields
+	slug, _, _, _, err := credentialFields(credentials[4], true, testImportResourceTypes, false)
+	if err != nil || slug != "v5-custom-fields" {
+		t.Errorf("card: got %q, %v", slug, err)
+	}
+	// Secure notes are passwordless default resources
+	slug, _, secretFields, _, err := credentialFields(credentials[3], true, testImportResourceTypes, false)
+	if err != nil || slug != "v5-default" || secretFields["description"] != "SSID: office\nKey: hunter2" {
+		t.Errorf("note: got %q %v, %v", slug, secretFields, err)
+	}
+}
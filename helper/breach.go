@@ -0,0 +1,245 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BreachRangeProvider looks up breached password hashes by k-anonymity range like the HIBP Pwned Passwords API.
+// Range returns the uppercase hex SHA-1 suffixes (35 characters) of all breached passwords whose hash starts with
+// the 5 character uppercase hex prefix
+type BreachRangeProvider interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// IsPasswordBreached checks if the SHA-1 hash of password is known to the provider, only the hash prefix is passed to it
+func IsPasswordBreached(ctx context.Context, provider BreachRangeProvider, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := provider.Range(ctx, hash[:5])
+	if err != nil {
+		return false, fmt.Errorf("getting breach range %v: %w", hash[:5], err)
+	}
+	for _, suffix := range suffixes {
+		if suffix == hash[5:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HIBPRangeProvider queries a server implementing the HIBP Pwned Passwords range API, e.g. a local mirror
+type HIBPRangeProvider struct {
+	// BaseURL is the URL the /range/{prefix} paths are relative to
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewHIBPRangeProvider returns a provider for the range API at baseURL, httpClient may be nil to use http.DefaultClient
+func NewHIBPRangeProvider(baseURL string, httpClient *http.Client) *HIBPRangeProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &HIBPRangeProvider{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: httpClient,
+	}
+}
+
+// Range implements BreachRangeProvider. Padding entries with a count of 0 are left out
+func (p *HIBPRangeProvider) Range(ctx context.Context, prefix string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.BaseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Add-Padding", "true")
+
+	res, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("doing request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("range API returned status %v", res.Status)
+	}
+	return parseHIBPRange(res.Body)
+}
+
+// parseHIBPRange parses "SUFFIX:COUNT" lines
+func parseHIBPRange(r io.Reader) ([]string, error) {
+	suffixes := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		suffix, count, _ := strings.Cut(line, ":")
+		if count == "0" {
+			continue
+		}
+		suffixes = append(suffixes, strings.ToUpper(suffix))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading range: %w", err)
+	}
+	return suffixes, nil
+}
+
+// HashFileRangeProvider looks up ranges in a local file of uppercase hex SHA-1 hashes sorted ascending,
+// one per line and optionally followed by ":COUNT", like the output of the HIBP downloader
+type HashFileRangeProvider struct {
+	file *os.File
+	size int64
+}
+
+// OpenHashFile opens a sorted hash file, the provider must be closed after use
+func OpenHashFile(path string) (*HashFileRangeProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening hash file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("stat hash file: %w", err)
+	}
+	return &HashFileRangeProvider{file: file, size: info.Size()}, nil
+}
+
+// Close closes the hash file
+func (p *HashFileRangeProvider) Close() error {
+	return p.file.Close()
+}
+
+// Range implements BreachRangeProvider with a binary search over the file
+func (p *HashFileRangeProvider) Range(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+
+	// Find the offset of the first line not sorting before prefix
+	low, high := int64(0), p.size
+	for low < high {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		mid := low + (high-low)/2
+		start, line, err := p.lineAt(mid)
+		if err != nil {
+			return nil, err
+		}
+		if start >= high || hashFileKey(line) >= prefix {
+			high = mid
+		} else {
+			low = min(start+int64(len(line))+1, high)
+		}
+	}
+
+	start, _, err := p.lineAt(low)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(io.NewSectionReader(p.file, start, p.size-start))
+	suffixes := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		key := hashFileKey(line)
+		if strings.HasPrefix(key, prefix) {
+			suffixes = append(suffixes, key[len(prefix):])
+		} else if key != "" {
+			break
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading hash file: %w", err)
+		}
+	}
+	return suffixes, nil
+}
+
+// lineAt returns the first line starting at or after offset and its start offset
+func (p *HashFileRangeProvider) lineAt(offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		// Skip the rest of the line offset points into, unless offset is already a line start
+		prev := make([]byte, 1)
+		if _, err := p.file.ReadAt(prev, offset-1); err != nil {
+			return 0, "", fmt.Errorf("reading hash file: %w", err)
+		}
+		if prev[0] != '\n' {
+			rest, err := p.readLine(offset)
+			if err != nil {
+				return 0, "", err
+			}
+			start = offset + int64(len(rest)) + 1
+		}
+	}
+	if start >= p.size {
+		return p.size, "", nil
+	}
+	line, err := p.readLine(start)
+	return start, line, err
+}
+
+// readLine reads from offset up to but excluding the next newline
+func (p *HashFileRangeProvider) readLine(offset int64) (string, error) {
+	var line []byte
+	buf := make([]byte, 64)
+	for offset < p.size {
+		n, err := p.file.ReadAt(buf, offset)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return string(append(line, buf[:i]...)), nil
+		}
+		line = append(line, buf[:n]...)
+		offset += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading hash file: %w", err)
+		}
+	}
+	return string(line), nil
+}
+
+// hashFileKey returns the uppercase hash of a hash file line without count and line ending
+func hashFileKey(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
+
+// cachedRangeProvider remembers ranges so an audit asks for each prefix only once
+type cachedRangeProvider struct {
+	provider BreachRangeProvider
+	mu       sync.Mutex
+	ranges   map[string][]string
+}
+
+func newCachedRangeProvider(provider BreachRangeProvider) *cachedRangeProvider {
+	return &cachedRangeProvider{provider: provider, ranges: map[string][]string{}}
+}
+
+func (p *cachedRangeProvider) Range(ctx context.Context, prefix string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if suffixes, ok := p.ranges[prefix]; ok {
+		return suffixes, nil
+	}
+	suffixes, err := p.provider.Range(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	p.ranges[prefix] = suffixes
+	return suffixes, nil
+}
@@ -0,0 +1,139 @@
+package helper
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Both providers only ever see a 5 character hash prefix. The hash file
+// search is hand rolled, so it is checked at the edges of the file too.
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestHIBPRangeProvider(t *testing.T) {
+	t.Parallel()
+
+	breached := sha1Hex("password")
+	var seenPaths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenPaths = append(seenPaths, r.URL.Path)
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Error("request without padding")
+		}
+		if r.URL.Path == "/range/"+breached[:5] {
+			fmt.Fprintf(w, "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n%v:9545824\r\n00D4F6E8FA6EECAD2A3AA415EEC418D38EC:0\r\n", breached[5:])
+		}
+	}))
+	defer server.Close()
+
+	provider := NewHIBPRangeProvider(server.URL+"/", nil)
+	ok, err := IsPasswordBreached(context.Background(), provider, "password")
+	if err != nil || !ok {
+		t.Errorf("IsPasswordBreached(password) = %v, %v", ok, err)
+	}
+	ok, err = IsPasswordBreached(context.Background(), provider, "correct horse battery staple unique")
+	if err != nil || ok {
+		t.Errorf("IsPasswordBreached(unique) = %v, %v", ok, err)
+	}
+	for _, path := range seenPaths {
+		if len(path) != len("/range/")+5 {
+			t.Errorf("server saw %q, more than a prefix", path)
+		}
+	}
+}
+
+func TestHashFileRangeProvider(t *testing.T) {
+	t.Parallel()
+
+	passwords := []string{"123456", "password", "qwerty", "letmein", "dragon", "monkey", "abc123", "iloveyou"}
+	hashes := []string{}
+	for i, password := range passwords {
+		hashes = append(hashes, fmt.Sprintf("%v:%d", sha1Hex(password), i+1))
+	}
+	// Two hashes sharing a prefix with "password"
+	prefix := sha1Hex("password")[:5]
+	hashes = append(hashes, prefix+strings.Repeat("0", 35), prefix+strings.Repeat("F", 35)+":7")
+	slices.Sort(hashes)
+
+	for name, content := range map[string]string{
+		"crlf":             strings.Join(hashes, "\r\n"),
+		"trailing newline": strings.Join(hashes, "\n") + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			testHashFile(t, content, passwords, prefix)
+		})
+	}
+}
+
+func testHashFile(t *testing.T, content string, passwords []string, prefix string) {
+	path := filepath.Join(t.TempDir(), "hashes.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := OpenHashFile(path)
+	if err != nil {
+		t.Fatalf("OpenHashFile: %v", err)
+	}
+	defer provider.Close()
+
+	ctx := context.Background()
+	for _, password := range passwords {
+		ok, err := IsPasswordBreached(ctx, provider, password)
+		if err != nil || !ok {
+			t.Errorf("IsPasswordBreached(%q) = %v, %v", password, ok, err)
+		}
+	}
+	for _, password := range []string{"not in the file", "", "zzzzzz"} {
+		ok, err := IsPasswordBreached(ctx, provider, password)
+		if err != nil || ok {
+			t.Errorf("IsPasswordBreached(%q) = %v, %v", password, ok, err)
+		}
+	}
+
+	suffixes, err := provider.Range(ctx, strings.ToLower(prefix))
+	if err != nil || len(suffixes) != 3 {
+		t.Errorf("Range(%v) = %v, %v", prefix, suffixes, err)
+	}
+	for _, edge := range []string{"00000", "FFFFF"} {
+		suffixes, err := provider.Range(ctx, edge)
+		if err != nil || len(suffixes) != 0 {
+			t.Errorf("Range(%v) = %v, %v", edge, suffixes, err)
+		}
+	}
+}
+
+type countingRangeProvider struct {
+	calls int
+}
+
+func (p *countingRangeProvider) Range(ctx context.Context, prefix string) ([]string, error) {
+	p.calls++
+	return []string{sha1Hex("password")[5:]}, nil
+}
+
+func TestCachedRangeProvider(t *testing.T) {
+	t.Parallel()
+
+	counting := &countingRangeProvider{}
+	cached := newCachedRangeProvider(counting)
+	for i := 0; i < 3; i++ {
+		if ok, _ := IsPasswordBreached(context.Background(), cached, "password"); !ok {
+			t.Fatal("password not breached")
+		}
+	}
+	if counting.calls != 1 {
+		t.Errorf("provider called %d times", counting.calls)
+	}
+}
@@ -0,0 +1,268 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// Comment is a Comment with its Content decrypted
+type Comment struct {
+	ID        string
+	ParentID  string
+	Content   string
+	CreatedBy string
+	// Creator is the User who wrote the Comment, including their Profile
+	Creator  *api.User
+	Created  *api.Time
+	Modified *api.Time
+	// Children are the replies to this Comment, oldest first
+	Children []Comment
+}
+
+// CreateComment Creates a Comment on a Resource using the server's preferred format (v4 or v5).
+// parentID may be empty or the ID of the Comment this is a reply to
+func CreateComment(ctx context.Context, c *api.Client, resourceID, parentID, content string) (string, error) {
+	comment := api.Comment{
+		ParentID:     parentID,
+		ForeignKey:   resourceID,
+		ForeignModel: string(api.ForeignModelTypesResource),
+	}
+
+	settings := c.MetadataTypeSettings()
+	if settings.DefaultCommentType == api.PassboltAPIVersionTypeV5 {
+		if !settings.AllowCreationOfV5Comments {
+			return "", ErrV5CommentCreationDisabled
+		}
+
+		resource, err := c.GetResource(ctx, resourceID)
+		if err != nil {
+			return "", fmt.Errorf("getting Resource: %w", err)
+		}
+
+		// Everyone who can see the Resource can see its Comments, so only use our personal key if the Resource does
+		personal := resource.MetadataKeyType == api.MetadataKeyTypeUserKey
+		err = setCommentMetadata(ctx, c, &comment, content, personal)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if !settings.AllowCreationOfV4Comments {
+			return "", ErrV4CommentCreationDisabled
+		}
+		comment.Content = content
+	}
+
+	newComment, err := c.CreateComment(ctx, resourceID, comment)
+	if err != nil {
+		return "", fmt.Errorf("creating Comment: %w", err)
+	}
+	return newComment.ID, nil
+}
+
+// GetComments gets all Comments of a Resource as a flat list, oldest first
+func GetComments(ctx context.Context, c *api.Client, resourceID string) ([]Comment, error) {
+	comments, err := c.GetComments(ctx, resourceID, &api.GetCommentsOptions{
+		ContainCreator:        true,
+		ContainCreatorProfile: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Comments: %w", err)
+	}
+
+	result := []Comment{}
+	for _, comment := range flattenComments(comments) {
+		content, err := getCommentContent(ctx, c, &comment)
+		if err != nil {
+			return nil, fmt.Errorf("getting Comment %v Content: %w", comment.ID, err)
+		}
+		result = append(result, Comment{
+			ID:        comment.ID,
+			ParentID:  comment.ParentID,
+			Content:   content,
+			CreatedBy: comment.CreatedBy,
+			Creator:   comment.Creator,
+			Created:   comment.Created,
+			Modified:  comment.Modified,
+		})
+	}
+	sortComments(result)
+	return result, nil
+}
+
+// GetCommentThread gets all Comments of a Resource as a tree of top level Comments and their replies.
+// Creators whose Profile was not included by the server are looked up
+func GetCommentThread(ctx context.Context, c *api.Client, resourceID string) ([]Comment, error) {
+	comments, err := GetComments(ctx, c, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []api.User
+	for i := range comments {
+		if comments[i].Creator != nil && comments[i].Creator.Profile != nil {
+			continue
+		}
+		if users == nil {
+			users, err = c.GetUsers(ctx, nil)
+			if err != nil {
+				return nil, fmt.Errorf("getting Users: %w", err)
+			}
+		}
+		user, err := findBy(users, func(u api.User) bool { return u.ID == comments[i].CreatedBy }, ErrUserNotFound, comments[i].CreatedBy)
+		if err != nil {
+			// The Creator may have been deleted, the Comment is still worth returning
+			continue
+		}
+		comments[i].Creator = user
+	}
+
+	return buildCommentThread(comments), nil
+}
+
+// UpdateComment Updates the Content of a Comment on a Resource, v5 Comments stay v5 and are re-encrypted with the matching metadata key
+func UpdateComment(ctx context.Context, c *api.Client, resourceID, commentID, content string) error {
+	comments, err := c.GetComments(ctx, resourceID, nil)
+	if err != nil {
+		return fmt.Errorf("getting Comments: %w", err)
+	}
+	comment, err := findBy(flattenComments(comments), func(cm api.Comment) bool { return cm.ID == commentID }, ErrCommentNotFound, commentID)
+	if err != nil {
+		return err
+	}
+
+	newComment := api.Comment{}
+	if comment.Metadata != "" {
+		personal := comment.MetadataKeyType != api.MetadataKeyTypeSharedKey
+		err = setCommentMetadata(ctx, c, &newComment, content, personal)
+		if err != nil {
+			return err
+		}
+	} else {
+		newComment.Content = content
+	}
+
+	_, err = c.UpdateComment(ctx, commentID, newComment)
+	if err != nil {
+		return fmt.Errorf("updating Comment: %w", err)
+	}
+	return nil
+}
+
+// DeleteComment Deletes a Comment
+func DeleteComment(ctx context.Context, c *api.Client, commentID string) error {
+	err := c.DeleteComment(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("deleting Comment: %w", err)
+	}
+	return nil
+}
+
+// setCommentMetadata encrypts content with the personal or shared metadata key and sets it on comment
+func setCommentMetadata(ctx context.Context, c *api.Client, comment *api.Comment, content string, personal bool) error {
+	metadataKeyID, metadataKeyType, encMetadata, err := encryptForeignMetadata(ctx, c, api.CommentMetadata{
+		ObjectType: api.PassboltObjectTypeCommentMetadata,
+		Content:    content,
+	}, personal)
+	if err != nil {
+		return fmt.Errorf("encrypting Comment Metadata: %w", err)
+	}
+
+	comment.MetadataKeyID = metadataKeyID
+	comment.MetadataKeyType = metadataKeyType
+	comment.Metadata = encMetadata
+	return nil
+}
+
+// getCommentContent returns the cleartext Content of a v4 Comment or the decrypted Content of a v5 Comment
+func getCommentContent(ctx context.Context, c *api.Client, comment *api.Comment) (string, error) {
+	if comment.Metadata == "" {
+		return comment.Content, nil
+	}
+	metadata, err := GetCommentMetadata(ctx, c, comment)
+	if err != nil {
+		return "", err
+	}
+	return metadata.Content, nil
+}
+
+// flattenComments returns the Comments and all their nested Children as a single list
+func flattenComments(comments []api.Comment) []api.Comment {
+	result := []api.Comment{}
+	for _, comment := range comments {
+		children := comment.Children
+		comment.Children = nil
+		result = append(result, comment)
+		result = append(result, flattenComments(children)...)
+	}
+	return result
+}
+
+// buildCommentThread nests Comments under their parent by ParentID.
+// Comments whose parent is unknown (e.g. deleted) become top level Comments, as does the oldest Comment of a ParentID cycle
+func buildCommentThread(comments []Comment) []Comment {
+	byParent := map[string][]Comment{}
+	known := map[string]bool{}
+	for _, comment := range comments {
+		known[comment.ID] = true
+	}
+	for _, comment := range comments {
+		parent := comment.ParentID
+		if !known[parent] || parent == comment.ID {
+			parent = ""
+		}
+		byParent[parent] = append(byParent[parent], comment)
+	}
+
+	var attach func(parentID string, seen map[string]bool) []Comment
+	attach = func(parentID string, seen map[string]bool) []Comment {
+		result := []Comment{}
+		for _, comment := range byParent[parentID] {
+			// Guard against cycles in ParentID
+			if seen[comment.ID] {
+				continue
+			}
+			seen[comment.ID] = true
+			comment.Children = attach(comment.ID, seen)
+			result = append(result, comment)
+		}
+		sortComments(result)
+		return result
+	}
+	seen := map[string]bool{}
+	thread := attach("", seen)
+
+	// Comments in a ParentID cycle are not reachable from the top level
+	unreachable := []Comment{}
+	for _, comment := range comments {
+		if !seen[comment.ID] {
+			unreachable = append(unreachable, comment)
+		}
+	}
+	if len(unreachable) == 0 {
+		return thread
+	}
+	sortComments(unreachable)
+	for _, comment := range unreachable {
+		if seen[comment.ID] {
+			continue
+		}
+		seen[comment.ID] = true
+		comment.Children = attach(comment.ID, seen)
+		thread = append(thread, comment)
+	}
+	sortComments(thread)
+	return thread
+}
+
+// sortComments sorts Comments oldest first
+func sortComments(comments []Comment) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].Created == nil || comments[j].Created == nil {
+			return comments[j].Created != nil
+		}
+		return comments[i].Created.Before(comments[j].Created.Time)
+	})
+}
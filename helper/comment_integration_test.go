@@ -0,0 +1,63 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"testing"
+)
+
+// TestCommentThread creates a comment and a reply, edits the reply and
+// checks the decrypted thread read back, independent of the server's comment type.
+func TestCommentThread(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	id, err := CreateResource(ctx, client, "", "commented", "username", "https://url.lan", "password123", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	rootID, err := CreateComment(ctx, client, id, "", "first")
+	if err != nil {
+		t.Fatalf("Creating Comment %v", err)
+	}
+	replyID, err := CreateComment(ctx, client, id, rootID, "reply")
+	if err != nil {
+		t.Fatalf("Creating Reply %v", err)
+	}
+
+	err = UpdateComment(ctx, client, id, replyID, "edited reply")
+	if err != nil {
+		t.Fatalf("Updating Comment %v", err)
+	}
+
+	thread, err := GetCommentThread(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Comment Thread %v", err)
+	}
+	if len(thread) != 1 || thread[0].Content != "first" {
+		t.Fatalf("Thread is %+v", thread)
+	}
+	if len(thread[0].Children) != 1 || thread[0].Children[0].Content != "edited reply" {
+		t.Fatalf("Replies are %+v", thread[0].Children)
+	}
+	if thread[0].Creator == nil {
+		t.Errorf("Creator was not resolved")
+	}
+
+	err = DeleteComment(ctx, client, replyID)
+	if err != nil {
+		t.Fatalf("Deleting Comment %v", err)
+	}
+	comments, err := GetComments(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Comments %v", err)
+	}
+	if len(comments) != 1 {
+		t.Errorf("Comments after delete are %+v", comments)
+	}
+}
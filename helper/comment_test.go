@@ -0,0 +1,100 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// buildCommentThread decides which replies show up under which comment. A
+// regression would hide replies whose parent was deleted or loop forever on
+// a malformed ParentID.
+
+func commentAt(id, parentID string, minute int) Comment {
+	return Comment{
+		ID:       id,
+		ParentID: parentID,
+		Created:  &api.Time{Time: time.Date(2025, 1, 1, 0, minute, 0, 0, time.UTC)},
+	}
+}
+
+func TestBuildCommentThread(t *testing.T) {
+	t.Parallel()
+
+	thread := buildCommentThread([]Comment{
+		commentAt("reply-2", "root", 3),
+		commentAt("root", "", 1),
+		commentAt("reply-1", "root", 2),
+		commentAt("nested", "reply-1", 4),
+		commentAt("orphan", "deleted", 0),
+	})
+
+	if len(thread) != 2 || thread[0].ID != "orphan" || thread[1].ID != "root" {
+		t.Fatalf("top level comments = %+v", thread)
+	}
+	root := thread[1]
+	if len(root.Children) != 2 || root.Children[0].ID != "reply-1" || root.Children[1].ID != "reply-2" {
+		t.Fatalf("root replies = %+v", root.Children)
+	}
+	if len(root.Children[0].Children) != 1 || root.Children[0].Children[0].ID != "nested" {
+		t.Errorf("nested replies = %+v", root.Children[0].Children)
+	}
+}
+
+func TestBuildCommentThread_Cycle(t *testing.T) {
+	t.Parallel()
+
+	thread := buildCommentThread([]Comment{
+		commentAt("a", "b", 0),
+		commentAt("b", "a", 1),
+		commentAt("self", "self", 2),
+		commentAt("c", "a", 3),
+	})
+
+	// The oldest Comment of the cycle is attached at the top level, the rest of it below
+	if len(thread) != 2 || thread[0].ID != "a" || thread[1].ID != "self" {
+		t.Fatalf("thread = %+v", thread)
+	}
+	a := thread[0]
+	if len(a.Children) != 2 || a.Children[0].ID != "b" || a.Children[1].ID != "c" {
+		t.Fatalf("a replies = %+v", a.Children)
+	}
+	if len(a.Children[0].Children) != 0 {
+		t.Errorf("b replies = %+v", a.Children[0].Children)
+	}
+}
+
+func TestFlattenComments(t *testing.T) {
+	t.Parallel()
+
+	flat := flattenComments([]api.Comment{
+		{ID: "root", Children: []api.Comment{{ID: "reply", Children: []api.Comment{{ID: "nested"}}}}},
+	})
+	if len(flat) != 3 || flat[0].ID != "root" || flat[1].ID != "reply" || flat[2].ID != "nested" {
+		t.Fatalf("flattenComments = %+v", flat)
+	}
+	for _, comment := range flat {
+		if comment.Children != nil {
+			t.Errorf("comment %v still has Children", comment.ID)
+		}
+	}
+}
+
+func TestParseCommentMetadata(t *testing.T) {
+	t.Parallel()
+
+	metadata, err := parseCommentMetadata(`{"object_type":"PASSBOLT_COMMENT_METADATA","content":"hello"}`)
+	if err != nil {
+		t.Fatalf("parseCommentMetadata: %v", err)
+	}
+	if metadata.Content != "hello" {
+		t.Errorf("Content = %q", metadata.Content)
+	}
+
+	_, err = parseCommentMetadata(`{"object_type":"PASSBOLT_TAG_METADATA","content":"hello"}`)
+	if !errors.Is(err, ErrInvalidMetadataObjectType) {
+		t.Errorf("wrong object type error = %v", err)
+	}
+}
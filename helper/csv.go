@@ -0,0 +1,353 @@
+package helper
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// CSVField is a Credential field a CSV column maps to
+type CSVField string
+
+const (
+	CSVFieldName         CSVField = "name"
+	CSVFieldUsername     CSVField = "username"
+	CSVFieldURI          CSVField = "uri"
+	CSVFieldPassword     CSVField = "password"
+	CSVFieldDescription  CSVField = "description"
+	CSVFieldFolder       CSVField = "folder"
+	CSVFieldTOTP         CSVField = "totp"
+	CSVFieldCustomFields CSVField = "custom_fields"
+)
+
+// csvFieldOrder is the column order of custom formats without a Header
+var csvFieldOrder = []CSVField{CSVFieldFolder, CSVFieldName, CSVFieldUsername, CSVFieldPassword, CSVFieldURI, CSVFieldDescription, CSVFieldTOTP, CSVFieldCustomFields}
+
+// CSVFormat maps the columns of a CSV file to Credential fields
+type CSVFormat struct {
+	Name string
+	// Header is the header row in file order, used for exports and DetectCSVFormat.
+	// Custom formats may leave it empty, the mapped columns are exported then
+	Header []string
+	// Columns maps fields to header names, matched case insensitively
+	Columns map[CSVField]string
+	// Defaults are the exported values of columns without a field
+	Defaults map[string]string
+	// FolderSeparator separates the folder names in the folder column, defaults to "/"
+	FolderSeparator string
+	// URISeparator separates multiple URIs in the URI column, only the first URI is used if empty
+	URISeparator string
+	// IgnoreURIs are placeholder URIs that are dropped on import
+	IgnoreURIs []string
+}
+
+// CSV formats of Passbolt's own export and of common password managers
+var (
+	CSVFormatPassbolt = CSVFormat{
+		Name:   "passbolt",
+		Header: []string{"Group", "Title", "Username", "Password", "URL", "Notes", "TOTP"},
+		Columns: map[CSVField]string{
+			CSVFieldFolder: "Group", CSVFieldName: "Title", CSVFieldUsername: "Username", CSVFieldPassword: "Password",
+			CSVFieldURI: "URL", CSVFieldDescription: "Notes", CSVFieldTOTP: "TOTP",
+		},
+	}
+	CSVFormatLastPass = CSVFormat{
+		Name:   "lastpass",
+		Header: []string{"url", "username", "password", "totp", "extra", "name", "grouping", "fav"},
+		Columns: map[CSVField]string{
+			CSVFieldURI: "url", CSVFieldUsername: "username", CSVFieldPassword: "password", CSVFieldTOTP: "totp",
+			CSVFieldDescription: "extra", CSVFieldName: "name", CSVFieldFolder: "grouping",
+		},
+		Defaults:        map[string]string{"fav": "0"},
+		FolderSeparator: "\\",
+		// Secure notes have this URL
+		IgnoreURIs: []string{"http://sn"},
+	}
+	CSVFormatBitwarden = CSVFormat{
+		Name:   "bitwarden",
+		Header: []string{"folder", "favorite", "type", "name", "notes", "fields", "reprompt", "login_uri", "login_username", "login_password", "login_totp"},
+		Columns: map[CSVField]string{
+			CSVFieldFolder: "folder", CSVFieldName: "name", CSVFieldDescription: "notes", CSVFieldCustomFields: "fields",
+			CSVFieldURI: "login_uri", CSVFieldUsername: "login_username", CSVFieldPassword: "login_password", CSVFieldTOTP: "login_totp",
+		},
+		Defaults:     map[string]string{"type": "login", "reprompt": "0"},
+		URISeparator: ",",
+	}
+	CSVFormat1Password = CSVFormat{
+		Name:   "1password",
+		Header: []string{"Title", "Url", "Username", "Password", "OTPAuth", "Favorite", "Archived", "Tags", "Notes"},
+		Columns: map[CSVField]string{
+			CSVFieldName: "Title", CSVFieldURI: "Url", CSVFieldUsername: "Username", CSVFieldPassword: "Password",
+			CSVFieldTOTP: "OTPAuth", CSVFieldDescription: "Notes",
+		},
+		Defaults: map[string]string{"Favorite": "false", "Archived": "false"},
+	}
+	CSVFormatChrome = CSVFormat{
+		Name:   "chrome",
+		Header: []string{"name", "url", "username", "password", "note"},
+		Columns: map[CSVField]string{
+			CSVFieldName: "name", CSVFieldURI: "url", CSVFieldUsername: "username", CSVFieldPassword: "password", CSVFieldDescription: "note",
+		},
+	}
+	CSVFormatFirefox = CSVFormat{
+		Name:   "firefox",
+		Header: []string{"url", "username", "password", "httpRealm", "formActionOrigin", "guid", "timeCreated", "timeLastUsed", "timePasswordChanged"},
+		Columns: map[CSVField]string{
+			CSVFieldURI: "url", CSVFieldUsername: "username", CSVFieldPassword: "password",
+		},
+	}
+)
+
+// CSVFormats are the presets, in the order DetectCSVFormat tries them
+var CSVFormats = []CSVFormat{CSVFormatBitwarden, CSVFormatFirefox, CSVFormatLastPass, CSVFormat1Password, CSVFormatPassbolt, CSVFormatChrome}
+
+// GetCSVFormat returns the preset with the given name
+func GetCSVFormat(name string) (CSVFormat, error) {
+	format, err := findBy(CSVFormats, func(f CSVFormat) bool { return strings.EqualFold(f.Name, name) }, ErrUnknownCSVFormat, name)
+	if err != nil {
+		return CSVFormat{}, err
+	}
+	return *format, nil
+}
+
+// DetectCSVFormat returns the first preset whose header columns are all in header
+func DetectCSVFormat(header []string) (CSVFormat, error) {
+	columns := csvHeaderIndex(header)
+	for _, format := range CSVFormats {
+		if !slices.ContainsFunc(format.Header, func(h string) bool { _, ok := columns[strings.ToLower(h)]; return !ok }) {
+			return format, nil
+		}
+	}
+	return CSVFormat{}, fmt.Errorf("%w: header %v", ErrUnknownCSVFormat, header)
+}
+
+// ImportCSV reads a CSV file in format and imports it with ImportCredentials.
+// Values that can't be read are listed in the report's warnings
+func ImportCSV(ctx context.Context, c *api.Client, r io.Reader, format CSVFormat, opts ImportOptions) (*ImportReport, error) {
+	credentials, warnings, err := ReadCSV(r, format)
+	if err != nil {
+		return nil, err
+	}
+	report, err := ImportCredentials(ctx, c, credentials, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.Warnings = append(warnings, report.Warnings...)
+	return report, nil
+}
+
+// ExportCSV writes every resource the user can decrypt as CSV in format
+func ExportCSV(ctx context.Context, c *api.Client, w io.Writer, format CSVFormat) error {
+	credentials, _, err := getCredentials(ctx, c)
+	if err != nil {
+		return err
+	}
+	return WriteCSV(w, format, credentials)
+}
+
+// ReadCSV reads credentials from a CSV file with a header row. Rows without any value are skipped,
+// credentials without a name are named after the host of their URI. TOTPs that can't be parsed
+// are listed in the warnings and kept as a custom field "TOTP" of the credential
+func ReadCSV(r io.Reader, format CSVFormat) ([]Credential, []ImportWarning, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := csvHeaderIndex(header)
+
+	index := map[CSVField]int{}
+	for field, column := range format.Columns {
+		if i, ok := columns[strings.ToLower(column)]; ok {
+			index[field] = i
+		}
+	}
+	if len(index) == 0 {
+		return nil, nil, fmt.Errorf("%w: header %v", ErrCSVColumnsMissing, header)
+	}
+
+	credentials := []Credential{}
+	warnings := []ImportWarning{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return credentials, warnings, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if !slices.ContainsFunc(record, func(v string) bool { return strings.TrimSpace(v) != "" }) {
+			continue
+		}
+
+		credential, warning := csvCredential(record, index, format)
+		if warning != "" {
+			warnings = append(warnings, ImportWarning{Name: credential.Name, Reason: fmt.Sprintf("line %v: %v", line, warning)})
+		}
+		credentials = append(credentials, credential)
+	}
+}
+
+// csvCredential maps a record to a credential, with a warning if a value could not be read
+func csvCredential(record []string, index map[CSVField]int, format CSVFormat) (Credential, string) {
+	get := func(field CSVField) string {
+		i, ok := index[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	separator := format.FolderSeparator
+	if separator == "" {
+		separator = "/"
+	}
+	credential := Credential{
+		FolderPath:   splitFolderPath(get(CSVFieldFolder), separator),
+		Name:         get(CSVFieldName),
+		Username:     get(CSVFieldUsername),
+		URIs:         []string{},
+		Password:     get(CSVFieldPassword),
+		Description:  get(CSVFieldDescription),
+		CustomFields: CustomFields{},
+	}
+
+	uris := []string{get(CSVFieldURI)}
+	if format.URISeparator != "" {
+		uris = strings.Split(uris[0], format.URISeparator)
+	}
+	for _, uri := range uris {
+		if uri = strings.TrimSpace(uri); uri != "" && !slices.Contains(format.IgnoreURIs, uri) {
+			credential.URIs = append(credential.URIs, uri)
+		}
+	}
+	if credential.Name == "" && len(credential.URIs) > 0 {
+		credential.Name = uriHost(credential.URIs[0])
+	}
+
+	warning := ""
+	if totp := strings.TrimSpace(get(CSVFieldTOTP)); totp != "" {
+		var err error
+		credential.TOTP, err = parseTOTPValue(totp)
+		if err != nil {
+			// Where the custom field ends up depends on the resource type, ImportCredentials warns about that itself
+			warning = fmt.Sprintf("TOTP %v, not imported as TOTP", err)
+			credential.CustomFields = append(credential.CustomFields, CustomField{Key: "TOTP", Type: CustomFieldTypePassword, Value: totp})
+		}
+	}
+
+	for _, line := range strings.Split(get(CSVFieldCustomFields), "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		credential.CustomFields = append(credential.CustomFields, CustomField{Key: strings.TrimSpace(key), Type: CustomFieldTypeText, Value: value})
+	}
+	return credential, warning
+}
+
+// parseTOTPValue parses an otpauth URI or a bare base32 secret with the default parameters
+func parseTOTPValue(value string) (*api.SecretDataTOTP, error) {
+	if strings.HasPrefix(value, "otpauth://") {
+		return ParseOTPAuthURI(value)
+	}
+	return ParseOTPAuthURI("otpauth://totp/?secret=" + value)
+}
+
+// WriteCSV writes credentials as CSV in format. Only the first URI is written if the format has no URISeparator
+func WriteCSV(w io.Writer, format CSVFormat, credentials []Credential) error {
+	header := format.Header
+	if len(header) == 0 {
+		for _, field := range csvFieldOrder {
+			if column, ok := format.Columns[field]; ok {
+				header = append(header, column)
+			}
+		}
+	}
+	fields := map[string]CSVField{}
+	for field, column := range format.Columns {
+		fields[strings.ToLower(column)] = field
+	}
+	separator := format.FolderSeparator
+	if separator == "" {
+		separator = "/"
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(header)
+	if err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	for _, credential := range credentials {
+		record := make([]string, len(header))
+		for i, column := range header {
+			field, ok := fields[strings.ToLower(column)]
+			if !ok {
+				record[i] = format.Defaults[column]
+				continue
+			}
+			record[i] = csvValue(credential, field, format, separator)
+		}
+		err := writer.Write(record)
+		if err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
+
+func csvValue(credential Credential, field CSVField, format CSVFormat, separator string) string {
+	switch field {
+	case CSVFieldName:
+		return credential.Name
+	case CSVFieldUsername:
+		return credential.Username
+	case CSVFieldPassword:
+		return credential.Password
+	case CSVFieldDescription:
+		return credential.Description
+	case CSVFieldFolder:
+		return strings.Join(credential.FolderPath, separator)
+	case CSVFieldURI:
+		if format.URISeparator != "" {
+			return strings.Join(credential.URIs, format.URISeparator)
+		}
+		if len(credential.URIs) > 0 {
+			return credential.URIs[0]
+		}
+	case CSVFieldTOTP:
+		if credential.TOTP != nil {
+			return OTPAuthURI(*credential.TOTP, credential.Name)
+		}
+	case CSVFieldCustomFields:
+		lines := []string{}
+		for _, f := range credential.CustomFields {
+			lines = append(lines, fmt.Sprintf("%v: %v", f.Key, f.Value))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// csvHeaderIndex maps the lower case column names to their index, a leading byte order mark is removed
+func csvHeaderIndex(header []string) map[string]int {
+	columns := map[string]int{}
+	for i, column := range header {
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	return columns
+}
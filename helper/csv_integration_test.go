@@ -0,0 +1,87 @@
+//go:build integration
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestImportExportCSV(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	data := "folder,favorite,type,name,notes,fields,reprompt,login_uri,login_username,login_password,login_totp\n" +
+		"csv import/Servers,,login,csv-mail,notes,,0,https://csv-mail.example.com,alice,pw1,\n" +
+		"csv import,,login,csv-router,,,0,https://csv-router.example.com,admin,pw2,JBSWY3DPEHPK3PXP\n"
+
+	report, err := ImportCSV(ctx, client, strings.NewReader(data), CSVFormatBitwarden, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry run %v", err)
+	}
+	if report.Count(ImportActionCreate) != 2 || len(report.Folders) != 2 || report.Results[0].ResourceID != "" {
+		t.Fatalf("got dry run %+v", report)
+	}
+	tree, err := getFolderTree(ctx, client)
+	if err != nil {
+		t.Fatalf("Getting Folders %v", err)
+	}
+	if _, ok := tree.children[""]["csv import"]; ok {
+		t.Fatalf("dry run created a Folder")
+	}
+
+	report, err = ImportCSV(ctx, client, strings.NewReader(data), CSVFormatBitwarden, ImportOptions{})
+	if report != nil {
+		defer cleanupImport(ctx, report)
+	}
+	if err != nil {
+		t.Fatalf("Importing CSV %v", err)
+	}
+	if report.Count(ImportActionCreate) != 2 {
+		t.Fatalf("got %+v", report)
+	}
+
+	folderID, err := EnsureFolderPath(ctx, client, "", "csv import/Servers")
+	if err != nil {
+		t.Fatalf("EnsureFolderPath %v", err)
+	}
+	folderParentID, _, _, _, password, _, err := GetResource(ctx, client, report.Results[0].ResourceID)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if folderParentID != folderID || password != "pw1" {
+		t.Fatalf("got folder %q and password %q", folderParentID, password)
+	}
+
+	// Importing again only finds duplicates
+	again, err := ImportCSV(ctx, client, strings.NewReader(data), CSVFormatBitwarden, ImportOptions{})
+	if err != nil {
+		t.Fatalf("Importing CSV again %v", err)
+	}
+	if again.Count(ImportActionDuplicate) != 2 || again.Results[0].ResourceID != report.Results[0].ResourceID {
+		t.Fatalf("got %+v", again)
+	}
+
+	var buf bytes.Buffer
+	err = ExportCSV(ctx, client, &buf, CSVFormatPassbolt)
+	if err != nil {
+		t.Fatalf("Exporting CSV %v", err)
+	}
+	credentials, _, err := ReadCSV(&buf, CSVFormatPassbolt)
+	if err != nil {
+		t.Fatalf("Reading export %v", err)
+	}
+	found := false
+	for _, c := range credentials {
+		if c.Name == "csv-router" {
+			found = strings.Join(c.FolderPath, "/") == "csv import" && c.Password == "pw2" && c.TOTP != nil
+		}
+	}
+	if !found {
+		t.Fatalf("csv-router missing in export")
+	}
+}
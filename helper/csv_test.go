@@ -0,0 +1,175 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestReadCSVPresets(t *testing.T) {
+	totp := &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DP", Digits: 6, Period: 30}
+	tests := []struct {
+		format CSVFormat
+		data   string
+		want   Credential
+	}{{
+		format: CSVFormatPassbolt,
+		data: "\"Group\",\"Title\",\"Username\",\"Password\",\"URL\",\"Notes\",\"TOTP\"\n" +
+			"\"Team/Servers\",\"Mail\",\"alice\",\"pw\",\"https://mail\",\"notes\",\"otpauth://totp/Mail?secret=JBSWY3DP\"\n",
+		want: Credential{FolderPath: []string{"Team", "Servers"}, Name: "Mail", Username: "alice", Password: "pw", URIs: []string{"https://mail"}, Description: "notes", TOTP: totp},
+	}, {
+		format: CSVFormatLastPass,
+		data: "url,username,password,totp,extra,name,grouping,fav\n" +
+			"https://mail,alice,pw,JBSWY3DP,notes,Mail,Team\\Servers,0\n",
+		want: Credential{FolderPath: []string{"Team", "Servers"}, Name: "Mail", Username: "alice", Password: "pw", URIs: []string{"https://mail"}, Description: "notes", TOTP: totp},
+	}, {
+		format: CSVFormatLastPass,
+		data: "url,username,password,totp,extra,name,grouping,fav\n" +
+			"http://sn,,,,secret note,Note,,0\n",
+		want: Credential{FolderPath: []string{}, Name: "Note", URIs: []string{}, Description: "secret note"},
+	}, {
+		format: CSVFormatBitwarden,
+		data: "folder,favorite,type,name,notes,fields,reprompt,login_uri,login_username,login_password,login_totp\n" +
+			"Team,,login,Mail,notes,\"Port: 5432\nRegion: eu\",0,\"https://mail,https://webmail\",alice,pw,otpauth://totp/Mail?secret=JBSWY3DP\n",
+		want: Credential{
+			FolderPath: []string{"Team"}, Name: "Mail", Username: "alice", Password: "pw", URIs: []string{"https://mail", "https://webmail"}, Description: "notes", TOTP: totp,
+			CustomFields: CustomFields{{Key: "Port", Type: CustomFieldTypeText, Value: "5432"}, {Key: "Region", Type: CustomFieldTypeText, Value: "eu"}},
+		},
+	}, {
+		format: CSVFormat1Password,
+		data: "Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes\n" +
+			"Mail,https://mail,alice,pw,otpauth://totp/Mail?secret=JBSWY3DP,false,false,work,notes\n",
+		want: Credential{FolderPath: []string{}, Name: "Mail", Username: "alice", Password: "pw", URIs: []string{"https://mail"}, Description: "notes", TOTP: totp},
+	}, {
+		format: CSVFormatChrome,
+		data: "\ufeffname,url,username,password,note\n" +
+			"Mail,https://mail,alice,pw,notes\n",
+		want: Credential{FolderPath: []string{}, Name: "Mail", Username: "alice", Password: "pw", URIs: []string{"https://mail"}, Description: "notes"},
+	}, {
+		format: CSVFormatFirefox,
+		data: "\"url\",\"username\",\"password\",\"httpRealm\",\"formActionOrigin\",\"guid\",\"timeCreated\",\"timeLastUsed\",\"timePasswordChanged\"\n" +
+			"\"https://Mail.example.com:8443\",\"alice\",\"pw\",,\"\",\"{1}\",\"1\",\"1\",\"1\"\n",
+		want: Credential{FolderPath: []string{}, Name: "mail.example.com", Username: "alice", Password: "pw", URIs: []string{"https://Mail.example.com:8443"}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.format.Name, func(t *testing.T) {
+			credentials, warnings, err := ReadCSV(strings.NewReader(tt.data), tt.format)
+			if err != nil || len(warnings) != 0 {
+				t.Fatalf("ReadCSV: %v, %+v", err, warnings)
+			}
+			if tt.want.CustomFields == nil {
+				tt.want.CustomFields = CustomFields{}
+			}
+			if len(credentials) != 1 || !reflect.DeepEqual(credentials[0], tt.want) {
+				t.Errorf("got %+v\nwant %+v", credentials, tt.want)
+			}
+
+			header, _, _ := strings.Cut(strings.TrimPrefix(tt.data, "\ufeff"), "\n")
+			detected, err := DetectCSVFormat(strings.Split(strings.ReplaceAll(header, "\"", ""), ","))
+			if err != nil || detected.Name != tt.format.Name {
+				t.Errorf("DetectCSVFormat = %q, %v", detected.Name, err)
+			}
+		})
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	_, _, err := ReadCSV(strings.NewReader("a,b\n1,2\n"), CSVFormatChrome)
+	if !errors.Is(err, ErrCSVColumnsMissing) {
+		t.Errorf("got %v, want ErrCSVColumnsMissing", err)
+	}
+	_, _, err = ReadCSV(strings.NewReader("name,url,username,password,note\n,,,,\nMail,,,,\nBad,,,,\n"), CSVFormat{
+		Columns: map[CSVField]string{CSVFieldName: "name", CSVFieldTOTP: "note"},
+	})
+	if err != nil {
+		t.Errorf("empty rows must be skipped: %v", err)
+	}
+
+	// A TOTP that can't be parsed is kept as custom field, the other rows are still read
+	credentials, warnings, err := ReadCSV(strings.NewReader("name,totp\nMail,otpauth://hotp/x?secret=A\nBank,JBSWY3DP\n"), CSVFormat{
+		Columns: map[CSVField]string{CSVFieldName: "name", CSVFieldTOTP: "totp"},
+	})
+	if err != nil || len(credentials) != 2 {
+		t.Fatalf("got %+v, %v", credentials, err)
+	}
+	if len(warnings) != 1 || warnings[0].Name != "Mail" || !strings.HasPrefix(warnings[0].Reason, "line 2: TOTP ") || !strings.HasSuffix(warnings[0].Reason, ", not imported as TOTP") {
+		t.Errorf("got warnings %+v", warnings)
+	}
+	want := CustomFields{{Key: "TOTP", Type: CustomFieldTypePassword, Value: "otpauth://hotp/x?secret=A"}}
+	if credentials[0].TOTP != nil || !reflect.DeepEqual(credentials[0].CustomFields, want) || credentials[1].TOTP == nil {
+		t.Errorf("got %+v", credentials)
+	}
+	_, err = DetectCSVFormat([]string{"a", "b"})
+	if !errors.Is(err, ErrUnknownCSVFormat) {
+		t.Errorf("got %v, want ErrUnknownCSVFormat", err)
+	}
+	_, err = GetCSVFormat("keepass")
+	if !errors.Is(err, ErrUnknownCSVFormat) {
+		t.Errorf("got %v, want ErrUnknownCSVFormat", err)
+	}
+}
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	credentials := []Credential{{
+		FolderPath:   []string{"Team", "Servers"},
+		Name:         "Mail",
+		Username:     "alice",
+		Password:     "p,w\"",
+		URIs:         []string{"https://mail", "https://webmail"},
+		Description:  "multi\nline",
+		TOTP:         &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DP", Digits: 6, Period: 30},
+		CustomFields: CustomFields{{Key: "Port", Type: CustomFieldTypeText, Value: "5432"}},
+	}}
+
+	for _, format := range CSVFormats {
+		t.Run(format.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteCSV(&buf, format, credentials)
+			if err != nil {
+				t.Fatalf("WriteCSV: %v", err)
+			}
+			read, _, err := ReadCSV(&buf, format)
+			if err != nil {
+				t.Fatalf("ReadCSV: %v", err)
+			}
+			if len(read) != 1 || read[0].Password != "p,w\"" || read[0].Username != "alice" || read[0].URIs[0] != "https://mail" {
+				t.Fatalf("got %+v", read)
+			}
+			if _, ok := format.Columns[CSVFieldFolder]; ok && !reflect.DeepEqual(read[0].FolderPath, credentials[0].FolderPath) {
+				t.Errorf("folder path = %v", read[0].FolderPath)
+			}
+			if _, ok := format.Columns[CSVFieldTOTP]; ok && (read[0].TOTP == nil || *read[0].TOTP != *credentials[0].TOTP) {
+				t.Errorf("TOTP = %+v", read[0].TOTP)
+			}
+		})
+	}
+
+	// Bitwarden keeps all URIs and the custom fields, the constant columns are filled in
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, CSVFormatBitwarden, credentials)
+	if err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	if !strings.Contains(buf.String(), ",login,Mail,") {
+		t.Errorf("type column missing: %q", buf.String())
+	}
+	read, _, _ := ReadCSV(&buf, CSVFormatBitwarden)
+	if !reflect.DeepEqual(read[0].URIs, credentials[0].URIs) || !reflect.DeepEqual(read[0].CustomFields, credentials[0].CustomFields) {
+		t.Errorf("got %+v", read[0])
+	}
+
+	// Custom formats without a header write the mapped columns
+	buf.Reset()
+	err = WriteCSV(&buf, CSVFormat{Columns: map[CSVField]string{CSVFieldPassword: "secret", CSVFieldName: "title"}}, credentials)
+	if err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	if want := "title,secret\nMail,\"p,w\"\"\"\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
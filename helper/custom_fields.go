@@ -1,11 +1,247 @@
 package helper
 
 import (
+	"context"
 	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
+	"github.com/passbolt/go-passbolt/api"
 )
 
+// CustomField is a decrypted custom field of a resource. The Key is stored in the
+// encrypted metadata and the Value in the secret
+type CustomField struct {
+	ID    string
+	Key   string
+	Type  CustomFieldType
+	Value any
+}
+
+// CustomFields are the custom fields of a resource in display order.
+// Changes are only saved by UpdateCustomFields
+type CustomFields []CustomField
+
+// GetCustomFields gets and decrypts the custom fields of a resource.
+// Returns ErrResourceTypeMismatch if the resource type has no custom fields
+func GetCustomFields(ctx context.Context, c *api.Client, resourceID string) (CustomFields, error) {
+	resource, err := c.GetResource(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting resource: %w", err)
+	}
+	rType, err := c.GetResourceType(ctx, resource.ResourceTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("getting resource type: %w", err)
+	}
+	if !rType.HasMetadataField("custom_fields") {
+		return nil, fmt.Errorf("%w: %q has no custom fields", ErrResourceTypeMismatch, rType.Slug)
+	}
+	secret, err := c.GetSecret(ctx, resource.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting resource secret: %w", err)
+	}
+
+	metadataFields, secretFields, err := resourceFieldMaps(c, *resource, *secret, *rType, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseCustomFields(metadataFields, secretFields)
+}
+
+// UpdateCustomFields replaces the custom fields of a resource, keys are written to the
+// metadata and values to the secret regardless of where they were stored before
+func UpdateCustomFields(ctx context.Context, c *api.Client, resourceID string, fields CustomFields) error {
+	metadataFields, secretFields := fields.fieldMaps()
+	return UpdateResourceGeneric(ctx, c, resourceID, metadataFields, secretFields)
+}
+
+// Get returns the custom field with the given ID
+func (f CustomFields) Get(id string) (CustomField, error) {
+	i, err := f.index(id)
+	if err != nil {
+		return CustomField{}, err
+	}
+	return f[i], nil
+}
+
+// Add appends a new custom field with a generated ID and returns the ID
+func (f *CustomFields) Add(key string, fieldType CustomFieldType, value any) (string, error) {
+	if key == "" {
+		return "", ErrCustomFieldEmptyKey
+	}
+	value, err := checkCustomFieldValue(fieldType, value)
+	if err != nil {
+		return "", err
+	}
+
+	id := uuid.NewString()
+	*f = append(*f, CustomField{
+		ID:    id,
+		Key:   key,
+		Type:  fieldType,
+		Value: value,
+	})
+	return id, nil
+}
+
+// Rename changes the key of a custom field
+func (f CustomFields) Rename(id, key string) error {
+	if key == "" {
+		return ErrCustomFieldEmptyKey
+	}
+	i, err := f.index(id)
+	if err != nil {
+		return err
+	}
+	f[i].Key = key
+	return nil
+}
+
+// SetValue changes the value of a custom field, the value must match the field's type
+func (f CustomFields) SetValue(id string, value any) error {
+	i, err := f.index(id)
+	if err != nil {
+		return err
+	}
+	value, err = checkCustomFieldValue(f[i].Type, value)
+	if err != nil {
+		return err
+	}
+	f[i].Value = value
+	return nil
+}
+
+// Move moves a custom field to position index, shifting the fields in between
+func (f CustomFields) Move(id string, index int) error {
+	i, err := f.index(id)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(f) {
+		return fmt.Errorf("%w: %d", ErrCustomFieldIndexOutOfRange, index)
+	}
+
+	field := f[i]
+	if index < i {
+		copy(f[index+1:i+1], f[index:i])
+	} else {
+		copy(f[i:index], f[i+1:index+1])
+	}
+	f[index] = field
+	return nil
+}
+
+// Delete removes a custom field
+func (f *CustomFields) Delete(id string) error {
+	i, err := f.index(id)
+	if err != nil {
+		return err
+	}
+	*f = slices.Delete(*f, i, i+1)
+	return nil
+}
+
+func (f CustomFields) index(id string) (int, error) {
+	i := slices.IndexFunc(f, func(field CustomField) bool { return field.ID == id })
+	if i == -1 {
+		return -1, fmt.Errorf("%w: %v", ErrCustomFieldNotFound, id)
+	}
+	return i, nil
+}
+
+// fieldMaps splits the custom fields into the metadata and secret custom_fields arrays,
+// only the key goes into the metadata and only the value into the secret
+func (f CustomFields) fieldMaps() (metadataFields, secretFields map[string]any) {
+	metadataCF := make([]any, 0, len(f))
+	secretCF := make([]any, 0, len(f))
+	for _, field := range f {
+		metadataCF = append(metadataCF, map[string]any{
+			"id":           field.ID,
+			"type":         string(field.Type),
+			"metadata_key": field.Key,
+		})
+		secretCF = append(secretCF, map[string]any{
+			"id":           field.ID,
+			"type":         string(field.Type),
+			"secret_value": field.Value,
+		})
+	}
+	return map[string]any{"custom_fields": metadataCF}, map[string]any{"custom_fields": secretCF}
+}
+
+// parseCustomFields joins the metadata and secret custom_fields arrays by ID in metadata order.
+// Keys and values are read from whichever side holds them
+func parseCustomFields(metadataFields, secretFields map[string]any) (CustomFields, error) {
+	if err := validateCustomFields(metadataFields, secretFields); err != nil {
+		return nil, err
+	}
+
+	var metadata V5CustomFieldsMetadata
+	if err := fromFieldMap(metadataFields, &metadata); err != nil {
+		return nil, fmt.Errorf("decoding metadata custom fields: %w", err)
+	}
+	var secret V5CustomFieldsSecret
+	if err := fromFieldMap(secretFields, &secret); err != nil {
+		return nil, fmt.Errorf("decoding secret custom fields: %w", err)
+	}
+
+	secretByID := make(map[string]CustomFieldSecret, len(secret.CustomFields))
+	for _, cf := range secret.CustomFields {
+		secretByID[cf.ID] = cf
+	}
+
+	fields := make(CustomFields, 0, len(metadata.CustomFields))
+	for _, cf := range metadata.CustomFields {
+		secretCF := secretByID[cf.ID]
+		field := CustomField{
+			ID:    cf.ID,
+			Key:   cf.MetadataKey,
+			Type:  cf.Type,
+			Value: secretCF.SecretValue,
+		}
+		if field.Key == "" {
+			field.Key = secretCF.SecretKey
+		}
+		if field.Value == nil || field.Value == "" {
+			if cf.MetadataValue != nil {
+				field.Value = cf.MetadataValue
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// checkCustomFieldValue checks value matches fieldType and normalizes numbers to float64 like decoded JSON
+func checkCustomFieldValue(fieldType CustomFieldType, value any) (any, error) {
+	switch fieldType {
+	case CustomFieldTypeText, CustomFieldTypePassword, CustomFieldTypeURI:
+		if _, ok := value.(string); ok {
+			return value, nil
+		}
+	case CustomFieldTypeBoolean:
+		if _, ok := value.(bool); ok {
+			return value, nil
+		}
+	case CustomFieldTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case int32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrCustomFieldValueType, fieldType)
+	}
+	return nil, fmt.Errorf("%w: %T is not a valid %v value", ErrCustomFieldValueType, value, fieldType)
+}
+
 // validateCustomFields validates custom_fields arrays in metadata and secret maps
 // before encryption. This enforces the same rules as the Passbolt web extension:
 //   - Every custom field id must be a valid UUID
@@ -0,0 +1,67 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// TestCustomFieldsEditing adds, edits and deletes custom fields on a v5-custom-fields resource.
+func TestCustomFieldsEditing(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	if client.MetadataTypeSettings().DefaultResourceType != api.PassboltAPIVersionTypeV5 {
+		t.Skip("server does not default to v5 resources")
+	}
+	ctx := context.TODO()
+
+	id, err := CreateTyped(ctx, client, "",
+		V5CustomFieldsMetadata{Name: "fields", CustomFields: []CustomFieldMetadata{}},
+		V5CustomFieldsSecret{CustomFields: []CustomFieldSecret{}},
+	)
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	fields, err := GetCustomFields(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Custom Fields %v", err)
+	}
+	pin, err := fields.Add("pin", CustomFieldTypePassword, "1234")
+	if err != nil {
+		t.Fatalf("Adding Custom Field %v", err)
+	}
+	note, err := fields.Add("note", CustomFieldTypeText, "hello")
+	if err != nil {
+		t.Fatalf("Adding Custom Field %v", err)
+	}
+	err = UpdateCustomFields(ctx, client, id, fields)
+	if err != nil {
+		t.Fatalf("Updating Custom Fields %v", err)
+	}
+
+	fields, err = GetCustomFields(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Custom Fields %v", err)
+	}
+	_ = fields.SetValue(pin, "4321")
+	_ = fields.Move(note, 0)
+	_ = fields.Rename(note, "comment")
+	err = UpdateCustomFields(ctx, client, id, fields)
+	if err != nil {
+		t.Fatalf("Updating Custom Fields %v", err)
+	}
+
+	fields, err = GetCustomFields(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Custom Fields %v", err)
+	}
+	if len(fields) != 2 || fields[0].Key != "comment" || fields[1].Value != "4321" {
+		t.Fatalf("Custom Fields are %+v", fields)
+	}
+}
@@ -3,6 +3,8 @@ package helper
 import (
 	"errors"
 	"testing"
+
+	"github.com/google/uuid"
 )
 
 // validateCustomFields is security-critical: it enforces the same
@@ -292,3 +294,123 @@ func Test_hasNonEmptyString(t *testing.T) {
 		})
 	}
 }
+
+// The editing API must keep keys in the metadata and values in the
+// secret, otherwise a secret value would be readable by everyone who can
+// decrypt the shared metadata.
+
+func TestCustomFields_FieldMapsSplitKeysAndValues(t *testing.T) {
+	t.Parallel()
+
+	var fields CustomFields
+	id, err := fields.Add("pin", CustomFieldTypePassword, "1234")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		t.Fatalf("Add returned invalid id %q", id)
+	}
+
+	metadataFields, secretFields := fields.fieldMaps()
+	if err := validateCustomFields(metadataFields, secretFields); err != nil {
+		t.Fatalf("validateCustomFields: %v", err)
+	}
+
+	metaCF, _ := extractCustomFields(metadataFields)
+	secretCF, _ := extractCustomFields(secretFields)
+	if _, ok := metaCF[0]["metadata_value"]; ok {
+		t.Error("value leaked into metadata")
+	}
+	if _, ok := secretCF[0]["secret_key"]; ok {
+		t.Error("key written to secret")
+	}
+	if metaCF[0]["metadata_key"] != "pin" || secretCF[0]["secret_value"] != "1234" {
+		t.Errorf("metadata %v, secret %v", metaCF[0], secretCF[0])
+	}
+}
+
+func TestCustomFields_Edit(t *testing.T) {
+	t.Parallel()
+
+	var fields CustomFields
+	a, _ := fields.Add("a", CustomFieldTypeText, "1")
+	b, _ := fields.Add("b", CustomFieldTypeNumber, 2)
+	c, _ := fields.Add("c", CustomFieldTypeBoolean, true)
+
+	if err := fields.Move(c, 0); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if err := fields.Move(a, 2); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if got := []string{fields[0].ID, fields[1].ID, fields[2].ID}; got[0] != c || got[1] != b || got[2] != a {
+		t.Errorf("order after Move = %v", got)
+	}
+
+	if err := fields.Rename(b, "renamed"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if err := fields.SetValue(b, 3); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	field, _ := fields.Get(b)
+	if field.Key != "renamed" || field.Value != float64(3) {
+		t.Errorf("field after edit = %+v", field)
+	}
+
+	if err := fields.SetValue(b, "three"); !errors.Is(err, ErrCustomFieldValueType) {
+		t.Errorf("SetValue with wrong type = %v", err)
+	}
+	if err := fields.Rename(b, ""); !errors.Is(err, ErrCustomFieldEmptyKey) {
+		t.Errorf("Rename to empty key = %v", err)
+	}
+	if err := fields.Move(b, 3); !errors.Is(err, ErrCustomFieldIndexOutOfRange) {
+		t.Errorf("Move out of range = %v", err)
+	}
+
+	if err := fields.Delete(c); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := fields.Get(c); !errors.Is(err, ErrCustomFieldNotFound) {
+		t.Errorf("Get deleted field = %v", err)
+	}
+	if len(fields) != 2 {
+		t.Errorf("len after Delete = %d", len(fields))
+	}
+}
+
+func TestParseCustomFields(t *testing.T) {
+	t.Parallel()
+
+	const idA = "11111111-1111-1111-1111-111111111111"
+	const idB = "22222222-2222-2222-2222-222222222222"
+
+	// idB was written by a client that kept its value in the metadata
+	fields, err := parseCustomFields(map[string]any{
+		"custom_fields": []any{
+			map[string]any{"id": idB, "type": "text", "metadata_key": "b", "metadata_value": "visible"},
+			map[string]any{"id": idA, "type": "password", "metadata_key": "a"},
+		},
+	}, map[string]any{
+		"custom_fields": []any{
+			map[string]any{"id": idA, "type": "password", "secret_value": "hidden"},
+			map[string]any{"id": idB, "type": "text", "secret_value": ""},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseCustomFields: %v", err)
+	}
+	if len(fields) != 2 || fields[0].ID != idB || fields[1].ID != idA {
+		t.Fatalf("fields = %+v", fields)
+	}
+	if fields[0].Value != "visible" || fields[1].Value != "hidden" || fields[1].Key != "a" {
+		t.Errorf("fields = %+v", fields)
+	}
+
+	// Writing it back moves the value into the secret
+	metadataFields, _ := fields.fieldMaps()
+	metaCF, _ := extractCustomFields(metadataFields)
+	if _, ok := metaCF[0]["metadata_value"]; ok {
+		t.Error("metadata_value was written back")
+	}
+}
@@ -6,6 +6,9 @@ import "errors"
 // that cannot be decoded by the helper functions.
 var ErrUnsupportedResourceType = errors.New("unsupported resource type")
 
+// ErrResourceTypeMismatch is returned when a typed helper is used on a resource of a different resource type.
+var ErrResourceTypeMismatch = errors.New("resource has a different resource type")
+
 var (
 	// Resource creation errors
 	ErrV5CreationDisabled       = errors.New("creation of V5 passwords is disabled on this server")
@@ -13,10 +16,100 @@ var (
 	ErrResourceTypeSlugNotFound = errors.New("cannot find resource type")
 	ErrPasswordTooLong          = errors.New("password exceeds maximum length")
 
+	// Folder creation errors
+	ErrV5FolderCreationDisabled = errors.New("creation of V5 folders is disabled on this server")
+	ErrV4FolderCreationDisabled = errors.New("creation of V4 folders is disabled on this server")
+
+	// Tag errors
+	ErrV5TagCreationDisabled = errors.New("creation of V5 tags is disabled on this server")
+	ErrV4TagCreationDisabled = errors.New("creation of V4 tags is disabled on this server")
+	ErrTagSharedMismatch     = errors.New("shared tag slugs must start with # and personal tag slugs must not")
+
+	// Comment errors
+	ErrV5CommentCreationDisabled = errors.New("creation of V5 comments is disabled on this server")
+	ErrV4CommentCreationDisabled = errors.New("creation of V4 comments is disabled on this server")
+
+	// Metadata errors
+	ErrInvalidMetadataObjectType = errors.New("metadata has unexpected object type")
+
 	// Lookup errors
 	ErrKeyNotFound        = errors.New("cannot find key for user")
 	ErrMembershipNotFound = errors.New("cannot find membership for user")
 	ErrSecretNotFound     = errors.New("cannot find secret for resource")
+	ErrResourceNotFound   = errors.New("cannot find resource")
+	ErrFolderNotFound     = errors.New("cannot find folder")
+	ErrTagNotFound        = errors.New("cannot find tag")
+	ErrCommentNotFound    = errors.New("cannot find comment")
+	ErrUserNotFound       = errors.New("cannot find user")
+
+	// Password generator errors
+	ErrNoCharacterMasks = errors.New("password policy enables no character masks")
+	ErrEntropyTooLow    = errors.New("password policy cannot reach the requested entropy")
+
+	// Offboarding errors
+	ErrNoNewOwner        = errors.New("a new owner User or Group is needed to transfer ownership")
+	ErrNoNewGroupManager = errors.New("a new group manager User is needed to transfer group management")
+
+	ErrInvalidOTPAuthURI = errors.New("invalid otpauth URI")
+
+	ErrUnknownCSVFormat  = errors.New("unknown CSV format")
+	ErrCSVColumnsMissing = errors.New("CSV has none of the mapped columns")
+
+	ErrBitwardenFormat           = errors.New("invalid Bitwarden export")
+	ErrBitwardenInvalidPassword  = errors.New("invalid Bitwarden export password")
+	ErrBitwardenAccountEncrypted = errors.New("Bitwarden export is encrypted with the account key, export it password protected instead")
+
+	ErrPasswordStoreNoRecipients = errors.New("password store needs at least one recipient key")
+
+	// Vault spec errors
+	ErrSpecInvalid          = errors.New("invalid vault spec")
+	ErrSpecSecret           = errors.New("cannot read secret of vault spec")
+	ErrSpecUnknownPrincipal = errors.New("vault spec references an unknown user or group")
+	ErrPlanDestructive      = errors.New("plan contains destructive changes")
+
+	// Secret reference errors
+	ErrInvalidSecretRef   = errors.New("invalid secret reference")
+	ErrSecretRefAmbiguous = errors.New("secret reference matches several resources")
+	ErrSecretRefField     = errors.New("secret reference field not found")
+
+	// Exec errors
+	ErrExecEnvName = errors.New("invalid environment variable name")
+
+	// SSH agent errors
+	ErrSSHAgentNoSelector  = errors.New("ssh agent needs a folder path or tag")
+	ErrSSHAgentReadOnly    = errors.New("ssh agent keys can only be changed in Passbolt")
+	ErrSSHAgentLocked      = errors.New("ssh agent is locked")
+	ErrSSHAgentUnlock      = errors.New("ssh agent is not locked or the passphrase is wrong")
+	ErrSSHAgentClosed      = errors.New("ssh agent is closed")
+	ErrSSHAgentKeyNotFound = errors.New("ssh agent has no such key")
+	ErrSSHAgentRefused     = errors.New("ssh agent signature was not confirmed")
+	ErrSSHAgentUnsupported = errors.New("ssh agent does not support the signature request")
+
+	// Sync errors
+	ErrSyncNoFolders    = errors.New("sync needs a folder selector")
+	ErrSyncStateVersion = errors.New("unsupported sync state version")
+
+	// Vault backup errors
+	ErrBackupNoRecipients = errors.New("vault backup needs at least one recovery key")
+	ErrBackupSignature    = errors.New("vault backup signature is invalid")
+	ErrBackupFormat       = errors.New("invalid vault backup")
+	ErrBackupVersion      = errors.New("unsupported vault backup version")
+
+	// KDBX errors
+	ErrKDBXFormat          = errors.New("not a KDBX file")
+	ErrKDBXUnsupported     = errors.New("unsupported KDBX feature")
+	ErrKDBXCorrupt         = errors.New("KDBX file is corrupted")
+	ErrKDBXInvalidPassword = errors.New("invalid KDBX password")
+
+	// Lease errors
+	ErrAlreadyShared = errors.New("resource is already shared with this user or group")
+
+	// Rotation errors
+	ErrRotationFailed  = errors.New("credential rotation failed")
+	ErrRollbackFailed  = errors.New("credential rotation rollback failed")
+	ErrRotationPending = errors.New("resource has an unverified previous rotation")
+
+	ErrRotationNoPreviousPassword = errors.New("resource type can't keep the previous password during rotation")
 
 	// Custom field validation errors
 	ErrCustomFieldInvalidID    = errors.New("custom field id must be a valid UUID")
@@ -24,4 +117,10 @@ var (
 	ErrCustomFieldMissingValue = errors.New("custom field in secret must have secret_value")
 	ErrCustomFieldCrossField   = errors.New("custom field key/value must be defined in only one of metadata or secret")
 	ErrCustomFieldIDMismatch   = errors.New("custom field ids must match in metadata and secret arrays")
+
+	// Custom field editing errors
+	ErrCustomFieldNotFound        = errors.New("cannot find custom field")
+	ErrCustomFieldEmptyKey        = errors.New("custom field key must not be empty")
+	ErrCustomFieldValueType       = errors.New("custom field value does not match its type")
+	ErrCustomFieldIndexOutOfRange = errors.New("custom field index out of range")
 )
@@ -0,0 +1,196 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// ExecMask replaces secret values in the output of a command run with ExecOptions.Mask
+const ExecMask = "****"
+
+// ExecOptions configure ExecWithSecrets
+type ExecOptions struct {
+	// Env maps environment variable names to passbolt:// references or Resource IDs, IDs resolve to the password
+	Env map[string]string
+	// Mask replaces the secret values in the command's stdout and stderr with ExecMask
+	Mask bool
+	// Signals are forwarded to the command, by default interrupt, SIGTERM, SIGHUP and SIGQUIT
+	Signals []os.Signal
+	// Resolver resolves the references, a new one for the client if nil
+	Resolver *SecretResolver
+}
+
+// ExecWithSecrets starts cmd with the resolved secrets added to its environment and waits for it to exit.
+// cmd.Env defaults to the current environment. Secrets are only kept in memory and passed to the command,
+// nothing is written to disk. Returns the command's exit code, -1 if it was killed by a signal.
+// An error is only returned if resolving a secret or running the command failed, not for a non-zero exit code
+func ExecWithSecrets(ctx context.Context, c *api.Client, cmd *exec.Cmd, opts ExecOptions) (int, error) {
+	resolver := opts.Resolver
+	if resolver == nil {
+		resolver = NewSecretResolver(c)
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	secrets := []string{}
+	for _, name := range slices.Sorted(maps.Keys(opts.Env)) {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return 0, fmt.Errorf("%w: %q", ErrExecEnvName, name)
+		}
+		ref := opts.Env[name]
+		var value string
+		var err error
+		if strings.HasPrefix(ref, SecretRefScheme) {
+			value, err = resolver.Resolve(ctx, ref)
+		} else {
+			value, err = resolver.ResolveRef(ctx, SecretRef{ID: ref, Field: SecretRefFieldPassword})
+		}
+		if err != nil {
+			return 0, fmt.Errorf("resolving %v: %w", name, err)
+		}
+		env = append(env, name+"="+value)
+		secrets = append(secrets, value)
+	}
+	cmd.Env = env
+
+	if opts.Mask {
+		stdout, stderr := cmd.Stdout, cmd.Stderr
+		if stdout == nil {
+			stdout = os.Stdout
+		}
+		if stderr == nil {
+			stderr = os.Stderr
+		}
+		maskedStdout, maskedStderr := newMaskWriter(stdout, secrets), newMaskWriter(stderr, secrets)
+		cmd.Stdout, cmd.Stderr = maskedStdout, maskedStderr
+		// Whatever is held back to check for a secret is written once the command exited
+		defer maskedStdout.Flush()
+		defer maskedStderr.Flush()
+	}
+
+	signals := opts.Signals
+	if signals == nil {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+	}
+	forward := make(chan os.Signal, 1)
+	if len(signals) > 0 {
+		signal.Notify(forward, signals...)
+		defer signal.Stop(forward)
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		return 0, fmt.Errorf("starting command: %w", err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-forward:
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("running command: %w", err)
+	}
+	return 0, nil
+}
+
+// maskWriter replaces secrets in what is written through it. Secrets can be split across writes,
+// so the end of a write that could be the start of a secret is held back until the next write or Flush
+type maskWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets [][]byte
+	pending []byte
+}
+
+// newMaskWriter masks the non-empty secrets, longer ones first so a secret containing another is masked whole
+func newMaskWriter(w io.Writer, secrets []string) *maskWriter {
+	m := &maskWriter{w: w}
+	for _, s := range secrets {
+		if s != "" {
+			m.secrets = append(m.secrets, []byte(s))
+		}
+	}
+	slices.SortFunc(m.secrets, func(a, b []byte) int { return len(b) - len(a) })
+	return m
+}
+
+// Write masks and writes p, except for a possibly incomplete secret at its end
+func (m *maskWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = append(m.pending, p...)
+	out, rest := m.mask(m.pending, false)
+	m.pending = append(m.pending[:0], rest...)
+	if len(out) > 0 {
+		_, err := m.w.Write(out)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush masks and writes what was held back
+func (m *maskWriter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out, _ := m.mask(m.pending, true)
+	m.pending = m.pending[:0]
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := m.w.Write(out)
+	return err
+}
+
+// mask returns data with the secrets replaced and, unless final, the end of data that could be the start of a secret
+func (m *maskWriter) mask(data []byte, final bool) ([]byte, []byte) {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		masked := false
+		for _, secret := range m.secrets {
+			if bytes.HasPrefix(data[i:], secret) {
+				out = append(out, ExecMask...)
+				i += len(secret)
+				masked = true
+				break
+			}
+			// Checked before shorter secrets, which might only be the start of this one
+			if !final && len(data)-i < len(secret) && bytes.HasPrefix(secret, data[i:]) {
+				return out, data[i:]
+			}
+		}
+		if !masked {
+			out = append(out, data[i])
+			i++
+		}
+	}
+	return out, nil
+}
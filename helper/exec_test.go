@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestExecHelperProcess is the command the ExecWithSecrets tests run, it is not a test by itself
+func TestExecHelperProcess(t *testing.T) {
+	if os.Getenv("GO_EXEC_HELPER_PROCESS") != "1" {
+		t.SkipNow()
+	}
+	fmt.Fprintf(os.Stdout, "user=%v pass=%v\n", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"))
+	fmt.Fprintf(os.Stderr, "pass=%v\n", os.Getenv("DB_PASSWORD"))
+	os.Exit(3)
+}
+
+func execHelperCommand() *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExecHelperProcess$")
+	cmd.Env = append(os.Environ(), "GO_EXEC_HELPER_PROCESS=1")
+	return cmd
+}
+
+func TestExecWithSecrets(t *testing.T) {
+	ctx := context.TODO()
+	env := map[string]string{
+		"DB_USER":     "passbolt://Ops/db#username",
+		"DB_PASSWORD": "r1",
+	}
+
+	for _, mask := range []bool{false, true} {
+		var stdout, stderr bytes.Buffer
+		cmd := execHelperCommand()
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		code, err := ExecWithSecrets(ctx, nil, cmd, ExecOptions{Env: env, Mask: mask, Resolver: testSecretResolver()})
+		if err != nil {
+			t.Fatalf("ExecWithSecrets: %v", err)
+		}
+		if code != 3 {
+			t.Errorf("got exit code %v, want 3", code)
+		}
+		wantOut, wantErr := "user=admin pass=db-secret\n", "pass=db-secret\n"
+		if mask {
+			wantOut, wantErr = "user=**** pass=****\n", "pass=****\n"
+		}
+		if !strings.HasPrefix(stdout.String(), wantOut) || !strings.HasPrefix(stderr.String(), wantErr) {
+			t.Errorf("mask %v: got stdout %q and stderr %q", mask, stdout.String(), stderr.String())
+		}
+	}
+
+	_, err := ExecWithSecrets(ctx, nil, execHelperCommand(), ExecOptions{Env: map[string]string{"A=B": "r1"}, Resolver: testSecretResolver()})
+	if !errors.Is(err, ErrExecEnvName) {
+		t.Errorf("got %v, want ErrExecEnvName", err)
+	}
+	_, err = ExecWithSecrets(ctx, nil, execHelperCommand(), ExecOptions{Env: map[string]string{"A": "passbolt://Ops/missing"}, Resolver: testSecretResolver()})
+	if !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("got %v, want ErrResourceNotFound", err)
+	}
+}
+
+func TestMaskWriter(t *testing.T) {
+	var out bytes.Buffer
+	m := newMaskWriter(&out, []string{"secret", "secret-long", ""})
+	for _, chunk := range []string{"a sec", "ret b secret-l", "ong c secre", "tive d se"} {
+		n, err := m.Write([]byte(chunk))
+		if err != nil || n != len(chunk) {
+			t.Fatalf("Write: %v, %v", n, err)
+		}
+	}
+	if out.String() != "a **** b **** c ****ive d " {
+		t.Errorf("before Flush got %q", out.String())
+	}
+	err := m.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a **** b **** c ****ive d se" {
+		t.Errorf("got %q", out.String())
+	}
+}
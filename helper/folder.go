@@ -3,16 +3,34 @@ package helper
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/passbolt/go-passbolt/api"
 )
 
-// CreateFolder Creates a new Folder
+// CreateFolder Creates a new Folder using the server's preferred format (v4 or v5)
 func CreateFolder(ctx context.Context, c *api.Client, folderParentID, name string) (string, error) {
-	f, err := c.CreateFolder(ctx, api.Folder{
-		Name:           name,
+	folder := api.Folder{
 		FolderParentID: folderParentID,
-	})
+	}
+
+	settings := c.MetadataTypeSettings()
+	if settings.DefaultFolderType == api.PassboltAPIVersionTypeV5 {
+		if !settings.AllowCreationOfV5Folders {
+			return "", ErrV5FolderCreationDisabled
+		}
+		err := setFolderMetadata(ctx, c, &folder, api.FolderMetadata{Name: name}, true)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if !settings.AllowCreationOfV4Folders {
+			return "", ErrV4FolderCreationDisabled
+		}
+		folder.Name = name
+	}
+
+	f, err := c.CreateFolder(ctx, folder)
 	if err != nil {
 		return "", fmt.Errorf("creating Folder: %w", err)
 	}
@@ -25,16 +43,53 @@ func GetFolder(ctx context.Context, c *api.Client, folderID string) (string, str
 	if err != nil {
 		return "", "", fmt.Errorf("getting Folder: %w", err)
 	}
-	return f.FolderParentID, f.Name, nil
+	return GetFolderFromData(ctx, c, *f)
+}
+
+// GetFolderFromData returns the parent folder ID and name of an already fetched Folder,
+// decrypting the Metadata of v5 Folders
+func GetFolderFromData(ctx context.Context, c *api.Client, folder api.Folder) (string, string, error) {
+	// V5 detection uses metadata presence, same as for resources
+	if folder.Metadata == "" {
+		return folder.FolderParentID, folder.Name, nil
+	}
+
+	metadata, err := GetFolderMetadata(ctx, c, &folder)
+	if err != nil {
+		return "", "", fmt.Errorf("getting Folder Metadata: %w", err)
+	}
+	return folder.FolderParentID, metadata.Name, nil
 }
 
-// UpdateFolder Updates a Folder
+// UpdateFolder Updates a Folder, v5 Folders stay v5 and are re-encrypted with the matching metadata key
 func UpdateFolder(ctx context.Context, c *api.Client, folderID, name string) error {
-	_, err := c.UpdateFolder(ctx, folderID, api.Folder{Name: name})
+	folder, err := c.GetFolder(ctx, folderID, nil)
+	if err != nil {
+		return fmt.Errorf("getting Folder: %w", err)
+	}
+
+	newFolder := api.Folder{}
+	if folder.Metadata != "" {
+		metadata, err := GetFolderMetadata(ctx, c, folder)
+		if err != nil {
+			return fmt.Errorf("getting Folder Metadata: %w", err)
+		}
+		metadata.Name = name
+
+		personal := folder.MetadataKeyType != api.MetadataKeyTypeSharedKey
+		err = setFolderMetadata(ctx, c, &newFolder, *metadata, personal)
+		if err != nil {
+			return err
+		}
+	} else {
+		newFolder.Name = name
+	}
+
+	_, err = c.UpdateFolder(ctx, folderID, newFolder)
 	if err != nil {
 		return fmt.Errorf("updating Folder: %w", err)
 	}
-	return err
+	return nil
 }
 
 // DeleteFolder Deletes a Folder
@@ -54,3 +109,115 @@ func MoveFolder(ctx context.Context, c *api.Client, folderID, folderParentID str
 	}
 	return nil
 }
+
+// setFolderMetadata encrypts metadata with the personal or shared metadata key and sets it on folder
+func setFolderMetadata(ctx context.Context, c *api.Client, folder *api.Folder, metadata api.FolderMetadata, personal bool) error {
+	metadata.ObjectType = api.PassboltObjectTypeFolderMetadata
+	metadataKeyID, metadataKeyType, encMetadata, err := encryptForeignMetadata(ctx, c, metadata, personal)
+	if err != nil {
+		return fmt.Errorf("encrypting Folder Metadata: %w", err)
+	}
+
+	folder.MetadataKeyID = metadataKeyID
+	folder.MetadataKeyType = metadataKeyType
+	folder.Metadata = encMetadata
+	return nil
+}
+
+// EnsureFolderPath returns the ID of the Folder at the "/" separated path below folderParentID,
+// existing Folders are matched by name and missing ones are created
+func EnsureFolderPath(ctx context.Context, c *api.Client, folderParentID, path string) (string, error) {
+	tree, err := getFolderTree(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	return tree.ensure(ctx, c, folderParentID, splitFolderPath(path, "/"), false)
+}
+
+// folderTree indexes the Folders the user has access to by parent and name
+type folderTree struct {
+	children map[string]map[string]string
+	names    map[string]string
+	parents  map[string]string
+	// created are the paths of the Folders created by ensure, in creation order
+	created []string
+}
+
+func getFolderTree(ctx context.Context, c *api.Client) (*folderTree, error) {
+	folders, err := c.GetFolders(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Folders: %w", err)
+	}
+	tree := &folderTree{children: map[string]map[string]string{}, names: map[string]string{}, parents: map[string]string{}}
+	for _, folder := range folders {
+		parentID, name, err := GetFolderFromData(ctx, c, folder)
+		if err != nil {
+			return nil, fmt.Errorf("folder %v: %w", folder.ID, err)
+		}
+		tree.add(folder.ID, parentID, name)
+	}
+	return tree, nil
+}
+
+func (t *folderTree) add(id, parentID, name string) {
+	if t.children[parentID] == nil {
+		t.children[parentID] = map[string]string{}
+	}
+	// Keep the first of several Folders with the same name
+	if _, ok := t.children[parentID][name]; !ok {
+		t.children[parentID][name] = id
+	}
+	t.names[id] = name
+	t.parents[id] = parentID
+}
+
+// ensure resolves path below parentID, creating missing Folders. With dryRun nothing is created
+// and missing Folders get placeholder IDs, so they are only reported once
+func (t *folderTree) ensure(ctx context.Context, c *api.Client, parentID string, path []string, dryRun bool) (string, error) {
+	for _, name := range path {
+		if id, ok := t.children[parentID][name]; ok {
+			parentID = id
+			continue
+		}
+
+		id := "dry-run:" + parentID + "/" + name
+		if !dryRun {
+			var err error
+			id, err = CreateFolder(ctx, c, parentID, name)
+			if err != nil {
+				return "", fmt.Errorf("creating Folder %q: %w", name, err)
+			}
+		}
+		t.add(id, parentID, name)
+		t.created = append(t.created, strings.Join(t.path(id), "/"))
+		parentID = id
+	}
+	return parentID, nil
+}
+
+// path returns the names of folderID and its parents from the root, stopping at Folders the user can't see
+func (t *folderTree) path(folderID string) []string {
+	path := []string{}
+	seen := map[string]bool{}
+	for folderID != "" && !seen[folderID] {
+		name, ok := t.names[folderID]
+		if !ok {
+			break
+		}
+		seen[folderID] = true
+		path = append([]string{name}, path...)
+		folderID = t.parents[folderID]
+	}
+	return path
+}
+
+// splitFolderPath splits a folder path into its names, dropping empty names
+func splitFolderPath(path, separator string) []string {
+	names := []string{}
+	for _, name := range strings.Split(path, separator) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
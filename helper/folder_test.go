@@ -0,0 +1,97 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// TestFolderV5RoundTrip creates, reads and renames a folder on a server whose
+// default folder type is v5. The name must only ever travel encrypted and
+// must survive the re-encryption done by UpdateFolder.
+func TestFolderV5RoundTrip(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	id, err := CreateFolder(ctx, client, "", "v5 folder")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, id) }()
+
+	raw, err := client.GetFolder(ctx, id, nil)
+	if err != nil {
+		t.Fatalf("Getting raw Folder %v", err)
+	}
+	if client.MetadataTypeSettings().DefaultFolderType == api.PassboltAPIVersionTypeV5 {
+		if raw.Metadata == "" || raw.Name != "" {
+			t.Fatalf("expected encrypted v5 folder, got Name=%q Metadata empty=%v", raw.Name, raw.Metadata == "")
+		}
+	}
+
+	_, name, err := GetFolder(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Folder %v", err)
+	}
+	equal(t, "Name", name, "v5 folder")
+
+	err = UpdateFolder(ctx, client, id, "renamed")
+	if err != nil {
+		t.Fatalf("Updating Folder %v", err)
+	}
+
+	_, name, err = GetFolder(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Folder %v", err)
+	}
+	equal(t, "Name", name, "renamed")
+}
+
+// TestShareFolderV5 shares a folder created with the personal metadata key,
+// the recipient must be able to decrypt its name afterwards.
+func TestShareFolderV5(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	recipient, err := pb.CreateUser(ctx, "folder-recipient@passbolt.com", "Folder", "Recipient", "user", "folder-recipient@passbolt.com")
+	if err != nil {
+		t.Fatalf("Creating User %v", err)
+	}
+	recipientClient, err := api.NewClient(nil, "go-passbolt-helper-tests", pb.BaseURL, recipient.PrivateKey, recipient.Password)
+	if err != nil {
+		t.Fatalf("Creating Client %v", err)
+	}
+	if err := recipientClient.Login(ctx); err != nil {
+		t.Fatalf("Login %v", err)
+	}
+
+	id, err := CreateFolder(ctx, client, "", "shared v5 folder")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, id) }()
+	err = ShareFolderWithUsersAndGroups(ctx, client, id, []string{recipient.UserID}, nil, 1)
+	if err != nil {
+		t.Fatalf("Sharing Folder %v", err)
+	}
+
+	raw, err := client.GetFolder(ctx, id, nil)
+	if err != nil {
+		t.Fatalf("Getting raw Folder %v", err)
+	}
+	if raw.Metadata != "" && raw.MetadataKeyType != api.MetadataKeyTypeSharedKey {
+		t.Fatalf("got metadata key type %q, want %q", raw.MetadataKeyType, api.MetadataKeyTypeSharedKey)
+	}
+	_, name, err := GetFolder(ctx, recipientClient, id)
+	if err != nil {
+		t.Fatalf("Getting Folder as recipient %v", err)
+	}
+	equal(t, "Name", name, "shared v5 folder")
+}
@@ -202,3 +202,30 @@ func DeleteGroup(ctx context.Context, c *api.Client, groupID string) error {
 	}
 	return nil
 }
+
+// DeleteGroupWithTransfer Deletes a Group after handing over the Resources and Folders only the Group owns to a new owner.
+// newOwnerARO is "User" or "Group", secrets are encrypted for the new owner where it has no access yet
+func DeleteGroupWithTransfer(ctx context.Context, c *api.Client, groupID, newOwnerARO, newOwnerID string) error {
+	blockers, err := c.DeleteGroupDryrunBlockers(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("delete Group Dryrun: %w", err)
+	}
+	if newOwnerARO == "Group" && newOwnerID == groupID {
+		return fmt.Errorf("%w: cannot transfer to the deleted Group", ErrNoNewOwner)
+	}
+
+	plan, err := planOwnershipTransfer(blockers, newOwnerARO, newOwnerID, "")
+	if err != nil {
+		return err
+	}
+	_, _, _, err = plan.apply(ctx, c, blockers)
+	if err != nil {
+		return err
+	}
+
+	err = c.DeleteGroupWithTransfer(ctx, groupID, plan.transfer)
+	if err != nil {
+		return fmt.Errorf("deleting Group: %w", err)
+	}
+	return nil
+}
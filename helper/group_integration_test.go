@@ -0,0 +1,57 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestDeleteGroupWithTransfer(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	member, err := pb.CreateUser(ctx, "group-owner@passbolt.com", "Group", "Owner", "user", "group-owner@passbolt.com")
+	if err != nil {
+		t.Fatalf("Creating User %v", err)
+	}
+	groupID, err := CreateGroup(ctx, client, "transfer", []GroupMembershipOperation{
+		{UserID: client.GetUserID(), IsGroupManager: true},
+	})
+	if err != nil {
+		t.Fatalf("Creating Group %v", err)
+	}
+
+	// Owned only by the group, the new owner has no access yet so its secret must be encrypted for it
+	id, err := CreateResource(ctx, client, "", "group owned", "", "", "secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+	err = ShareResource(ctx, client, id, []ShareOperation{{Type: 15, ARO: "Group", AROID: groupID}})
+	if err != nil {
+		t.Fatalf("Sharing Resource %v", err)
+	}
+	err = ShareResource(ctx, client, id, []ShareOperation{{Type: 1, ARO: "User", AROID: client.GetUserID()}})
+	if err != nil {
+		t.Fatalf("Downgrading own Permission %v", err)
+	}
+
+	err = DeleteGroupWithTransfer(ctx, client, groupID, "User", member.UserID)
+	if err != nil {
+		t.Fatalf("Deleting Group %v", err)
+	}
+
+	permissions, err := client.GetResourcePermissions(ctx, id)
+	if err != nil {
+		t.Fatalf("Getting Permissions %v", err)
+	}
+	if !slices.ContainsFunc(permissions, func(p api.Permission) bool { return p.AROForeignKey == member.UserID && p.Type == 15 }) {
+		t.Errorf("new owner is not owner: %+v", permissions)
+	}
+}
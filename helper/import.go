@@ -0,0 +1,345 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// Credential is a resource independent of its resource type, as read from or written to other password managers
+type Credential struct {
+	// FolderPath are the names of the parent Folders from the root
+	FolderPath   []string
+	Name         string
+	Username     string
+	URIs         []string
+	Password     string
+	Description  string
+	TOTP         *api.SecretDataTOTP
+	CustomFields CustomFields
+}
+
+// ImportOptions configure ImportCredentials
+type ImportOptions struct {
+	// FolderParentID is the Folder the folder paths of the credentials start in
+	FolderParentID string
+	// DryRun only reports what would be created
+	DryRun bool
+	// CreateDuplicates creates credentials with the same name, username and URI as an existing resource instead of skipping them
+	CreateDuplicates bool
+	// SecretFieldsInDescription appends password type custom fields, like protected KeePass fields, to the description
+	// if the resource type can't store custom fields. They are skipped with a warning otherwise
+	SecretFieldsInDescription bool
+}
+
+// ImportAction is what happened to an imported credential
+type ImportAction string
+
+const (
+	ImportActionCreate    ImportAction = "create"
+	ImportActionDuplicate ImportAction = "skip-duplicate"
+	ImportActionFailed    ImportAction = "failed"
+)
+
+// ImportResult is the outcome for one credential
+type ImportResult struct {
+	// Index of the credential in the imported list
+	Index      int          `json:"index"`
+	Name       string       `json:"name"`
+	FolderPath string       `json:"folder_path,omitempty"`
+	Action     ImportAction `json:"action"`
+	// ResourceID is the created resource, or the existing one for duplicates. Empty for dry runs
+	ResourceID string `json:"resource_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ImportReport is the result of ImportCredentials
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// Folders are the paths of the Folders created
+	Folders []string       `json:"folders"`
+	Results []ImportResult `json:"results"`
+	// Warnings are items or parts of items the source format has but Passbolt can't represent
+	Warnings []ImportWarning `json:"warnings"`
+}
+
+// ImportWarning is an item or part of an item that was not imported
+type ImportWarning struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Count returns the number of results with the action
+func (r ImportReport) Count(action ImportAction) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// ImportCredentials creates the credentials as resources of the server's default format, creating their folder paths.
+// Credentials matching an existing resource by name, username and first URI are skipped unless opts.CreateDuplicates is set.
+// A credential that can't be created is reported as failed and does not stop the import
+func ImportCredentials(ctx context.Context, c *api.Client, credentials []Credential, opts ImportOptions) (*ImportReport, error) {
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+	tree, err := getFolderTree(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := getCredentialKeys(ctx, c, rTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	v5 := c.MetadataTypeSettings().DefaultResourceType == api.PassboltAPIVersionTypeV5
+	report := &ImportReport{DryRun: opts.DryRun, Folders: []string{}, Results: []ImportResult{}, Warnings: []ImportWarning{}}
+	for i, credential := range credentials {
+		result := ImportResult{Index: i, Name: credential.Name, FolderPath: strings.Join(credential.FolderPath, "/"), Action: ImportActionCreate}
+
+		key := credentialKey(credential.Name, credential.Username, credential.URIs)
+		if id, ok := existing[key]; ok && !opts.CreateDuplicates {
+			result.Action, result.ResourceID = ImportActionDuplicate, id
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		id, warnings, err := importCredential(ctx, c, tree, credential, v5, rTypes, opts)
+		for _, reason := range warnings {
+			report.Warnings = append(report.Warnings, ImportWarning{Name: credential.Name, Reason: reason})
+		}
+		if err != nil {
+			result.Action, result.Error = ImportActionFailed, err.Error()
+		} else {
+			result.ResourceID = id
+			existing[key] = id
+		}
+		report.Results = append(report.Results, result)
+	}
+	report.Folders = tree.created
+	return report, nil
+}
+
+func importCredential(ctx context.Context, c *api.Client, tree *folderTree, credential Credential, v5 bool, rTypes []api.ResourceType, opts ImportOptions) (string, []string, error) {
+	slug, metadataFields, secretFields, warnings, err := credentialFields(credential, v5, rTypes, opts.SecretFieldsInDescription)
+	if err != nil {
+		return "", nil, err
+	}
+	folderID, err := tree.ensure(ctx, c, opts.FolderParentID, credential.FolderPath, opts.DryRun)
+	if err != nil {
+		return "", warnings, err
+	}
+	if opts.DryRun {
+		return "", warnings, nil
+	}
+	id, err := CreateResourceGeneric(ctx, c, slug, folderID, metadataFields, secretFields)
+	return id, warnings, err
+}
+
+// credentialKey identifies a credential for duplicate detection
+func credentialKey(name, username string, uris []string) string {
+	uri := ""
+	if len(uris) > 0 {
+		uri = uris[0]
+	}
+	return strings.ToLower(name) + "\x00" + username + "\x00" + uri
+}
+
+// getCredentialKeys returns the keys of all resources the user has access to, only the metadata is decrypted
+func getCredentialKeys(ctx context.Context, c *api.Client, rTypes []api.ResourceType) (map[string]string, error) {
+	resources, err := c.GetResources(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	keys := map[string]string{}
+	for _, resource := range resources {
+		rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.ID == resource.ResourceTypeID }, ErrResourceTypeSlugNotFound, resource.ResourceTypeID)
+		if err != nil {
+			continue
+		}
+		metadataFields, _, err := resourceFieldMaps(c, resource, api.Secret{}, *rType, false)
+		if err != nil {
+			continue
+		}
+		keys[credentialKey(GetStringField(metadataFields, "name"), GetStringField(metadataFields, "username"), resourceURIs(metadataFields))] = resource.ID
+	}
+	return keys, nil
+}
+
+// credentialFields picks the resource type for a credential and maps it to metadata and secret fields.
+// Custom fields that the type can't store are appended to the description, password type ones only with secretsInDescription.
+// The returned warnings list every custom field that was not stored as one
+func credentialFields(credential Credential, v5 bool, rTypes []api.ResourceType, secretsInDescription bool) (string, map[string]any, map[string]any, []string, error) {
+	slug := "password-and-description"
+	switch {
+	case v5 && credential.TOTP != nil:
+		slug = "v5-default-with-totp"
+	case v5 && credential.Password == "" && len(credential.CustomFields) > 0:
+		slug = "v5-custom-fields"
+	case v5:
+		slug = "v5-default"
+	case credential.TOTP != nil:
+		slug = "password-description-totp"
+	}
+	rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.Slug == slug }, ErrResourceTypeSlugNotFound, slug)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	name := credential.Name
+	for _, fallback := range slices.Concat(credential.URIs, []string{credential.Username, "Untitled"}) {
+		if name == "" {
+			name = fallback
+		}
+	}
+	metadataFields := map[string]any{
+		"name": name,
+	}
+	secretFields := map[string]any{}
+	// v5-custom-fields is the only type without username and password
+	if slug != "v5-custom-fields" {
+		metadataFields["username"] = credential.Username
+		secretFields["password"] = credential.Password
+	}
+	uris := credential.URIs
+	if uris == nil {
+		uris = []string{}
+	}
+	if rType.IsV5() {
+		metadataFields["uris"] = uris
+	} else if len(uris) > 0 {
+		metadataFields["uri"] = uris[0]
+	}
+
+	description := credential.Description
+	warnings := []string{}
+	if credential.TOTP != nil {
+		secretFields["totp"] = map[string]any{
+			"algorithm":  credential.TOTP.Algorithm,
+			"secret_key": credential.TOTP.SecretKey,
+			"digits":     credential.TOTP.Digits,
+			"period":     credential.TOTP.Period,
+		}
+	}
+
+	if len(credential.CustomFields) > 0 && rType.HasSecretField("custom_fields") {
+		customFields := CustomFields{}
+		for _, f := range credential.CustomFields {
+			_, err := customFields.Add(f.Key, f.Type, f.Value)
+			if err != nil {
+				return "", nil, nil, nil, fmt.Errorf("custom field %q: %w", f.Key, err)
+			}
+		}
+		customMetadata, customSecret := customFields.fieldMaps()
+		metadataFields["custom_fields"] = customMetadata["custom_fields"]
+		secretFields["custom_fields"] = customSecret["custom_fields"]
+	} else if len(credential.CustomFields) > 0 {
+		lines := []string{}
+		for _, f := range credential.CustomFields {
+			if f.Type == CustomFieldTypePassword && !secretsInDescription {
+				warnings = append(warnings, fmt.Sprintf("secret custom field %q skipped, %q has no custom fields", f.Key, slug))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%v: %v", f.Key, f.Value))
+			warnings = append(warnings, fmt.Sprintf("custom field %q stored in the description, %q has no custom fields", f.Key, slug))
+		}
+		if len(lines) > 0 {
+			description = strings.TrimSpace(description + "\n\n" + strings.Join(lines, "\n"))
+		}
+	}
+
+	// Moved to the metadata by CreateResourceGeneric if the type stores it there
+	secretFields["description"] = description
+	return slug, metadataFields, secretFields, warnings, nil
+}
+
+// getCredentials decrypts every resource the user has access to, together with the paths of all Folders
+func getCredentials(ctx context.Context, c *api.Client) ([]Credential, [][]string, error) {
+	tree, err := getFolderTree(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		ContainSecret: true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+
+	credentials := []Credential{}
+	for _, resource := range resources {
+		rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.ID == resource.ResourceTypeID }, ErrResourceTypeSlugNotFound, resource.ResourceTypeID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resource %v: %w", resource.ID, err)
+		}
+		var secret api.Secret
+		if len(resource.Secrets) > 0 {
+			secret = resource.Secrets[0]
+		}
+		folderParentID, metadataFields, secretFields, err := GetResourceFieldMaps(c, resource, secret, *rType, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resource %v: %w", resource.ID, err)
+		}
+		credential, err := credentialFromFields(metadataFields, secretFields)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resource %v: %w", resource.ID, err)
+		}
+		credential.FolderPath = tree.path(folderParentID)
+		credentials = append(credentials, *credential)
+	}
+
+	folders := [][]string{}
+	for id := range tree.names {
+		folders = append(folders, tree.path(id))
+	}
+	return credentials, folders, nil
+}
+
+// credentialFromFields maps the decrypted fields of a resource to a Credential
+func credentialFromFields(metadataFields, secretFields map[string]any) (*Credential, error) {
+	credential := &Credential{
+		FolderPath:  []string{},
+		Name:        GetStringField(metadataFields, "name"),
+		Username:    GetStringField(metadataFields, "username"),
+		URIs:        resourceURIs(metadataFields),
+		Password:    GetStringField(secretFields, "password"),
+		Description: GetStringField(secretFields, "description"),
+	}
+	if credential.Description == "" {
+		credential.Description = GetStringField(metadataFields, "description")
+	}
+
+	if totp, ok := secretFields["totp"].(map[string]any); ok {
+		credential.TOTP = &api.SecretDataTOTP{
+			Algorithm: GetStringField(totp, "algorithm"),
+			SecretKey: GetStringField(totp, "secret_key"),
+		}
+		if digits, ok := totp["digits"].(float64); ok {
+			credential.TOTP.Digits = int(digits)
+		}
+		if period, ok := totp["period"].(float64); ok {
+			credential.TOTP.Period = int(period)
+		}
+	}
+
+	if _, ok := metadataFields["custom_fields"]; ok {
+		customFields, err := parseCustomFields(metadataFields, secretFields)
+		if err != nil {
+			return nil, err
+		}
+		credential.CustomFields = customFields
+	}
+	return credential, nil
+}
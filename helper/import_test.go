@@ -0,0 +1,173 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+var testImportResourceTypes = []api.ResourceType{
+	*resourceType("v5-default", []string{"name", "username", "uris"}, []string{"password", "description"}),
+	*resourceType("v5-default-with-totp", []string{"name", "username", "uris"}, []string{"password", "description", "totp", "custom_fields"}),
+	*resourceType("v5-custom-fields", []string{"name", "uris", "description", "custom_fields"}, []string{"custom_fields"}),
+	*resourceType("password-and-description", []string{"name", "username", "uri"}, []string{"password", "description"}),
+}
+
+func TestCredentialFields(t *testing.T) {
+	totp := &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DP", Digits: 6, Period: 30}
+	fields := CustomFields{
+		{Key: "Port", Type: CustomFieldTypeText, Value: "5432"},
+		{Key: "Key", Type: CustomFieldTypePassword, Value: "k3y"},
+	}
+
+	// v5 with TOTP, all URIs are kept
+	slug, metadataFields, secretFields, warnings, err := credentialFields(Credential{Name: "Mail", Username: "alice", Password: "pw", URIs: []string{"https://a", "https://b"}, TOTP: totp}, true, testImportResourceTypes, false)
+	if err != nil {
+		t.Fatalf("credentialFields: %v", err)
+	}
+	if slug != "v5-default-with-totp" {
+		t.Errorf("slug = %q", slug)
+	}
+	if !reflect.DeepEqual(metadataFields["uris"], []string{"https://a", "https://b"}) || metadataFields["username"] != "alice" {
+		t.Errorf("metadata = %v", metadataFields)
+	}
+	wantTOTP := map[string]any{"algorithm": "SHA1", "secret_key": "JBSWY3DP", "digits": 6, "period": 30}
+	if !reflect.DeepEqual(secretFields["totp"], wantTOTP) || secretFields["password"] != "pw" || len(warnings) != 0 {
+		t.Errorf("secret = %v, warnings %v", secretFields, warnings)
+	}
+
+	// v5-default has no custom fields, they are appended to the description, secret ones only if asked to
+	slug, _, secretFields, warnings, err = credentialFields(Credential{Name: "DB", Password: "pw", Description: "notes", CustomFields: fields}, true, testImportResourceTypes, false)
+	if err != nil {
+		t.Fatalf("credentialFields: %v", err)
+	}
+	if want := "notes\n\nPort: 5432"; slug != "v5-default" || secretFields["description"] != want {
+		t.Errorf("got %q with description %q, want %q", slug, secretFields["description"], want)
+	}
+	wantWarnings := []string{
+		`custom field "Port" stored in the description, "v5-default" has no custom fields`,
+		`secret custom field "Key" skipped, "v5-default" has no custom fields`,
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("got warnings %q", warnings)
+	}
+	_, _, secretFields, warnings, err = credentialFields(Credential{Name: "DB", Password: "pw", Description: "notes", CustomFields: fields}, true, testImportResourceTypes, true)
+	if err != nil {
+		t.Fatalf("credentialFields: %v", err)
+	}
+	if want := "notes\n\nPort: 5432\nKey: k3y"; secretFields["description"] != want || len(warnings) != 2 {
+		t.Errorf("got description %q, warnings %q", secretFields["description"], warnings)
+	}
+
+	// Custom fields are stored if the type supports them
+	_, metadataFields, secretFields, warnings, err = credentialFields(Credential{Name: "DB", Password: "pw", TOTP: totp, CustomFields: fields}, true, testImportResourceTypes, false)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("credentialFields: %v", err)
+	}
+	parsed, err := parseCustomFields(metadataFields, secretFields)
+	if err != nil {
+		t.Fatalf("parseCustomFields: %v", err)
+	}
+	if len(parsed) != 2 || parsed[1].Key != "Key" || parsed[1].Type != CustomFieldTypePassword || parsed[1].Value != "k3y" {
+		t.Errorf("custom fields = %+v", parsed)
+	}
+
+	// Without a password only the custom fields are stored
+	slug, metadataFields, secretFields, _, err = credentialFields(Credential{Name: "API", CustomFields: fields}, true, testImportResourceTypes, false)
+	if err != nil {
+		t.Fatalf("credentialFields: %v", err)
+	}
+	if _, ok := metadataFields["username"]; slug != "v5-custom-fields" || ok {
+		t.Errorf("got %q with metadata %v", slug, metadataFields)
+	}
+	if _, ok := secretFields["password"]; ok {
+		t.Errorf("secret = %v", secretFields)
+	}
+
+	// v4 keeps the first URI, names fall back to the URI
+	slug, metadataFields, _, _, err = credentialFields(Credential{URIs: []string{"https://a", "https://b"}}, false, testImportResourceTypes, false)
+	if err != nil {
+		t.Fatalf("credentialFields: %v", err)
+	}
+	if slug != "password-and-description" || metadataFields["uri"] != "https://a" || metadataFields["name"] != "https://a" {
+		t.Errorf("got %q with metadata %v", slug, metadataFields)
+	}
+
+	// The server has no v4 TOTP type
+	_, _, _, _, err = credentialFields(Credential{Name: "Mail", TOTP: totp}, false, testImportResourceTypes, false)
+	if !errors.Is(err, ErrResourceTypeSlugNotFound) {
+		t.Errorf("got %v, want ErrResourceTypeSlugNotFound", err)
+	}
+}
+
+func TestCredentialFromFields(t *testing.T) {
+	customFields := CustomFields{}
+	customFields.Add("Port", CustomFieldTypeNumber, float64(5432))
+	metadataFields, secretFields := customFields.fieldMaps()
+	metadataFields["name"] = "Postgres"
+	metadataFields["username"] = "postgres"
+	metadataFields["uris"] = []any{"https://db", "https://db2"}
+	metadataFields["description"] = "notes"
+	secretFields["password"] = "pw"
+	secretFields["totp"] = map[string]any{"algorithm": "SHA1", "secret_key": "JBSWY3DP", "digits": float64(6), "period": float64(30)}
+
+	credential, err := credentialFromFields(metadataFields, secretFields)
+	if err != nil {
+		t.Fatalf("credentialFromFields: %v", err)
+	}
+	want := &Credential{
+		FolderPath:   []string{},
+		Name:         "Postgres",
+		Username:     "postgres",
+		URIs:         []string{"https://db", "https://db2"},
+		Password:     "pw",
+		Description:  "notes",
+		TOTP:         &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DP", Digits: 6, Period: 30},
+		CustomFields: customFields,
+	}
+	if !reflect.DeepEqual(credential, want) {
+		t.Errorf("got %+v\nwant %+v", credential, want)
+	}
+}
+
+func TestFolderTree(t *testing.T) {
+	tree := &folderTree{children: map[string]map[string]string{}, names: map[string]string{}, parents: map[string]string{}}
+	tree.add("a", "", "A")
+	tree.add("b", "a", "B")
+	tree.add("hidden-child", "hidden", "H")
+
+	id, err := tree.ensure(context.TODO(), nil, "", []string{"A", "B"}, true)
+	if err != nil || id != "b" {
+		t.Fatalf("ensure existing = %q, %v", id, err)
+	}
+	if len(tree.created) != 0 {
+		t.Errorf("created = %v", tree.created)
+	}
+
+	// A dry run reports missing folders once
+	first, err := tree.ensure(context.TODO(), nil, "", []string{"A", "C", "D"}, true)
+	if err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	second, _ := tree.ensure(context.TODO(), nil, "", []string{"A", "C", "D"}, true)
+	if first != second || !reflect.DeepEqual(tree.created, []string{"A/C", "A/C/D"}) {
+		t.Errorf("got %q and %q, created %v", first, second, tree.created)
+	}
+
+	if path := tree.path("hidden-child"); !reflect.DeepEqual(path, []string{"H"}) {
+		t.Errorf("path = %v", path)
+	}
+	if names := splitFolderPath(`Work\ Servers \\`, `\`); !reflect.DeepEqual(names, []string{"Work", "Servers"}) {
+		t.Errorf("splitFolderPath = %v", names)
+	}
+}
+
+func TestImportReportCount(t *testing.T) {
+	report := ImportReport{Results: []ImportResult{{Action: ImportActionCreate}, {Action: ImportActionDuplicate}, {Action: ImportActionCreate}}}
+	if report.Count(ImportActionCreate) != 2 || report.Count(ImportActionFailed) != 0 {
+		t.Errorf("got %v created and %v failed", report.Count(ImportActionCreate), report.Count(ImportActionFailed))
+	}
+}
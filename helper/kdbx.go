@@ -0,0 +1,890 @@
+package helper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+)
+
+// KDBXCipher is the outer encryption of a KDBX file
+type KDBXCipher int
+
+const (
+	KDBXCipherAES256 KDBXCipher = iota
+	KDBXCipherChaCha20
+)
+
+// KeePass defaults for new databases
+const (
+	DefaultKDBXIterations  = 2
+	DefaultKDBXMemory      = 64 * 1024 * 1024
+	DefaultKDBXParallelism = 2
+)
+
+// Limits on the key derivation of a database, the parameters are read before the password is checked
+// so without them a crafted file could use up all memory or keep the CPU busy for hours.
+// The Argon2 work, memory times iterations, is limited as well since both maximums together would still take hours
+const (
+	kdbxMaxArgon2Memory     = 2 * 1024 * 1024 * 1024
+	kdbxMaxArgon2Iterations = 10000
+	kdbxMaxArgon2Work       = 64 * 1024 * 1024 * 1024
+	kdbxMaxAESRounds        = 1 << 30
+)
+
+// KDBXDatabase is the content of a KeePass database
+type KDBXDatabase struct {
+	Name string
+	Root KDBXGroup
+}
+
+// KDBXGroup is a KeePass group with its entries and sub groups
+type KDBXGroup struct {
+	Name    string
+	Notes   string
+	Groups  []KDBXGroup
+	Entries []KDBXEntry
+}
+
+// KDBXEntry is a KeePass entry
+type KDBXEntry struct {
+	Title    string
+	UserName string
+	Password string
+	URL      string
+	Notes    string
+	// Fields are all other string fields in file order, like "otp" or user defined ones
+	Fields []KDBXField
+}
+
+// KDBXField is a string field of an entry, Protected fields are encrypted in memory by KeePass
+type KDBXField struct {
+	Key       string
+	Value     string
+	Protected bool
+}
+
+// KDBXWriteOptions configure WriteKDBX. Zero values use the KeePass defaults
+type KDBXWriteOptions struct {
+	Cipher KDBXCipher
+	// Iterations, Memory in bytes and Parallelism of the Argon2d key derivation
+	Iterations  uint64
+	Memory      uint64
+	Parallelism uint32
+}
+
+// Field returns the value of a field, empty if there is none
+func (e KDBXEntry) Field(key string) string {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+var (
+	kdbxSignature1 = uint32(0x9AA2D903)
+	kdbxSignature2 = uint32(0xB54BFB67)
+	kdbxVersion4   = uint32(0x00040000)
+
+	kdbxCipherAES256   = []byte{0x31, 0xc1, 0xf2, 0xe6, 0xbf, 0x71, 0x43, 0x50, 0xbe, 0x58, 0x05, 0x21, 0x6a, 0xfc, 0x5a, 0xff}
+	kdbxCipherChaCha20 = []byte{0xd6, 0x03, 0x8a, 0x2b, 0x8b, 0x6f, 0x4c, 0xb5, 0xa5, 0x24, 0x33, 0x9a, 0x31, 0xdb, 0xb5, 0x9a}
+	kdbxKDFArgon2d     = []byte{0xef, 0x63, 0x6d, 0xdf, 0x8c, 0x29, 0x44, 0x4b, 0x91, 0xf7, 0xa9, 0xa4, 0x03, 0xe3, 0x0a, 0x0c}
+	kdbxKDFArgon2id    = []byte{0x9e, 0x29, 0x8b, 0x19, 0x56, 0xdb, 0x47, 0x73, 0xb2, 0x3d, 0xfc, 0x3e, 0xc6, 0xf0, 0xa1, 0xe6}
+	kdbxKDFAES         = []byte{0xc9, 0xd9, 0xf3, 0x9a, 0x62, 0x8a, 0x44, 0x60, 0xbf, 0x74, 0x0d, 0x08, 0xc1, 0x8a, 0x4f, 0xea}
+)
+
+// Outer header field IDs
+const (
+	kdbxHeaderEnd         = 0
+	kdbxHeaderCipherID    = 2
+	kdbxHeaderCompression = 3
+	kdbxHeaderMasterSeed  = 4
+	kdbxHeaderIV          = 7
+	kdbxHeaderKDF         = 11
+)
+
+// Inner header field IDs
+const (
+	kdbxInnerEnd       = 0
+	kdbxInnerStreamID  = 1
+	kdbxInnerStreamKey = 2
+
+	kdbxStreamNone     = 0
+	kdbxStreamChaCha20 = 3
+)
+
+const kdbxBlockSize = 1024 * 1024
+
+type kdbxHeader struct {
+	cipherID   []byte
+	compressed bool
+	masterSeed []byte
+	iv         []byte
+	kdf        map[string]any
+}
+
+// ReadKDBX decrypts a KDBX 4 database protected by password. Entries in the recycle bin are skipped
+func ReadKDBX(r io.Reader, password string) (*KDBXDatabase, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading KDBX: %w", err)
+	}
+	header, headerLen, err := parseKDBXHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerLen+64 {
+		return nil, fmt.Errorf("%w: truncated header", ErrKDBXFormat)
+	}
+	headerHash := sha256.Sum256(data[:headerLen])
+	if !hmac.Equal(headerHash[:], data[headerLen:headerLen+32]) {
+		return nil, fmt.Errorf("%w: header hash mismatch", ErrKDBXCorrupt)
+	}
+
+	transformed, err := kdbxTransformKey(header.kdf, kdbxCompositeKey(password))
+	if err != nil {
+		return nil, err
+	}
+	cipherKey, hmacKey := kdbxKeys(header.masterSeed, transformed)
+	if !hmac.Equal(kdbxHMAC(hmacKey, math.MaxUint64, data[:headerLen]), data[headerLen+32:headerLen+64]) {
+		return nil, ErrKDBXInvalidPassword
+	}
+
+	payload, err := readKDBXBlocks(data[headerLen+64:], hmacKey)
+	if err != nil {
+		return nil, err
+	}
+	payload, err = kdbxDecrypt(header, cipherKey, payload)
+	if err != nil {
+		return nil, err
+	}
+	if header.compressed {
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKDBXCorrupt, err)
+		}
+		payload, err = io.ReadAll(gz)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKDBXCorrupt, err)
+		}
+	}
+
+	stream, xmlData, err := parseKDBXInnerHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+	xmlData, err = kdbxTransformProtected(xmlData, stream, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseKDBXXML(xmlData)
+}
+
+// WriteKDBX encrypts db as a KDBX 4 database with password, using Argon2d and gzip compression
+func WriteKDBX(w io.Writer, db *KDBXDatabase, password string, opts KDBXWriteOptions) error {
+	if opts.Iterations == 0 {
+		opts.Iterations = DefaultKDBXIterations
+	}
+	if opts.Memory == 0 {
+		opts.Memory = DefaultKDBXMemory
+	}
+	if opts.Parallelism == 0 {
+		opts.Parallelism = DefaultKDBXParallelism
+	}
+
+	header := kdbxHeader{
+		compressed: true,
+		masterSeed: kdbxRandom(32),
+		kdf: map[string]any{
+			"$UUID": kdbxKDFArgon2d,
+			"S":     kdbxRandom(32),
+			"P":     opts.Parallelism,
+			"M":     opts.Memory,
+			"I":     opts.Iterations,
+			"V":     uint32(argon2Version),
+		},
+	}
+	switch opts.Cipher {
+	case KDBXCipherAES256:
+		header.cipherID, header.iv = kdbxCipherAES256, kdbxRandom(16)
+	case KDBXCipherChaCha20:
+		header.cipherID, header.iv = kdbxCipherChaCha20, kdbxRandom(12)
+	default:
+		return fmt.Errorf("%w: cipher %v", ErrKDBXUnsupported, opts.Cipher)
+	}
+	headerData := writeKDBXHeader(header)
+
+	transformed, err := kdbxTransformKey(header.kdf, kdbxCompositeKey(password))
+	if err != nil {
+		return err
+	}
+	cipherKey, hmacKey := kdbxKeys(header.masterSeed, transformed)
+
+	streamKey := kdbxRandom(64)
+	xmlData, err := writeKDBXXML(db)
+	if err != nil {
+		return err
+	}
+	xmlData, err = kdbxTransformProtected(xmlData, kdbxInnerStream(streamKey), false)
+	if err != nil {
+		return err
+	}
+
+	var payload bytes.Buffer
+	gz := gzip.NewWriter(&payload)
+	writeKDBXField(gz, kdbxInnerStreamID, binary.LittleEndian.AppendUint32(nil, kdbxStreamChaCha20))
+	writeKDBXField(gz, kdbxInnerStreamKey, streamKey)
+	writeKDBXField(gz, kdbxInnerEnd, nil)
+	gz.Write(xmlData)
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("compressing KDBX: %w", err)
+	}
+	encrypted, err := kdbxEncrypt(header, cipherKey, payload.Bytes())
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	out.Write(headerData)
+	headerHash := sha256.Sum256(headerData)
+	out.Write(headerHash[:])
+	out.Write(kdbxHMAC(hmacKey, math.MaxUint64, headerData))
+	writeKDBXBlocks(&out, encrypted, hmacKey)
+
+	_, err = w.Write(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("writing KDBX: %w", err)
+	}
+	return nil
+}
+
+// parseKDBXHeader parses the outer header and returns its length
+func parseKDBXHeader(data []byte) (*kdbxHeader, int, error) {
+	if len(data) < 12 || binary.LittleEndian.Uint32(data[0:]) != kdbxSignature1 || binary.LittleEndian.Uint32(data[4:]) != kdbxSignature2 {
+		return nil, 0, ErrKDBXFormat
+	}
+	if version := binary.LittleEndian.Uint32(data[8:]); version&0xFFFF0000 != kdbxVersion4 {
+		return nil, 0, fmt.Errorf("%w: version %#x, only KDBX 4 is supported", ErrKDBXUnsupported, version)
+	}
+
+	header := &kdbxHeader{}
+	pos := 12
+	for {
+		if len(data) < pos+5 {
+			return nil, 0, fmt.Errorf("%w: truncated header", ErrKDBXFormat)
+		}
+		id := data[pos]
+		size := int(binary.LittleEndian.Uint32(data[pos+1:]))
+		pos += 5
+		if size < 0 || len(data)-pos < size {
+			return nil, 0, fmt.Errorf("%w: truncated header field %v", ErrKDBXFormat, id)
+		}
+		value := data[pos : pos+size]
+		pos += size
+
+		switch id {
+		case kdbxHeaderEnd:
+			if header.cipherID == nil || header.masterSeed == nil || header.iv == nil || header.kdf == nil {
+				return nil, 0, fmt.Errorf("%w: missing header field", ErrKDBXFormat)
+			}
+			return header, pos, nil
+		case kdbxHeaderCipherID:
+			header.cipherID = value
+		case kdbxHeaderCompression:
+			if len(value) != 4 || binary.LittleEndian.Uint32(value) > 1 {
+				return nil, 0, fmt.Errorf("%w: compression", ErrKDBXUnsupported)
+			}
+			header.compressed = binary.LittleEndian.Uint32(value) == 1
+		case kdbxHeaderMasterSeed:
+			if len(value) != 32 {
+				return nil, 0, fmt.Errorf("%w: master seed length %v", ErrKDBXFormat, len(value))
+			}
+			header.masterSeed = value
+		case kdbxHeaderIV:
+			header.iv = value
+		case kdbxHeaderKDF:
+			kdf, err := parseKDBXVariantDictionary(value)
+			if err != nil {
+				return nil, 0, err
+			}
+			header.kdf = kdf
+		}
+	}
+}
+
+func writeKDBXHeader(header kdbxHeader) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []uint32{kdbxSignature1, kdbxSignature2, kdbxVersion4})
+	writeKDBXField(&buf, kdbxHeaderCipherID, header.cipherID)
+	compression := uint32(0)
+	if header.compressed {
+		compression = 1
+	}
+	writeKDBXField(&buf, kdbxHeaderCompression, binary.LittleEndian.AppendUint32(nil, compression))
+	writeKDBXField(&buf, kdbxHeaderMasterSeed, header.masterSeed)
+	writeKDBXField(&buf, kdbxHeaderIV, header.iv)
+	writeKDBXField(&buf, kdbxHeaderKDF, writeKDBXVariantDictionary(header.kdf))
+	writeKDBXField(&buf, kdbxHeaderEnd, []byte("\r\n\r\n"))
+	return buf.Bytes()
+}
+
+// writeKDBXField writes a header field, outer and inner header fields have the same layout
+func writeKDBXField(w io.Writer, id byte, value []byte) {
+	field := binary.LittleEndian.AppendUint32([]byte{id}, uint32(len(value)))
+	w.Write(append(field, value...))
+}
+
+// Variant dictionary value types
+const (
+	kdbxVariantUInt32    = 0x04
+	kdbxVariantUInt64    = 0x05
+	kdbxVariantBool      = 0x08
+	kdbxVariantInt32     = 0x0C
+	kdbxVariantInt64     = 0x0D
+	kdbxVariantString    = 0x18
+	kdbxVariantByteArray = 0x42
+)
+
+func parseKDBXVariantDictionary(data []byte) (map[string]any, error) {
+	if len(data) < 2 || data[1] != 0x01 {
+		return nil, fmt.Errorf("%w: variant dictionary version", ErrKDBXUnsupported)
+	}
+	dict := map[string]any{}
+	pos := 2
+	for {
+		if len(data) < pos+1 {
+			return nil, fmt.Errorf("%w: truncated variant dictionary", ErrKDBXFormat)
+		}
+		valueType := data[pos]
+		pos++
+		if valueType == 0 {
+			return dict, nil
+		}
+
+		readBytes := func() ([]byte, error) {
+			if len(data) < pos+4 {
+				return nil, fmt.Errorf("%w: truncated variant dictionary", ErrKDBXFormat)
+			}
+			size := int(int32(binary.LittleEndian.Uint32(data[pos:])))
+			pos += 4
+			if size < 0 || len(data)-pos < size {
+				return nil, fmt.Errorf("%w: truncated variant dictionary", ErrKDBXFormat)
+			}
+			b := data[pos : pos+size]
+			pos += size
+			return b, nil
+		}
+		key, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		value, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		wantLen := map[byte]int{kdbxVariantUInt32: 4, kdbxVariantInt32: 4, kdbxVariantUInt64: 8, kdbxVariantInt64: 8, kdbxVariantBool: 1}
+		if n, ok := wantLen[valueType]; ok && len(value) != n {
+			return nil, fmt.Errorf("%w: variant %q has length %v", ErrKDBXFormat, key, len(value))
+		}
+		switch valueType {
+		case kdbxVariantUInt32:
+			dict[string(key)] = binary.LittleEndian.Uint32(value)
+		case kdbxVariantUInt64:
+			dict[string(key)] = binary.LittleEndian.Uint64(value)
+		case kdbxVariantBool:
+			dict[string(key)] = value[0] != 0
+		case kdbxVariantInt32:
+			dict[string(key)] = int32(binary.LittleEndian.Uint32(value))
+		case kdbxVariantInt64:
+			dict[string(key)] = int64(binary.LittleEndian.Uint64(value))
+		case kdbxVariantString:
+			dict[string(key)] = string(value)
+		case kdbxVariantByteArray:
+			dict[string(key)] = value
+		default:
+			return nil, fmt.Errorf("%w: variant type %#x", ErrKDBXUnsupported, valueType)
+		}
+	}
+}
+
+func writeKDBXVariantDictionary(dict map[string]any) []byte {
+	keys := make([]string, 0, len(dict))
+	for k := range dict {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := []byte{0x00, 0x01}
+	for _, k := range keys {
+		var valueType byte
+		var value []byte
+		switch v := dict[k].(type) {
+		case uint32:
+			valueType, value = kdbxVariantUInt32, binary.LittleEndian.AppendUint32(nil, v)
+		case uint64:
+			valueType, value = kdbxVariantUInt64, binary.LittleEndian.AppendUint64(nil, v)
+		case bool:
+			valueType, value = kdbxVariantBool, []byte{0}
+			if v {
+				value[0] = 1
+			}
+		case int32:
+			valueType, value = kdbxVariantInt32, binary.LittleEndian.AppendUint32(nil, uint32(v))
+		case int64:
+			valueType, value = kdbxVariantInt64, binary.LittleEndian.AppendUint64(nil, uint64(v))
+		case string:
+			valueType, value = kdbxVariantString, []byte(v)
+		case []byte:
+			valueType, value = kdbxVariantByteArray, v
+		default:
+			continue
+		}
+		buf = append(buf, valueType)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(k)))
+		buf = append(buf, k...)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(value)))
+		buf = append(buf, value...)
+	}
+	return append(buf, 0x00)
+}
+
+// kdbxCompositeKey is the composite key of a password only database
+func kdbxCompositeKey(password string) []byte {
+	passwordHash := sha256.Sum256([]byte(password))
+	composite := sha256.Sum256(passwordHash[:])
+	return composite[:]
+}
+
+// kdbxTransformKey runs the key derivation function described by the KDF parameters
+func kdbxTransformKey(params map[string]any, composite []byte) ([]byte, error) {
+	id, _ := params["$UUID"].([]byte)
+	salt, _ := params["S"].([]byte)
+
+	switch {
+	case bytes.Equal(id, kdbxKDFArgon2d) || bytes.Equal(id, kdbxKDFArgon2id):
+		parallelism, _ := params["P"].(uint32)
+		memory, _ := params["M"].(uint64)
+		iterations, _ := params["I"].(uint64)
+		version, _ := params["V"].(uint32)
+		secret, _ := params["K"].([]byte)
+		data, _ := params["A"].([]byte)
+		if version != argon2Version {
+			return nil, fmt.Errorf("%w: Argon2 version %#x", ErrKDBXUnsupported, version)
+		}
+		if parallelism == 0 || parallelism > math.MaxUint8 || iterations == 0 || iterations > kdbxMaxArgon2Iterations || memory > kdbxMaxArgon2Memory || memory*iterations > kdbxMaxArgon2Work {
+			return nil, fmt.Errorf("%w: Argon2 parameters out of range", ErrKDBXFormat)
+		}
+		if bytes.Equal(id, kdbxKDFArgon2d) {
+			return argon2dKey(composite, salt, secret, data, uint32(iterations), uint32(memory/1024), parallelism, 32), nil
+		}
+		if len(secret) != 0 || len(data) != 0 {
+			return nil, fmt.Errorf("%w: Argon2id with secret or associated data", ErrKDBXUnsupported)
+		}
+		return argon2.IDKey(composite, salt, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+
+	case bytes.Equal(id, kdbxKDFAES):
+		rounds, _ := params["R"].(uint64)
+		if rounds > kdbxMaxAESRounds {
+			return nil, fmt.Errorf("%w: AES-KDF rounds out of range", ErrKDBXFormat)
+		}
+		block, err := aes.NewCipher(salt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: AES-KDF seed: %w", ErrKDBXFormat, err)
+		}
+		key := bytes.Clone(composite)
+		for range rounds {
+			block.Encrypt(key[:16], key[:16])
+			block.Encrypt(key[16:], key[16:])
+		}
+		transformed := sha256.Sum256(key)
+		return transformed[:], nil
+
+	default:
+		return nil, fmt.Errorf("%w: key derivation function %x", ErrKDBXUnsupported, id)
+	}
+}
+
+// kdbxKeys derives the payload encryption key and the base key for the block HMACs
+func kdbxKeys(masterSeed, transformed []byte) ([]byte, []byte) {
+	cipherKey := sha256.Sum256(append(bytes.Clone(masterSeed), transformed...))
+	hmacKey := sha512.Sum512(append(append(bytes.Clone(masterSeed), transformed...), 0x01))
+	return cipherKey[:], hmacKey[:]
+}
+
+// kdbxHMAC authenticates data as block index, the header uses the maximum index
+func kdbxHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	blockKey := sha512.Sum512(append(binary.LittleEndian.AppendUint64(nil, index), hmacKey...))
+	mac := hmac.New(sha256.New, blockKey[:])
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func readKDBXBlocks(data []byte, hmacKey []byte) ([]byte, error) {
+	var payload []byte
+	for index := uint64(0); ; index++ {
+		if len(data) < 36 {
+			return nil, fmt.Errorf("%w: truncated block %v", ErrKDBXCorrupt, index)
+		}
+		mac := data[:32]
+		size := int(int32(binary.LittleEndian.Uint32(data[32:])))
+		if size < 0 || len(data)-36 < size {
+			return nil, fmt.Errorf("%w: truncated block %v", ErrKDBXCorrupt, index)
+		}
+		if !hmac.Equal(mac, kdbxHMAC(hmacKey, index, data[32:36+size])) {
+			return nil, fmt.Errorf("%w: block %v HMAC mismatch", ErrKDBXCorrupt, index)
+		}
+		if size == 0 {
+			return payload, nil
+		}
+		payload = append(payload, data[36:36+size]...)
+		data = data[36+size:]
+	}
+}
+
+func writeKDBXBlocks(w io.Writer, payload []byte, hmacKey []byte) {
+	for index := uint64(0); ; index++ {
+		size := min(len(payload), kdbxBlockSize)
+		block := binary.LittleEndian.AppendUint32(nil, uint32(size))
+		block = append(block, payload[:size]...)
+		w.Write(kdbxHMAC(hmacKey, index, block))
+		w.Write(block)
+		if size == 0 {
+			return
+		}
+		payload = payload[size:]
+	}
+}
+
+func kdbxDecrypt(header *kdbxHeader, key, data []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(header.cipherID, kdbxCipherAES256):
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(header.iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("%w: invalid AES payload", ErrKDBXCorrupt)
+		}
+		plain := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, header.iv).CryptBlocks(plain, data)
+		padding := int(plain[len(plain)-1])
+		if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+			return nil, fmt.Errorf("%w: invalid padding", ErrKDBXCorrupt)
+		}
+		return plain[:len(plain)-padding], nil
+	case bytes.Equal(header.cipherID, kdbxCipherChaCha20):
+		stream, err := chacha20.NewUnauthenticatedCipher(key, header.iv)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKDBXFormat, err)
+		}
+		plain := make([]byte, len(data))
+		stream.XORKeyStream(plain, data)
+		return plain, nil
+	default:
+		return nil, fmt.Errorf("%w: cipher %x", ErrKDBXUnsupported, header.cipherID)
+	}
+}
+
+func kdbxEncrypt(header kdbxHeader, key, data []byte) ([]byte, error) {
+	if bytes.Equal(header.cipherID, kdbxCipherChaCha20) {
+		stream, err := chacha20.NewUnauthenticatedCipher(key, header.iv)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, len(data))
+		stream.XORKeyStream(out, data)
+		return out, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	padded := append(bytes.Clone(data), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, header.iv).CryptBlocks(padded, padded)
+	return padded, nil
+}
+
+// parseKDBXInnerHeader returns the stream for protected values and the XML document
+func parseKDBXInnerHeader(payload []byte) (cipher.Stream, []byte, error) {
+	streamID := uint32(kdbxStreamNone)
+	var streamKey []byte
+	pos := 0
+	for {
+		if len(payload) < pos+5 {
+			return nil, nil, fmt.Errorf("%w: truncated inner header", ErrKDBXCorrupt)
+		}
+		id := payload[pos]
+		size := int(binary.LittleEndian.Uint32(payload[pos+1:]))
+		pos += 5
+		if size < 0 || len(payload)-pos < size {
+			return nil, nil, fmt.Errorf("%w: truncated inner header", ErrKDBXCorrupt)
+		}
+		value := payload[pos : pos+size]
+		pos += size
+
+		switch id {
+		case kdbxInnerEnd:
+			switch streamID {
+			case kdbxStreamNone:
+				return nil, payload[pos:], nil
+			case kdbxStreamChaCha20:
+				return kdbxInnerStream(streamKey), payload[pos:], nil
+			default:
+				return nil, nil, fmt.Errorf("%w: inner random stream %v", ErrKDBXUnsupported, streamID)
+			}
+		case kdbxInnerStreamID:
+			if len(value) != 4 {
+				return nil, nil, fmt.Errorf("%w: inner random stream ID", ErrKDBXCorrupt)
+			}
+			streamID = binary.LittleEndian.Uint32(value)
+		case kdbxInnerStreamKey:
+			streamKey = value
+		}
+		// Binaries (attachments) are not imported
+	}
+}
+
+// kdbxInnerStream is the ChaCha20 stream protected values are XORed with
+func kdbxInnerStream(key []byte) cipher.Stream {
+	hash := sha512.Sum512(key)
+	stream, _ := chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+	return stream
+}
+
+// kdbxTransformProtected reveals or protects the values marked Protected="True" in document order.
+// A nil stream means the values are not protected
+func kdbxTransformProtected(xmlData []byte, stream cipher.Stream, reveal bool) ([]byte, error) {
+	if stream == nil {
+		return xmlData, nil
+	}
+	dec := xml.NewDecoder(bytes.NewReader(xmlData))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	protected := false
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKDBXCorrupt, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			protected = t.Name.Local == "Value" && kdbxAttr(t, "Protected") == "True"
+			text.Reset()
+		case xml.CharData:
+			if protected {
+				text.Write(t)
+				continue
+			}
+		case xml.EndElement:
+			if protected {
+				value, err := kdbxTransformValue(text.String(), stream, reveal)
+				if err != nil {
+					return nil, err
+				}
+				if err := enc.EncodeToken(xml.CharData(value)); err != nil {
+					return nil, err
+				}
+				protected = false
+			}
+		}
+		if err := enc.EncodeToken(xml.CopyToken(tok)); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKDBXCorrupt, err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func kdbxTransformValue(value string, stream cipher.Stream, reveal bool) (string, error) {
+	if !reveal {
+		out := []byte(value)
+		stream.XORKeyStream(out, out)
+		return base64.StdEncoding.EncodeToString(out), nil
+	}
+	out, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("%w: protected value: %w", ErrKDBXCorrupt, err)
+	}
+	stream.XORKeyStream(out, out)
+	return string(out), nil
+}
+
+func kdbxAttr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+type kdbxXMLFile struct {
+	XMLName xml.Name    `xml:"KeePassFile"`
+	Meta    kdbxXMLMeta `xml:"Meta"`
+	Root    kdbxXMLRoot `xml:"Root"`
+}
+
+type kdbxXMLMeta struct {
+	Generator         string `xml:"Generator"`
+	DatabaseName      string `xml:"DatabaseName"`
+	RecycleBinEnabled string `xml:"RecycleBinEnabled,omitempty"`
+	RecycleBinUUID    string `xml:"RecycleBinUUID,omitempty"`
+}
+
+type kdbxXMLRoot struct {
+	Group kdbxXMLGroup `xml:"Group"`
+}
+
+type kdbxXMLGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Notes   string         `xml:"Notes,omitempty"`
+	Entries []kdbxXMLEntry `xml:"Entry"`
+	Groups  []kdbxXMLGroup `xml:"Group"`
+}
+
+type kdbxXMLEntry struct {
+	UUID    string          `xml:"UUID"`
+	Strings []kdbxXMLString `xml:"String"`
+}
+
+type kdbxXMLString struct {
+	Key   string       `xml:"Key"`
+	Value kdbxXMLValue `xml:"Value"`
+}
+
+type kdbxXMLValue struct {
+	Protected string `xml:"Protected,attr,omitempty"`
+	Text      string `xml:",chardata"`
+}
+
+// kdbxStandardFields are the fields every KeePass entry has
+var kdbxStandardFields = []string{"Title", "UserName", "Password", "URL", "Notes"}
+
+func parseKDBXXML(xmlData []byte) (*KDBXDatabase, error) {
+	var file kdbxXMLFile
+	err := xml.Unmarshal(xmlData, &file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKDBXCorrupt, err)
+	}
+
+	recycleBin := ""
+	if !strings.EqualFold(file.Meta.RecycleBinEnabled, "False") {
+		recycleBin = file.Meta.RecycleBinUUID
+	}
+
+	var convert func(g kdbxXMLGroup) KDBXGroup
+	convert = func(g kdbxXMLGroup) KDBXGroup {
+		group := KDBXGroup{Name: g.Name, Notes: g.Notes, Groups: []KDBXGroup{}, Entries: []KDBXEntry{}}
+		for _, e := range g.Entries {
+			entry := KDBXEntry{Fields: []KDBXField{}}
+			for _, s := range e.Strings {
+				switch s.Key {
+				case "Title":
+					entry.Title = s.Value.Text
+				case "UserName":
+					entry.UserName = s.Value.Text
+				case "Password":
+					entry.Password = s.Value.Text
+				case "URL":
+					entry.URL = s.Value.Text
+				case "Notes":
+					entry.Notes = s.Value.Text
+				default:
+					entry.Fields = append(entry.Fields, KDBXField{Key: s.Key, Value: s.Value.Text, Protected: s.Value.Protected == "True"})
+				}
+			}
+			group.Entries = append(group.Entries, entry)
+		}
+		for _, sub := range g.Groups {
+			if recycleBin != "" && sub.UUID == recycleBin {
+				continue
+			}
+			group.Groups = append(group.Groups, convert(sub))
+		}
+		return group
+	}
+
+	return &KDBXDatabase{Name: file.Meta.DatabaseName, Root: convert(file.Root.Group)}, nil
+}
+
+func writeKDBXXML(db *KDBXDatabase) ([]byte, error) {
+	var convert func(g KDBXGroup) kdbxXMLGroup
+	convert = func(g KDBXGroup) kdbxXMLGroup {
+		group := kdbxXMLGroup{UUID: kdbxUUID(), Name: g.Name, Notes: g.Notes}
+		for _, e := range g.Entries {
+			entry := kdbxXMLEntry{UUID: kdbxUUID()}
+			for i, value := range []string{e.Title, e.UserName, e.Password, e.URL, e.Notes} {
+				s := kdbxXMLString{Key: kdbxStandardFields[i], Value: kdbxXMLValue{Text: value}}
+				if s.Key == "Password" {
+					s.Value.Protected = "True"
+				}
+				entry.Strings = append(entry.Strings, s)
+			}
+			for _, f := range e.Fields {
+				s := kdbxXMLString{Key: f.Key, Value: kdbxXMLValue{Text: f.Value}}
+				if f.Protected {
+					s.Value.Protected = "True"
+				}
+				entry.Strings = append(entry.Strings, s)
+			}
+			group.Entries = append(group.Entries, entry)
+		}
+		for _, sub := range g.Groups {
+			group.Groups = append(group.Groups, convert(sub))
+		}
+		return group
+	}
+
+	root := db.Root
+	if root.Name == "" {
+		root.Name = "Root"
+	}
+	file := kdbxXMLFile{
+		Meta: kdbxXMLMeta{Generator: "go-passbolt", DatabaseName: db.Name, RecycleBinEnabled: "False"},
+		Root: kdbxXMLRoot{Group: convert(root)},
+	}
+	data, err := xml.MarshalIndent(file, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling KDBX XML: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func kdbxUUID() string {
+	return base64.StdEncoding.EncodeToString(kdbxRandom(16))
+}
+
+func kdbxRandom(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
@@ -0,0 +1,191 @@
+package helper
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// golang.org/x/crypto/argon2 only exports Argon2i and Argon2id, KeePass defaults to Argon2d.
+// This is a plain, single threaded Argon2d (version 0x13) following RFC 9106
+
+const (
+	argon2Version    = 0x13
+	argon2dType      = 0
+	argon2SyncPoints = 4
+	argon2BlockWords = 128
+)
+
+type argon2Block [argon2BlockWords]uint64
+
+// argon2dKey derives a keyLen byte key. memory is in KiB
+func argon2dKey(password, salt, secret, data []byte, time, memory, threads, keyLen uint32) []byte {
+	h0 := argon2H0(password, salt, secret, data, time, memory, threads, keyLen)
+
+	memory = memory / (argon2SyncPoints * threads) * (argon2SyncPoints * threads)
+	if memory < 2*argon2SyncPoints*threads {
+		memory = 2 * argon2SyncPoints * threads
+	}
+	laneLength := memory / threads
+	segmentLength := laneLength / argon2SyncPoints
+
+	blocks := make([]argon2Block, memory)
+	var buf [1024]byte
+	for lane := uint32(0); lane < threads; lane++ {
+		for i := uint32(0); i < 2; i++ {
+			input := binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(h0[:], i), lane)
+			argon2Hash(buf[:], input)
+			for w := range blocks[lane*laneLength+i] {
+				blocks[lane*laneLength+i][w] = binary.LittleEndian.Uint64(buf[w*8:])
+			}
+		}
+	}
+
+	for pass := uint32(0); pass < time; pass++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			// Segments of the same slice never reference each other, so lanes can run one after another
+			for lane := uint32(0); lane < threads; lane++ {
+				start := uint32(0)
+				if pass == 0 && slice == 0 {
+					start = 2
+				}
+				for index := start; index < segmentLength; index++ {
+					offset := lane*laneLength + slice*segmentLength + index
+					prev := offset - 1
+					if slice == 0 && index == 0 {
+						prev = lane*laneLength + laneLength - 1
+					}
+					ref := argon2RefIndex(blocks[prev][0], pass, slice, lane, index, threads, laneLength, segmentLength)
+					argon2Compress(&blocks[offset], &blocks[prev], &blocks[ref])
+				}
+			}
+		}
+	}
+
+	final := blocks[laneLength-1]
+	for lane := uint32(1); lane < threads; lane++ {
+		for w, v := range blocks[lane*laneLength+laneLength-1] {
+			final[w] ^= v
+		}
+	}
+	for w, v := range final {
+		binary.LittleEndian.PutUint64(buf[w*8:], v)
+	}
+	key := make([]byte, keyLen)
+	argon2Hash(key, buf[:])
+	return key
+}
+
+// argon2H0 is the initial 64 byte hash of all parameters
+func argon2H0(password, salt, secret, data []byte, time, memory, threads, keyLen uint32) [blake2b.Size]byte {
+	var in []byte
+	for _, v := range []uint32{threads, keyLen, memory, time, argon2Version, argon2dType} {
+		in = binary.LittleEndian.AppendUint32(in, v)
+	}
+	for _, b := range [][]byte{password, salt, secret, data} {
+		in = binary.LittleEndian.AppendUint32(in, uint32(len(b)))
+		in = append(in, b...)
+	}
+	return blake2b.Sum512(in)
+}
+
+// argon2Hash is the variable length hash H' writing len(out) bytes
+func argon2Hash(out, in []byte) {
+	prefixed := binary.LittleEndian.AppendUint32(nil, uint32(len(out)))
+	prefixed = append(prefixed, in...)
+	if len(out) <= blake2b.Size {
+		h, _ := blake2b.New(len(out), nil)
+		h.Write(prefixed)
+		h.Sum(out[:0])
+		return
+	}
+
+	v := blake2b.Sum512(prefixed)
+	n := copy(out, v[:32])
+	for len(out)-n > blake2b.Size {
+		v = blake2b.Sum512(v[:])
+		n += copy(out[n:], v[:32])
+	}
+	h, _ := blake2b.New(len(out)-n, nil)
+	h.Write(v[:])
+	h.Sum(out[n:n])
+}
+
+// argon2RefIndex maps the pseudo random value of the previous block to the referenced block
+func argon2RefIndex(random uint64, pass, slice, lane, index, threads, laneLength, segmentLength uint32) uint32 {
+	refLane := uint32(random>>32) % threads
+	if pass == 0 && slice == 0 {
+		refLane = lane
+	}
+	sameLane := refLane == lane
+
+	// Number of blocks that may be referenced
+	var area uint32
+	if pass == 0 {
+		area = slice * segmentLength
+		if sameLane {
+			area += index - 1
+		} else if index == 0 {
+			area--
+		}
+	} else {
+		area = laneLength - segmentLength
+		if sameLane {
+			area += index - 1
+		} else if index == 0 {
+			area--
+		}
+	}
+
+	x := random & 0xFFFFFFFF
+	x = x * x >> 32
+	relative := uint64(area) - 1 - (uint64(area) * x >> 32)
+
+	startPosition := uint64(0)
+	if pass != 0 && slice != argon2SyncPoints-1 {
+		startPosition = uint64((slice + 1) * segmentLength)
+	}
+	return refLane*laneLength + uint32((startPosition+relative)%uint64(laneLength))
+}
+
+// argon2Compress XORs G(prev, ref) into out, which is zero in the first pass
+func argon2Compress(out, prev, ref *argon2Block) {
+	var r, z argon2Block
+	for i := range r {
+		r[i] = prev[i] ^ ref[i]
+	}
+	z = r
+	for i := 0; i < argon2BlockWords; i += 16 {
+		blamkaRound(&z, i, i+1, i+2, i+3, i+4, i+5, i+6, i+7, i+8, i+9, i+10, i+11, i+12, i+13, i+14, i+15)
+	}
+	for i := 0; i < 16; i += 2 {
+		blamkaRound(&z, i, i+1, i+16, i+17, i+32, i+33, i+48, i+49, i+64, i+65, i+80, i+81, i+96, i+97, i+112, i+113)
+	}
+	for i := range out {
+		out[i] ^= z[i] ^ r[i]
+	}
+}
+
+// blamkaRound is the BLAKE2b round with multiplications on the 16 words at the given indexes
+func blamkaRound(b *argon2Block, v ...int) {
+	g := func(a, bb, c, d int) {
+		mul := func(x, y uint64) uint64 { return 2 * uint64(uint32(x)) * uint64(uint32(y)) }
+		rotr := func(x uint64, n uint) uint64 { return x>>n | x<<(64-n) }
+		b[a] += b[bb] + mul(b[a], b[bb])
+		b[d] = rotr(b[d]^b[a], 32)
+		b[c] += b[d] + mul(b[c], b[d])
+		b[bb] = rotr(b[bb]^b[c], 24)
+		b[a] += b[bb] + mul(b[a], b[bb])
+		b[d] = rotr(b[d]^b[a], 16)
+		b[c] += b[d] + mul(b[c], b[d])
+		b[bb] = rotr(b[bb]^b[c], 63)
+	}
+	g(v[0], v[4], v[8], v[12])
+	g(v[1], v[5], v[9], v[13])
+	g(v[2], v[6], v[10], v[14])
+	g(v[3], v[7], v[11], v[15])
+	g(v[0], v[5], v[10], v[15])
+	g(v[1], v[6], v[11], v[12])
+	g(v[2], v[7], v[8], v[13])
+	g(v[3], v[4], v[9], v[14])
+}
@@ -0,0 +1,258 @@
+package helper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// The fixtures were generated outside this package (openssl for the ciphers,
+// x/crypto for Argon2id) from the same KeePass XML, protected with "passbolt"
+var kdbxFixtures = []string{
+	"testdata/aes-aeskdf.kdbx",
+	"testdata/chacha20-argon2id.kdbx",
+}
+
+// testKDBXDatabase is the content of the fixtures, the recycle bin and entry history are dropped
+var testKDBXDatabase = &KDBXDatabase{
+	Name: "Fixture",
+	Root: KDBXGroup{
+		Name: "Root",
+		Entries: []KDBXEntry{{
+			Title:    "Mail",
+			UserName: "alice@example.com",
+			Password: "m4il-p@ss<word>",
+			URL:      "https://mail.example.com",
+			Notes:    "line one & two",
+			Fields: []KDBXField{
+				{Key: "otp", Value: "otpauth://totp/Mail:alice?secret=JBSWY3DPEHPK3PXP&issuer=Mail&period=30&digits=6&algorithm=SHA1", Protected: true},
+				{Key: "KP2A_URL", Value: "https://webmail.example.com"},
+			},
+		}},
+		Groups: []KDBXGroup{{
+			Name: "Infrastructure",
+			Entries: []KDBXEntry{{
+				Title:    "Router",
+				UserName: "admin",
+				Password: "r0uter!",
+				Fields: []KDBXField{
+					{Key: "TimeOtp-Secret-Base32", Value: "GEZDGNBVGY3TQOJQ", Protected: true},
+					{Key: "TimeOtp-Length", Value: "8"},
+					{Key: "TimeOtp-Algorithm", Value: "HMAC-SHA-256"},
+				},
+			}},
+			Groups: []KDBXGroup{{
+				Name: "Databases",
+				Entries: []KDBXEntry{{
+					Title:    "Postgres",
+					UserName: "postgres",
+					Password: "pg-sécret",
+					Fields: []KDBXField{
+						{Key: "Port", Value: "5432"},
+						{Key: "Replication Key", Value: "repl-key-123", Protected: true},
+						{Key: "Empty Secret", Protected: true},
+					},
+				}},
+				Groups: []KDBXGroup{},
+			}},
+		}},
+	},
+}
+
+func readKDBXFixture(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	return data
+}
+
+func TestReadKDBX(t *testing.T) {
+	for _, path := range kdbxFixtures {
+		t.Run(path, func(t *testing.T) {
+			db, err := ReadKDBX(bytes.NewReader(readKDBXFixture(t, path)), "passbolt")
+			if err != nil {
+				t.Fatalf("ReadKDBX: %v", err)
+			}
+			if !reflect.DeepEqual(db, testKDBXDatabase) {
+				t.Errorf("got %+v\nwant %+v", db, testKDBXDatabase)
+			}
+		})
+	}
+}
+
+func TestReadKDBXErrors(t *testing.T) {
+	data := readKDBXFixture(t, kdbxFixtures[1])
+
+	_, err := ReadKDBX(bytes.NewReader(data), "wrong")
+	if !errors.Is(err, ErrKDBXInvalidPassword) {
+		t.Errorf("wrong password: got %v, want ErrKDBXInvalidPassword", err)
+	}
+
+	corrupt := bytes.Clone(data)
+	corrupt[len(corrupt)-100] ^= 0x01
+	_, err = ReadKDBX(bytes.NewReader(corrupt), "passbolt")
+	if !errors.Is(err, ErrKDBXCorrupt) {
+		t.Errorf("modified block: got %v, want ErrKDBXCorrupt", err)
+	}
+
+	_, err = ReadKDBX(strings.NewReader("not a database"), "passbolt")
+	if !errors.Is(err, ErrKDBXFormat) {
+		t.Errorf("no signature: got %v, want ErrKDBXFormat", err)
+	}
+
+	kdbx3 := bytes.Clone(data)
+	copy(kdbx3[8:12], []byte{0x01, 0x00, 0x03, 0x00})
+	_, err = ReadKDBX(bytes.NewReader(kdbx3), "passbolt")
+	if !errors.Is(err, ErrKDBXUnsupported) {
+		t.Errorf("KDBX 3.1: got %v, want ErrKDBXUnsupported", err)
+	}
+
+	// The key derivation runs before the password is checked, so its cost is limited
+	for name, kdf := range map[string]map[string]any{
+		"Argon2 memory":     {"$UUID": kdbxKDFArgon2d, "S": kdbxRandom(32), "P": uint32(1), "M": uint64(1 << 40), "I": uint64(1), "V": uint32(argon2Version)},
+		"Argon2 iterations": {"$UUID": kdbxKDFArgon2id, "S": kdbxRandom(32), "P": uint32(1), "M": uint64(1024 * 1024), "I": uint64(math.MaxUint32), "V": uint32(argon2Version)},
+		"Argon2 work":       {"$UUID": kdbxKDFArgon2id, "S": kdbxRandom(32), "P": uint32(1), "M": uint64(kdbxMaxArgon2Memory), "I": uint64(kdbxMaxArgon2Iterations), "V": uint32(argon2Version)},
+		"AES rounds":        {"$UUID": kdbxKDFAES, "S": kdbxRandom(32), "R": uint64(math.MaxUint64)},
+	} {
+		header := writeKDBXHeader(kdbxHeader{cipherID: kdbxCipherAES256, masterSeed: kdbxRandom(32), iv: kdbxRandom(16), kdf: kdf})
+		headerHash := sha256.Sum256(header)
+		crafted := slices.Concat(header, headerHash[:], make([]byte, 32))
+		_, err = ReadKDBX(bytes.NewReader(crafted), "passbolt")
+		if !errors.Is(err, ErrKDBXFormat) {
+			t.Errorf("%v: got %v, want ErrKDBXFormat", name, err)
+		}
+	}
+}
+
+func TestWriteKDBXRoundTrip(t *testing.T) {
+	for _, cipher := range []KDBXCipher{KDBXCipherAES256, KDBXCipherChaCha20} {
+		var buf bytes.Buffer
+		opts := KDBXWriteOptions{Cipher: cipher, Iterations: 1, Memory: 1024 * 1024, Parallelism: 2}
+		err := WriteKDBX(&buf, testKDBXDatabase, "s3cret", opts)
+		if err != nil {
+			t.Fatalf("WriteKDBX: %v", err)
+		}
+		if bytes.Contains(buf.Bytes(), []byte("m4il-p@ss")) {
+			t.Fatal("password written in cleartext")
+		}
+
+		db, err := ReadKDBX(&buf, "s3cret")
+		if err != nil {
+			t.Fatalf("ReadKDBX: %v", err)
+		}
+		if !reflect.DeepEqual(db, testKDBXDatabase) {
+			t.Errorf("cipher %v: got %+v\nwant %+v", cipher, db, testKDBXDatabase)
+		}
+	}
+}
+
+func TestKDBXCredentials(t *testing.T) {
+	credentials := kdbxCredentials(testKDBXDatabase.Root, []string{})
+	if len(credentials) != 3 {
+		t.Fatalf("got %v credentials, want 3", len(credentials))
+	}
+	mail, router, postgres := credentials[0], credentials[1], credentials[2]
+
+	// TOTP from the otp URI, additional URLs go into URIs
+	if !reflect.DeepEqual(mail.URIs, []string{"https://mail.example.com", "https://webmail.example.com"}) {
+		t.Errorf("URIs = %v", mail.URIs)
+	}
+	wantTOTP := api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DPEHPK3PXP", Digits: 6, Period: 30}
+	if mail.TOTP == nil || *mail.TOTP != wantTOTP {
+		t.Errorf("TOTP = %+v, want %+v", mail.TOTP, wantTOTP)
+	}
+	if len(mail.CustomFields) != 0 || len(mail.FolderPath) != 0 {
+		t.Errorf("got custom fields %+v and folder path %v", mail.CustomFields, mail.FolderPath)
+	}
+
+	// TimeOtp fields are consumed entirely
+	wantTOTP = api.SecretDataTOTP{Algorithm: "SHA256", SecretKey: "GEZDGNBVGY3TQOJQ", Digits: 8, Period: 30}
+	if router.TOTP == nil || *router.TOTP != wantTOTP || len(router.CustomFields) != 0 {
+		t.Errorf("got TOTP %+v and custom fields %+v", router.TOTP, router.CustomFields)
+	}
+	if !reflect.DeepEqual(router.FolderPath, []string{"Infrastructure"}) {
+		t.Errorf("folder path = %v", router.FolderPath)
+	}
+
+	// Protected fields become password fields
+	wantFields := CustomFields{
+		{Key: "Port", Type: CustomFieldTypeText, Value: "5432"},
+		{Key: "Replication Key", Type: CustomFieldTypePassword, Value: "repl-key-123"},
+		{Key: "Empty Secret", Type: CustomFieldTypePassword, Value: ""},
+	}
+	if !reflect.DeepEqual(postgres.CustomFields, wantFields) {
+		t.Errorf("custom fields = %+v", postgres.CustomFields)
+	}
+	if !reflect.DeepEqual(postgres.FolderPath, []string{"Infrastructure", "Databases"}) {
+		t.Errorf("folder path = %v", postgres.FolderPath)
+	}
+}
+
+func TestKDBXEntry(t *testing.T) {
+	credential := Credential{
+		Name:        "Postgres",
+		Username:    "postgres",
+		Password:    "pw",
+		URIs:        []string{"https://db", "https://db2", "https://db3"},
+		Description: "notes",
+		TOTP:        &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DP", Digits: 6, Period: 30},
+		CustomFields: CustomFields{
+			{Key: "Port", Type: CustomFieldTypeNumber, Value: float64(5432)},
+			{Key: "Key", Type: CustomFieldTypePassword, Value: "k3y"},
+		},
+	}
+	entry := kdbxEntry(credential)
+	want := KDBXEntry{
+		Title:    "Postgres",
+		UserName: "postgres",
+		Password: "pw",
+		URL:      "https://db",
+		Notes:    "notes",
+		Fields: []KDBXField{
+			{Key: "KP2A_URL", Value: "https://db2"},
+			{Key: "KP2A_URL_1", Value: "https://db3"},
+			{Key: "otp", Value: "otpauth://totp/Postgres?algorithm=SHA1&digits=6&period=30&secret=JBSWY3DP", Protected: true},
+			{Key: "Port", Value: "5432"},
+			{Key: "Key", Value: "k3y", Protected: true},
+		},
+	}
+	if !reflect.DeepEqual(entry, want) {
+		t.Errorf("got %+v\nwant %+v", entry, want)
+	}
+
+	// The exported entry imports to the same credential, custom fields become text or password fields
+	imported := kdbxCredential(entry, nil)
+	if !reflect.DeepEqual(imported.URIs, credential.URIs) || *imported.TOTP != *credential.TOTP || len(imported.CustomFields) != 2 {
+		t.Errorf("got %+v", imported)
+	}
+}
+
+func TestKDBXGroupTree(t *testing.T) {
+	credentials := []Credential{
+		{Name: "z", FolderPath: []string{}},
+		{Name: "y"},
+		{Name: "x", FolderPath: []string{"A", "B"}},
+	}
+	folders := [][]string{{"C"}, {"A"}, {"A", "B"}}
+
+	root := kdbxGroupTree("Passbolt", credentials, folders)
+	if len(root.Groups) != 2 || root.Groups[0].Name != "A" || root.Groups[1].Name != "C" {
+		t.Fatalf("root groups = %+v", root.Groups)
+	}
+	if len(root.Entries) != 2 || root.Entries[0].Title != "y" {
+		t.Errorf("entries not sorted: %+v", root.Entries)
+	}
+	if b := root.Groups[0].Groups; len(b) != 1 || b[0].Name != "B" || b[0].Entries[0].Title != "x" {
+		t.Errorf("A groups = %+v", b)
+	}
+}
@@ -0,0 +1,198 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// kdbxURLPrefix marks additional URLs of an entry, as used by KeePassXC and Keepass2Android
+const kdbxURLPrefix = "KP2A_URL"
+
+// ImportKDBX reads a KDBX 4 database and imports its entries with ImportCredentials, groups become Folders.
+// TOTPs are read from the "otp" URI or the TimeOtp fields, other string fields become custom fields
+func ImportKDBX(ctx context.Context, c *api.Client, r io.Reader, password string, opts ImportOptions) (*ImportReport, error) {
+	db, err := ReadKDBX(r, password)
+	if err != nil {
+		return nil, err
+	}
+	return ImportCredentials(ctx, c, kdbxCredentials(db.Root, []string{}), opts)
+}
+
+// kdbxCredentials flattens a group and its sub groups, the name of the root group is not part of the folder path
+func kdbxCredentials(group KDBXGroup, path []string) []Credential {
+	credentials := []Credential{}
+	for _, entry := range group.Entries {
+		credentials = append(credentials, kdbxCredential(entry, path))
+	}
+	for _, sub := range group.Groups {
+		credentials = append(credentials, kdbxCredentials(sub, slices.Concat(path, []string{sub.Name}))...)
+	}
+	return credentials
+}
+
+func kdbxCredential(entry KDBXEntry, path []string) Credential {
+	totp, rest := kdbxEntryTOTP(entry.Fields)
+	credential := Credential{
+		FolderPath:   path,
+		Name:         entry.Title,
+		Username:     entry.UserName,
+		URIs:         []string{},
+		Password:     entry.Password,
+		Description:  entry.Notes,
+		TOTP:         totp,
+		CustomFields: CustomFields{},
+	}
+	if entry.URL != "" {
+		credential.URIs = append(credential.URIs, entry.URL)
+	}
+	for _, f := range rest {
+		if strings.HasPrefix(f.Key, kdbxURLPrefix) {
+			if f.Value != "" {
+				credential.URIs = append(credential.URIs, f.Value)
+			}
+			continue
+		}
+		fieldType := CustomFieldTypeText
+		if f.Protected {
+			fieldType = CustomFieldTypePassword
+		}
+		credential.CustomFields = append(credential.CustomFields, CustomField{Key: f.Key, Type: fieldType, Value: f.Value})
+	}
+	return credential
+}
+
+// kdbxEntryTOTP returns the TOTP of an entry, from a KeePassXC "otp" URI or the KeePass TimeOtp fields,
+// together with the fields that are not part of it. Fields that can't be parsed are kept
+func kdbxEntryTOTP(fields []KDBXField) (*api.SecretDataTOTP, []KDBXField) {
+	get := func(key string) string {
+		for _, f := range fields {
+			if f.Key == key {
+				return f.Value
+			}
+		}
+		return ""
+	}
+
+	if totp, err := ParseOTPAuthURI(get("otp")); err == nil {
+		return totp, kdbxWithoutFields(fields, func(key string) bool { return key == "otp" })
+	}
+
+	secret := get("TimeOtp-Secret-Base32")
+	if secret == "" {
+		return nil, fields
+	}
+	totp := &api.SecretDataTOTP{
+		Algorithm: "SHA1",
+		SecretKey: strings.ToUpper(strings.ReplaceAll(secret, " ", "")),
+		Digits:    codeLength,
+		Period:    timeSplitInSeconds,
+	}
+	if v, err := strconv.Atoi(get("TimeOtp-Length")); err == nil && v >= 6 && v <= 8 {
+		totp.Digits = v
+	}
+	if v, err := strconv.Atoi(get("TimeOtp-Period")); err == nil && v > 0 {
+		totp.Period = v
+	}
+	switch get("TimeOtp-Algorithm") {
+	case "HMAC-SHA-256":
+		totp.Algorithm = "SHA256"
+	case "HMAC-SHA-512":
+		totp.Algorithm = "SHA512"
+	}
+	return totp, kdbxWithoutFields(fields, func(key string) bool { return strings.HasPrefix(key, "TimeOtp-") })
+}
+
+func kdbxWithoutFields(fields []KDBXField, drop func(key string) bool) []KDBXField {
+	rest := []KDBXField{}
+	for _, f := range fields {
+		if !drop(f.Key) {
+			rest = append(rest, f)
+		}
+	}
+	return rest
+}
+
+// ExportKDBX writes every resource the user can decrypt to a KDBX 4 database protected by password.
+// Folders become groups, TOTPs are stored as "otp" URI and custom fields as string fields
+func ExportKDBX(ctx context.Context, c *api.Client, w io.Writer, password string, opts KDBXWriteOptions) error {
+	credentials, folders, err := getCredentials(ctx, c)
+	if err != nil {
+		return err
+	}
+	db := &KDBXDatabase{Name: "Passbolt", Root: kdbxGroupTree("Passbolt", credentials, folders)}
+	return WriteKDBX(w, db, password, opts)
+}
+
+// kdbxGroupTree builds the group tree of the credentials and folder paths, sorted by name
+func kdbxGroupTree(name string, credentials []Credential, folders [][]string) KDBXGroup {
+	root := &KDBXGroup{Name: name, Groups: []KDBXGroup{}, Entries: []KDBXEntry{}}
+	group := func(path []string) *KDBXGroup {
+		g := root
+		for _, name := range path {
+			i := slices.IndexFunc(g.Groups, func(sub KDBXGroup) bool { return sub.Name == name })
+			if i == -1 {
+				g.Groups = append(g.Groups, KDBXGroup{Name: name, Groups: []KDBXGroup{}, Entries: []KDBXEntry{}})
+				i = len(g.Groups) - 1
+			}
+			g = &g.Groups[i]
+		}
+		return g
+	}
+	for _, path := range folders {
+		group(path)
+	}
+	for _, credential := range credentials {
+		g := group(credential.FolderPath)
+		g.Entries = append(g.Entries, kdbxEntry(credential))
+	}
+
+	var sortGroup func(g *KDBXGroup)
+	sortGroup = func(g *KDBXGroup) {
+		sort.SliceStable(g.Entries, func(i, j int) bool { return g.Entries[i].Title < g.Entries[j].Title })
+		sort.SliceStable(g.Groups, func(i, j int) bool { return g.Groups[i].Name < g.Groups[j].Name })
+		for i := range g.Groups {
+			sortGroup(&g.Groups[i])
+		}
+	}
+	sortGroup(root)
+	return *root
+}
+
+// kdbxEntry maps a credential to a KeePass entry
+func kdbxEntry(credential Credential) KDBXEntry {
+	entry := KDBXEntry{
+		Title:    credential.Name,
+		UserName: credential.Username,
+		Password: credential.Password,
+		Notes:    credential.Description,
+		Fields:   []KDBXField{},
+	}
+	for i, uri := range credential.URIs {
+		switch i {
+		case 0:
+			entry.URL = uri
+		case 1:
+			entry.Fields = append(entry.Fields, KDBXField{Key: kdbxURLPrefix, Value: uri})
+		default:
+			entry.Fields = append(entry.Fields, KDBXField{Key: kdbxURLPrefix + "_" + strconv.Itoa(i-1), Value: uri})
+		}
+	}
+	if credential.TOTP != nil {
+		entry.Fields = append(entry.Fields, KDBXField{Key: "otp", Value: OTPAuthURI(*credential.TOTP, credential.Name), Protected: true})
+	}
+	for _, f := range credential.CustomFields {
+		entry.Fields = append(entry.Fields, KDBXField{
+			Key:       f.Key,
+			Value:     fmt.Sprint(f.Value),
+			Protected: f.Type == CustomFieldTypePassword,
+		})
+	}
+	return entry
+}
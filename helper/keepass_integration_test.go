@@ -0,0 +1,93 @@
+//go:build integration
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// cleanupImport deletes the Resources and Folders an import created
+func cleanupImport(ctx context.Context, report *ImportReport) {
+	for _, result := range report.Results {
+		if result.Action == ImportActionCreate && result.ResourceID != "" {
+			_ = DeleteResource(ctx, client, result.ResourceID)
+		}
+	}
+	tree, err := getFolderTree(ctx, client)
+	if err != nil {
+		return
+	}
+	for _, path := range slices.Backward(report.Folders) {
+		for id := range tree.names {
+			if strings.Join(tree.path(id), "/") == path {
+				_ = DeleteFolder(ctx, client, id)
+			}
+		}
+	}
+}
+
+func TestImportExportKDBX(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	folderID, err := CreateFolder(ctx, client, "", "kdbx import")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+
+	data, err := os.ReadFile("testdata/chacha20-argon2id.kdbx")
+	if err != nil {
+		t.Fatalf("Reading fixture %v", err)
+	}
+	report, err := ImportKDBX(ctx, client, bytes.NewReader(data), "passbolt", ImportOptions{FolderParentID: folderID})
+	if report != nil {
+		defer cleanupImport(ctx, report)
+	}
+	if err != nil {
+		t.Fatalf("Importing KDBX %v", err)
+	}
+	if report.Count(ImportActionCreate) != 3 || len(report.Folders) != 2 {
+		t.Fatalf("got %+v, want 3 Resources and 2 Folders", report)
+	}
+
+	folderParentID, name, username, _, password, _, err := GetResource(ctx, client, report.Results[0].ResourceID)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if folderParentID != folderID || name != "Mail" || username != "alice@example.com" || password != "m4il-p@ss<word>" {
+		t.Fatalf("got %q %q %q %q", folderParentID, name, username, password)
+	}
+
+	var buf bytes.Buffer
+	err = ExportKDBX(ctx, client, &buf, "export", KDBXWriteOptions{Iterations: 1, Memory: 1024 * 1024})
+	if err != nil {
+		t.Fatalf("Exporting KDBX %v", err)
+	}
+	db, err := ReadKDBX(&buf, "export")
+	if err != nil {
+		t.Fatalf("Reading export %v", err)
+	}
+
+	i := slices.IndexFunc(db.Root.Groups, func(g KDBXGroup) bool { return g.Name == "kdbx import" })
+	if i == -1 {
+		t.Fatalf("import folder missing in export")
+	}
+	imported := db.Root.Groups[i]
+	if len(imported.Entries) != 1 || imported.Entries[0].Password != "m4il-p@ss<word>" {
+		t.Fatalf("got entries %+v", imported.Entries)
+	}
+	if imported.Entries[0].Field("otp") == "" {
+		t.Fatalf("TOTP missing in export")
+	}
+	if len(imported.Groups) != 1 || imported.Groups[0].Name != "Infrastructure" || len(imported.Groups[0].Groups) != 1 {
+		t.Fatalf("got groups %+v", imported.Groups)
+	}
+}
@@ -0,0 +1,345 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// ShareLease is a temporary share of a Resource that is revoked once it expires
+type ShareLease struct {
+	ID         string `json:"id"`
+	ResourceID string `json:"resource_id"`
+	// ARO is what Type the Resource is Shared With (User, Group)
+	ARO   string `json:"aro"`
+	AROID string `json:"aro_id"`
+	// Type of Permission: 1 = Read, 7 = can Update, 15 = Owner
+	Type    int       `json:"type"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+	// RotationPending is set once the Permission is revoked and the Resource still has to be rotated
+	RotationPending bool `json:"rotation_pending,omitempty"`
+}
+
+// LeaseStore persists ShareLeases
+type LeaseStore interface {
+	Put(ctx context.Context, lease ShareLease) error
+	List(ctx context.Context) ([]ShareLease, error)
+	// Delete removes a lease, deleting a lease that does not exist is not an error
+	Delete(ctx context.Context, leaseID string) error
+}
+
+// MemoryLeaseStore keeps leases in memory, they are lost when the process exits
+type MemoryLeaseStore struct {
+	mu     sync.Mutex
+	leases map[string]ShareLease
+}
+
+// NewMemoryLeaseStore returns an empty MemoryLeaseStore
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{leases: map[string]ShareLease{}}
+}
+
+// Put stores or replaces a lease
+func (s *MemoryLeaseStore) Put(ctx context.Context, lease ShareLease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leases == nil {
+		s.leases = map[string]ShareLease{}
+	}
+	s.leases[lease.ID] = lease
+	return nil
+}
+
+// List returns all leases, soonest expiry first
+func (s *MemoryLeaseStore) List(ctx context.Context) ([]ShareLease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases := []ShareLease{}
+	for _, lease := range s.leases {
+		leases = append(leases, lease)
+	}
+	sortLeases(leases)
+	return leases, nil
+}
+
+// Delete removes a lease
+func (s *MemoryLeaseStore) Delete(ctx context.Context, leaseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.leases, leaseID)
+	return nil
+}
+
+// FileLeaseStore keeps leases in a JSON file. Writes replace the file atomically
+type FileLeaseStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileLeaseStore returns a FileLeaseStore for path, the file is created on the first Put
+func NewFileLeaseStore(path string) *FileLeaseStore {
+	return &FileLeaseStore{path: path}
+}
+
+// Put stores or replaces a lease
+func (s *FileLeaseStore) Put(ctx context.Context, lease ShareLease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases, err := s.read()
+	if err != nil {
+		return err
+	}
+	leases = slices.DeleteFunc(leases, func(l ShareLease) bool { return l.ID == lease.ID })
+	return s.write(append(leases, lease))
+}
+
+// List returns all leases, soonest expiry first
+func (s *FileLeaseStore) List(ctx context.Context) ([]ShareLease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	sortLeases(leases)
+	return leases, nil
+}
+
+// Delete removes a lease
+func (s *FileLeaseStore) Delete(ctx context.Context, leaseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases, err := s.read()
+	if err != nil {
+		return err
+	}
+	remaining := slices.DeleteFunc(slices.Clone(leases), func(l ShareLease) bool { return l.ID == leaseID })
+	if len(remaining) == len(leases) {
+		return nil
+	}
+	return s.write(remaining)
+}
+
+func (s *FileLeaseStore) read() ([]ShareLease, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []ShareLease{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lease file: %w", err)
+	}
+	leases := []ShareLease{}
+	err = json.Unmarshal(data, &leases)
+	if err != nil {
+		return nil, fmt.Errorf("parsing lease file: %w", err)
+	}
+	return leases, nil
+}
+
+func (s *FileLeaseStore) write(leases []ShareLease) error {
+	sortLeases(leases)
+	data, err := json.MarshalIndent(leases, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling leases: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating lease file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing lease file: %w", err)
+	}
+	err = os.Rename(tmp.Name(), s.path)
+	if err != nil {
+		return fmt.Errorf("replacing lease file: %w", err)
+	}
+	return nil
+}
+
+// ShareResourceTemporarily Shares a Resource like ShareResource and records a lease so LeaseReconciler revokes the share after duration.
+// The User or Group must not already have a Permission, as revoking the lease would remove it
+func ShareResourceTemporarily(ctx context.Context, c *api.Client, store LeaseStore, resourceID, aro, aroID string, permissionType int, duration time.Duration) (*ShareLease, error) {
+	permissions, err := c.GetResourcePermissions(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Permissions: %w", err)
+	}
+	if slices.ContainsFunc(permissions, func(p api.Permission) bool { return p.ARO == aro && p.AROForeignKey == aroID }) {
+		return nil, fmt.Errorf("%w: %v %v", ErrAlreadyShared, aro, aroID)
+	}
+
+	now := time.Now()
+	lease := ShareLease{
+		ID:         uuid.NewString(),
+		ResourceID: resourceID,
+		ARO:        aro,
+		AROID:      aroID,
+		Type:       permissionType,
+		Created:    now,
+		Expires:    now.Add(duration),
+	}
+	// The lease is stored first, a share that is not recorded would never be revoked
+	err = store.Put(ctx, lease)
+	if err != nil {
+		return nil, fmt.Errorf("storing lease: %w", err)
+	}
+
+	err = ShareResource(ctx, c, resourceID, []ShareOperation{{Type: permissionType, ARO: aro, AROID: aroID}})
+	if err != nil {
+		return nil, errors.Join(err, store.Delete(ctx, lease.ID))
+	}
+	return &lease, nil
+}
+
+// LeaseReconciler revokes expired ShareLeases
+type LeaseReconciler struct {
+	Client *api.Client
+	Store  LeaseStore
+	// Rotator, if set, rotates the password of a Resource after a lease on it was revoked
+	Rotator Rotator
+	// RotationOptions are passed to RotateResource. The zero value works for Resource types with a secret description,
+	// Resources of the password string types are only rotated with WithoutPreviousPassword
+	RotationOptions RotationOptions
+	// OnError is called by Run with the errors of a reconciliation, nil ignores them
+	OnError func(err error)
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// RunOnce revokes all expired leases and, with a Rotator, rotates their Resources. It returns the leases that are done.
+// A lease that fails to be revoked or rotated is kept for the next run
+func (r *LeaseReconciler) RunOnce(ctx context.Context) ([]ShareLease, error) {
+	leases, err := r.Store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing leases: %w", err)
+	}
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+
+	revoked := []ShareLease{}
+	errs := []error{}
+	for _, lease := range expiredLeases(leases, now) {
+		err = r.expire(ctx, lease)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		revoked = append(revoked, lease)
+	}
+	return revoked, errors.Join(errs...)
+}
+
+// Run calls RunOnce every interval until ctx is done
+func (r *LeaseReconciler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := r.RunOnce(ctx)
+		if err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// expire revokes the Permission of a lease and rotates the Resource. The lease is only deleted once both succeeded,
+// if the rotation fails it is stored with RotationPending so the next run retries the rotation
+func (r *LeaseReconciler) expire(ctx context.Context, lease ShareLease) error {
+	if !lease.RotationPending {
+		deleted, err := r.revoke(ctx, lease)
+		if err != nil {
+			return fmt.Errorf("revoking lease %v: %w", lease.ID, err)
+		}
+		if r.Rotator != nil && !deleted {
+			lease.RotationPending = true
+			err = r.Store.Put(ctx, lease)
+			if err != nil {
+				return fmt.Errorf("storing lease %v: %w", lease.ID, err)
+			}
+		}
+	}
+
+	if r.Rotator != nil && lease.RotationPending {
+		err := RotateResource(ctx, r.Client, lease.ResourceID, r.Rotator, r.RotationOptions)
+		if errors.Is(err, ErrRotationPending) {
+			// An interrupted rotation is finished first so the next one starts from the password the target accepts
+			err = ResolveRotation(ctx, r.Client, lease.ResourceID, r.Rotator)
+			if err == nil {
+				err = RotateResource(ctx, r.Client, lease.ResourceID, r.Rotator, r.RotationOptions)
+			}
+		}
+		var apiErr *api.APIError
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+			return fmt.Errorf("rotating Resource %v after lease %v: %w", lease.ResourceID, lease.ID, err)
+		}
+	}
+
+	err := r.Store.Delete(ctx, lease.ID)
+	if err != nil {
+		return fmt.Errorf("deleting lease %v: %w", lease.ID, err)
+	}
+	return nil
+}
+
+// revoke deletes the Permission of a lease unless someone else already removed it.
+// It reports whether the Resource was deleted, which leaves nothing to revoke or rotate
+func (r *LeaseReconciler) revoke(ctx context.Context, lease ShareLease) (bool, error) {
+	permissions, err := r.Client.GetResourcePermissions(ctx, lease.ResourceID)
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting Resource Permissions: %w", err)
+	}
+
+	if slices.ContainsFunc(permissions, func(p api.Permission) bool { return p.ARO == lease.ARO && p.AROForeignKey == lease.AROID }) {
+		err = ShareResource(ctx, r.Client, lease.ResourceID, []ShareOperation{{Type: -1, ARO: lease.ARO, AROID: lease.AROID}})
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// expiredLeases returns the leases expired at now
+func expiredLeases(leases []ShareLease, now time.Time) []ShareLease {
+	expired := []ShareLease{}
+	for _, lease := range leases {
+		if !lease.Expires.After(now) {
+			expired = append(expired, lease)
+		}
+	}
+	return expired
+}
+
+func sortLeases(leases []ShareLease) {
+	sort.SliceStable(leases, func(i, j int) bool {
+		if !leases[i].Expires.Equal(leases[j].Expires) {
+			return leases[i].Expires.Before(leases[j].Expires)
+		}
+		return leases[i].ID < leases[j].ID
+	})
+}
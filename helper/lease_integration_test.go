@@ -0,0 +1,139 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestShareResourceTemporarily(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	contractor, err := pb.CreateUser(ctx, "contractor@passbolt.com", "Temporary", "Contractor", "user", "contractor@passbolt.com")
+	if err != nil {
+		t.Fatalf("Creating User %v", err)
+	}
+	id, err := CreateResource(ctx, client, "", "temporary", "", "", "secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	store := NewMemoryLeaseStore()
+	lease, err := ShareResourceTemporarily(ctx, client, store, id, "User", contractor.UserID, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("Sharing Resource %v", err)
+	}
+	_, err = ShareResourceTemporarily(ctx, client, store, id, "User", contractor.UserID, 1, time.Hour)
+	if !errors.Is(err, ErrAlreadyShared) {
+		t.Fatalf("got %v, want %v", err, ErrAlreadyShared)
+	}
+
+	hasAccess := func() bool {
+		permissions, err := client.GetResourcePermissions(ctx, id)
+		if err != nil {
+			t.Fatalf("Getting Permissions %v", err)
+		}
+		return slices.ContainsFunc(permissions, func(p api.Permission) bool { return p.AROForeignKey == contractor.UserID })
+	}
+	if !hasAccess() {
+		t.Fatal("contractor has no access")
+	}
+
+	reconciler := &LeaseReconciler{Client: client, Store: store}
+	revoked, err := reconciler.RunOnce(ctx)
+	if err != nil || len(revoked) != 0 {
+		t.Fatalf("revoked %+v, %v before expiry", revoked, err)
+	}
+
+	reconciler.Now = func() time.Time { return lease.Expires }
+	revoked, err = reconciler.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("Reconciling %v", err)
+	}
+	if len(revoked) != 1 || revoked[0].ID != lease.ID {
+		t.Fatalf("revoked = %+v", revoked)
+	}
+	if hasAccess() {
+		t.Error("contractor still has access")
+	}
+	leases, _ := store.List(ctx)
+	if len(leases) != 0 {
+		t.Errorf("leases left = %+v", leases)
+	}
+}
+
+func TestLeaseReconcilerRotationFailure(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	contractor, err := pb.CreateUser(ctx, "rotation-contractor@passbolt.com", "Rotation", "Contractor", "user", "rotation-contractor@passbolt.com")
+	if err != nil {
+		t.Fatalf("Creating User %v", err)
+	}
+	id, err := CreateResource(ctx, client, "", "leased", "", "", "initial", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	store := NewMemoryLeaseStore()
+	lease, err := ShareResourceTemporarily(ctx, client, store, id, "User", contractor.UserID, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("Sharing Resource %v", err)
+	}
+	rotator := NewFakeRotator(map[string]string{id: "initial"})
+	rotator.RotateErr = errors.New("target unreachable")
+	reconciler := &LeaseReconciler{
+		Client:  client,
+		Store:   store,
+		Rotator: rotator,
+		Now:     func() time.Time { return lease.Expires },
+	}
+
+	// The access is revoked, the lease is kept until the rotation succeeds
+	revoked, err := reconciler.RunOnce(ctx)
+	if !errors.Is(err, ErrRotationFailed) || len(revoked) != 0 {
+		t.Fatalf("got %+v, %v", revoked, err)
+	}
+	permissions, err := client.GetResourcePermissions(ctx, id)
+	if err != nil {
+		t.Fatalf("Getting Permissions %v", err)
+	}
+	if slices.ContainsFunc(permissions, func(p api.Permission) bool { return p.AROForeignKey == contractor.UserID }) {
+		t.Error("contractor still has access")
+	}
+	leases, _ := store.List(ctx)
+	if len(leases) != 1 || !leases[0].RotationPending {
+		t.Fatalf("leases = %+v", leases)
+	}
+
+	// The default RotationOptions keep the previous password until the new one is verified
+	rotator.RotateErr = nil
+	revoked, err = reconciler.RunOnce(ctx)
+	if err != nil || len(revoked) != 1 || revoked[0].ID != lease.ID {
+		t.Fatalf("got %+v, %v", revoked, err)
+	}
+	_, _, _, _, password, description, err := GetResource(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if current, _ := rotator.Password(id); current != password || password == "initial" || description != "" {
+		t.Errorf("got target password %q, Resource password %q, description %q", current, password, description)
+	}
+	leases, _ = store.List(ctx)
+	if len(leases) != 0 {
+		t.Errorf("leases left = %+v", leases)
+	}
+}
@@ -0,0 +1,127 @@
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testLeaseStore(t *testing.T, store LeaseStore) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	later := ShareLease{ID: "later", ResourceID: "r1", ARO: "User", AROID: "u1", Type: 1, Expires: now.Add(2 * time.Hour)}
+	sooner := ShareLease{ID: "sooner", ResourceID: "r2", ARO: "Group", AROID: "g1", Type: 7, Expires: now.Add(time.Hour)}
+	for _, lease := range []ShareLease{later, sooner} {
+		if err := store.Put(ctx, lease); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	// Put replaces a lease with the same ID
+	later.Type = 15
+	if err := store.Put(ctx, later); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	leases, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(leases) != 2 || leases[0].ID != "sooner" || leases[1].ID != "later" || leases[1].Type != 15 {
+		t.Fatalf("leases = %+v", leases)
+	}
+
+	if err := store.Delete(ctx, "sooner"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "missing"); err != nil {
+		t.Fatalf("Delete of missing lease: %v", err)
+	}
+	leases, err = store.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(leases) != 1 || leases[0].ID != "later" {
+		t.Fatalf("leases after delete = %+v", leases)
+	}
+}
+
+func TestMemoryLeaseStore(t *testing.T) {
+	t.Parallel()
+	testLeaseStore(t, NewMemoryLeaseStore())
+}
+
+func TestFileLeaseStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "leases.json")
+	testLeaseStore(t, NewFileLeaseStore(path))
+
+	// A new store on the same file sees the same leases
+	leases, err := NewFileLeaseStore(path).List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(leases) != 1 || leases[0].ID != "later" {
+		t.Fatalf("reopened leases = %+v", leases)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestFileLeaseStore_Missing(t *testing.T) {
+	t.Parallel()
+
+	leases, err := NewFileLeaseStore(filepath.Join(t.TempDir(), "missing.json")).List(context.Background())
+	if err != nil || len(leases) != 0 {
+		t.Fatalf("got %v, %v for missing file", leases, err)
+	}
+}
+
+func TestExpiredLeases(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	leases := []ShareLease{
+		{ID: "past", Expires: now.Add(-time.Minute)},
+		{ID: "now", Expires: now},
+		{ID: "future", Expires: now.Add(time.Minute)},
+	}
+	expired := expiredLeases(leases, now)
+	if len(expired) != 2 || expired[0].ID != "past" || expired[1].ID != "now" {
+		t.Errorf("expired = %+v", expired)
+	}
+}
+
+func TestLeaseReconciler_RunStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	r := &LeaseReconciler{
+		Store: NewMemoryLeaseStore(),
+		Now: func() time.Time {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+			return time.Now()
+		},
+	}
+	err := r.Run(ctx, time.Millisecond)
+	if err != context.Canceled {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	if runs != 3 {
+		t.Errorf("reconciled %d times, want 3", runs)
+	}
+}
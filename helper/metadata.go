@@ -33,25 +33,9 @@ func GetResourceMetadata(ctx context.Context, c *api.Client, resource *api.Resou
 		// If decrypt failed, fall through to full decryption path
 	}
 
-	var metadatakey *crypto.Key
-	var metadataKeyID string
-
-	if resource.MetadataKeyType == api.MetadataKeyTypeUserKey {
-		tmp, err := c.GetUserPrivateKeyCopy()
-		if err != nil {
-			return "", fmt.Errorf("get Private Key Copy: %w", err)
-		}
-		metadatakey = tmp
-		// Use user key fingerprint as cache key to enable session key caching
-		metadataKeyID = "user-key:" + tmp.GetFingerprint()
-	} else {
-		// Use cached decrypted metadata key
-		metadataKeyID = resource.MetadataKeyID
-		key, err := c.GetDecryptedMetadataKeyCached(ctx, metadataKeyID)
-		if err != nil {
-			return "", fmt.Errorf("get Metadata Key by ID: %w", err)
-		}
-		metadatakey = key
+	metadataKeyID, metadatakey, err := getMetadataDecryptionKey(ctx, c, resource.MetadataKeyType, resource.MetadataKeyID)
+	if err != nil {
+		return "", err
 	}
 
 	// Use resource-aware decryption that checks pre-fetched session keys first
@@ -69,6 +53,135 @@ func GetResourceMetadata(ctx context.Context, c *api.Client, resource *api.Resou
 	return decMetadata, nil
 }
 
+// GetFolderMetadata decrypts and parses the Metadata of a v5 Folder
+func GetFolderMetadata(ctx context.Context, c *api.Client, folder *api.Folder) (*api.FolderMetadata, error) {
+	decMetadata, err := decryptForeignMetadata(ctx, c, api.ForeignModelTypesFolder, folder.ID, folder.MetadataKeyType, folder.MetadataKeyID, folder.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return parseFolderMetadata(decMetadata)
+}
+
+// GetTagMetadata decrypts and parses the Metadata of a v5 Tag
+func GetTagMetadata(ctx context.Context, c *api.Client, tag *api.Tag) (*api.TagMetadata, error) {
+	decMetadata, err := decryptForeignMetadata(ctx, c, api.ForeignModelTypesTag, tag.ID, tag.MetadataKeyType, tag.MetadataKeyID, tag.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return parseTagMetadata(decMetadata)
+}
+
+// GetCommentMetadata decrypts and parses the Metadata of a v5 Comment
+func GetCommentMetadata(ctx context.Context, c *api.Client, comment *api.Comment) (*api.CommentMetadata, error) {
+	decMetadata, err := decryptForeignMetadata(ctx, c, api.ForeignModelTypesComment, comment.ID, comment.MetadataKeyType, comment.MetadataKeyID, comment.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommentMetadata(decMetadata)
+}
+
+// decryptForeignMetadata decrypts the Metadata of a Folder, Tag or Comment.
+// Same as for resources, getting the metadata key is skipped if a session key has been pre-fetched
+func decryptForeignMetadata(ctx context.Context, c *api.Client, foreignModel api.ForeignModelTypes, foreignID string, keyType api.MetadataKeyType, keyID, armoredMetadata string) (string, error) {
+	if cachedSessionKey := c.GetSessionKeyByForeignID(foreignModel, foreignID); cachedSessionKey != nil {
+		decMetadata, err := c.DecryptMetadataWithForeignID(foreignModel, foreignID, "", nil, armoredMetadata)
+		if err == nil {
+			return decMetadata, nil
+		}
+		// If decrypt failed, fall through to full decryption path
+	}
+
+	metadataKeyID, metadatakey, err := getMetadataDecryptionKey(ctx, c, keyType, keyID)
+	if err != nil {
+		return "", err
+	}
+
+	decMetadata, err := c.DecryptMetadataWithForeignID(foreignModel, foreignID, metadataKeyID, metadatakey, armoredMetadata)
+	if err != nil {
+		return "", fmt.Errorf("decrypt Metadata: %w", err)
+	}
+	return decMetadata, nil
+}
+
+// encryptForeignMetadata marshals metadata and encrypts it with the personal or shared metadata key.
+// Returns the Metadata Key ID, Key Type and the armored Metadata
+func encryptForeignMetadata(ctx context.Context, c *api.Client, metadata any, personal bool) (string, api.MetadataKeyType, string, error) {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", "", "", fmt.Errorf("marshaling Metadata: %w", err)
+	}
+
+	metadataKeyID, metadataKeyType, publicMetadataKey, err := c.GetMetadataKey(ctx, personal)
+	if err != nil {
+		return "", "", "", fmt.Errorf("get Metadata Key: %w", err)
+	}
+
+	encMetadata, err := c.EncryptMetadata(publicMetadataKey, string(data))
+	if err != nil {
+		return "", "", "", fmt.Errorf("encrypt Metadata: %w", err)
+	}
+	return metadataKeyID, metadataKeyType, encMetadata, nil
+}
+
+func parseFolderMetadata(decMetadata string) (*api.FolderMetadata, error) {
+	var metadata api.FolderMetadata
+	err := json.Unmarshal([]byte(decMetadata), &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("parse Folder Metadata: %w", err)
+	}
+
+	if metadata.ObjectType != api.PassboltObjectTypeFolderMetadata {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMetadataObjectType, metadata.ObjectType)
+	}
+	return &metadata, nil
+}
+
+func parseTagMetadata(decMetadata string) (*api.TagMetadata, error) {
+	var metadata api.TagMetadata
+	err := json.Unmarshal([]byte(decMetadata), &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("parse Tag Metadata: %w", err)
+	}
+
+	if metadata.ObjectType != api.PassboltObjectTypeTagMetadata {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMetadataObjectType, metadata.ObjectType)
+	}
+	return &metadata, nil
+}
+
+func parseCommentMetadata(decMetadata string) (*api.CommentMetadata, error) {
+	var metadata api.CommentMetadata
+	err := json.Unmarshal([]byte(decMetadata), &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("parse Comment Metadata: %w", err)
+	}
+
+	if metadata.ObjectType != api.PassboltObjectTypeCommentMetadata {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMetadataObjectType, metadata.ObjectType)
+	}
+	return &metadata, nil
+}
+
+// getMetadataDecryptionKey returns the key needed to decrypt metadata encrypted with the given key type and ID,
+// together with the ID under which session keys for that key are cached
+func getMetadataDecryptionKey(ctx context.Context, c *api.Client, keyType api.MetadataKeyType, keyID string) (string, *crypto.Key, error) {
+	if keyType == api.MetadataKeyTypeUserKey {
+		key, err := c.GetUserPrivateKeyCopy()
+		if err != nil {
+			return "", nil, fmt.Errorf("get Private Key Copy: %w", err)
+		}
+		// Use user key fingerprint as cache key to enable session key caching
+		return "user-key:" + key.GetFingerprint(), key, nil
+	}
+
+	// Use cached decrypted metadata key
+	key, err := c.GetDecryptedMetadataKeyCached(ctx, keyID)
+	if err != nil {
+		return "", nil, fmt.Errorf("get Metadata Key by ID: %w", err)
+	}
+	return keyID, key, nil
+}
+
 func validateMetadata(rType *api.ResourceType, metadata string) error {
 	// Check schema cache first
 	schemaCacheMu.RLock()
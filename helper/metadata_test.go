@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+)
+
+// parseFolderMetadata is the last check between a decrypted folder
+// payload and the name shown to the caller. Decrypted metadata of a
+// different object (e.g. resource metadata encrypted with the same key)
+// must be rejected instead of being surfaced as a folder name.
+func TestParseFolderMetadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid folder metadata", func(t *testing.T) {
+		t.Parallel()
+		got, err := parseFolderMetadata(`{"object_type":"PASSBOLT_FOLDER_METADATA","name":"Infra","color":"#ff0000"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Name != "Infra" || got.Color != "#ff0000" {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("wrong object type", func(t *testing.T) {
+		t.Parallel()
+		_, err := parseFolderMetadata(`{"object_type":"PASSBOLT_RESOURCE_METADATA","name":"Stripe"}`)
+		if !errors.Is(err, ErrInvalidMetadataObjectType) {
+			t.Errorf("err = %v, want wrap of ErrInvalidMetadataObjectType", err)
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		t.Parallel()
+		if _, err := parseFolderMetadata(`{`); err == nil {
+			t.Error("expected error for invalid JSON")
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// OffboardOptions configure OffboardUser
+type OffboardOptions struct {
+	// NewOwnerARO is "User" or "Group", it receives ownership of the Resources and Folders only the user owns
+	NewOwnerARO string
+	NewOwnerID  string
+	// NewGroupManagerID is the User that becomes manager of the Groups only the user manages,
+	// defaults to NewOwnerID if the new owner is a User
+	NewGroupManagerID string
+	// Disable disables the user instead of deleting it
+	Disable bool
+}
+
+// OffboardingResource is a resource the offboarded user could decrypt
+type OffboardingResource struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URI  string `json:"uri,omitempty"`
+	// GroupIDs are the Groups the user had access through, empty if the access was only direct
+	GroupIDs []string `json:"group_ids,omitempty"`
+}
+
+// OffboardingReport is the result of OffboardUser
+type OffboardingReport struct {
+	UserID               string   `json:"user_id"`
+	Deleted              bool     `json:"deleted"`
+	Disabled             bool     `json:"disabled"`
+	TransferredResources []string `json:"transferred_resources"`
+	TransferredFolders   []string `json:"transferred_folders"`
+	TransferredGroups    []string `json:"transferred_groups"`
+	// Rotate are the resources the user could decrypt, their passwords should be rotated.
+	// Resources the client has no access to are only listed if the user was their sole owner
+	Rotate []OffboardingResource `json:"rotate"`
+}
+
+// OffboardUser hands over what only userID owns or manages to a new owner, then deletes or disables the user.
+// The report lists every resource the user could decrypt, it is collected before anything is changed
+func OffboardUser(ctx context.Context, c *api.Client, userID string, opts OffboardOptions) (*OffboardingReport, error) {
+	blockers, err := c.DeleteUserDryrunBlockers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("delete User Dryrun: %w", err)
+	}
+
+	rotate, err := getOffboardingResources(ctx, c, userID, blockers.Resources.SoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.NewGroupManagerID == "" && opts.NewOwnerARO == "User" {
+		opts.NewGroupManagerID = opts.NewOwnerID
+	}
+	plan, err := planOwnershipTransfer(blockers, opts.NewOwnerARO, opts.NewOwnerID, opts.NewGroupManagerID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Disable {
+		// Disabled users keep their permissions, so promotions the server does on deletion are done by the client
+		plan.inline(blockers)
+	}
+
+	resources, folders, groups, err := plan.apply(ctx, c, blockers)
+	if err != nil {
+		return nil, err
+	}
+	report := &OffboardingReport{
+		UserID:               userID,
+		TransferredResources: resources,
+		TransferredFolders:   folders,
+		TransferredGroups:    groups,
+		Rotate:               rotate,
+	}
+
+	if opts.Disable {
+		_, err = c.UpdateUser(ctx, userID, api.User{Disabled: &api.Time{Time: time.Now()}})
+		if err != nil {
+			return nil, fmt.Errorf("disabling User: %w", err)
+		}
+		report.Disabled = true
+		return report, nil
+	}
+
+	err = c.DeleteUserWithTransfer(ctx, userID, plan.transfer)
+	if err != nil {
+		return nil, fmt.Errorf("deleting User: %w", err)
+	}
+	report.Deleted = true
+	return report, nil
+}
+
+// getOffboardingResources lists the resources userID can decrypt, directly or through a Group
+func getOffboardingResources(ctx context.Context, c *api.Client, userID string, soleOwner []api.Resource) ([]OffboardingResource, error) {
+	groups, err := c.GetGroups(ctx, &api.GetGroupsOptions{FilterHasUsers: []string{userID}})
+	if err != nil {
+		return nil, fmt.Errorf("getting Groups: %w", err)
+	}
+	groupIDs := []string{}
+	for _, g := range groups {
+		groupIDs = append(groupIDs, g.ID)
+	}
+
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		ContainPermissionsUserProfile: true,
+		ContainPermissionsGroup:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	// Resources only the user owns may not be visible to the client
+	for _, r := range soleOwner {
+		if !slices.ContainsFunc(resources, func(v api.Resource) bool { return v.ID == r.ID }) {
+			resources = append(resources, r)
+		}
+	}
+
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+
+	exposed := exposedResources(resources, userID, groupIDs)
+	for i, e := range exposed {
+		idx := slices.IndexFunc(resources, func(r api.Resource) bool { return r.ID == e.ID })
+		exposed[i].Name, exposed[i].URI = resourceNameAndURI(c, resources[idx], rTypes)
+	}
+	sort.SliceStable(exposed, func(i, j int) bool { return exposed[i].Name < exposed[j].Name })
+	return exposed, nil
+}
+
+// exposedResources returns the resources with a permission for userID or one of its groupIDs
+func exposedResources(resources []api.Resource, userID string, groupIDs []string) []OffboardingResource {
+	exposed := []OffboardingResource{}
+	for _, r := range resources {
+		direct := false
+		via := []string{}
+		for _, p := range r.Permissions {
+			switch {
+			case p.ARO == "User" && p.AROForeignKey == userID:
+				direct = true
+			case p.ARO == "Group" && slices.Contains(groupIDs, p.AROForeignKey):
+				via = append(via, p.AROForeignKey)
+			}
+		}
+		if !direct && len(via) == 0 {
+			continue
+		}
+		e := OffboardingResource{ID: r.ID, Name: r.Name, URI: r.URI}
+		if len(via) != 0 {
+			e.GroupIDs = via
+		}
+		exposed = append(exposed, e)
+	}
+	return exposed
+}
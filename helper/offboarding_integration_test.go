@@ -0,0 +1,63 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestOffboardUser(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	leaver, err := pb.CreateUser(ctx, "leaver@passbolt.com", "Leaving", "User", "user", "leaver@passbolt.com")
+	if err != nil {
+		t.Fatalf("Creating User %v", err)
+	}
+	leaverClient, err := api.NewClient(nil, "go-passbolt-helper-tests", pb.BaseURL, leaver.PrivateKey, leaver.Password)
+	if err != nil {
+		t.Fatalf("Creating Client %v", err)
+	}
+	if err := leaverClient.Login(ctx); err != nil {
+		t.Fatalf("Login %v", err)
+	}
+
+	// Shared read only with the admin, so the admin can be promoted on deletion
+	id, err := CreateResource(ctx, leaverClient, "", "offboarding", "leaver", "https://example.com", "secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	err = ShareResourceWithUsersAndGroups(ctx, leaverClient, id, []string{client.GetUserID()}, nil, 1)
+	if err != nil {
+		t.Fatalf("Sharing Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	report, err := OffboardUser(ctx, client, leaver.UserID, OffboardOptions{NewOwnerARO: "User", NewOwnerID: client.GetUserID()})
+	if err != nil {
+		t.Fatalf("Offboarding User %v", err)
+	}
+	if !report.Deleted {
+		t.Errorf("user not deleted")
+	}
+	if !slices.Contains(report.TransferredResources, id) {
+		t.Errorf("resource %v not transferred: %v", id, report.TransferredResources)
+	}
+	if !slices.ContainsFunc(report.Rotate, func(r OffboardingResource) bool { return r.ID == id && r.Name == "offboarding" }) {
+		t.Errorf("resource %v not in rotation report: %+v", id, report.Rotate)
+	}
+
+	permissions, err := client.GetResourcePermissions(ctx, id)
+	if err != nil {
+		t.Fatalf("Getting Permissions %v", err)
+	}
+	if !slices.ContainsFunc(permissions, func(p api.Permission) bool { return p.AROForeignKey == client.GetUserID() && p.Type == 15 }) {
+		t.Errorf("admin is not owner: %+v", permissions)
+	}
+}
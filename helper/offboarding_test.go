@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestExposedResources(t *testing.T) {
+	t.Parallel()
+
+	resources := []api.Resource{
+		{ID: "direct", Permissions: []api.Permission{{ARO: "User", AROForeignKey: "leaver"}}},
+		{ID: "group", Permissions: []api.Permission{{ARO: "Group", AROForeignKey: "g1"}, {ARO: "Group", AROForeignKey: "g2"}}},
+		{ID: "both", Permissions: []api.Permission{{ARO: "User", AROForeignKey: "leaver"}, {ARO: "Group", AROForeignKey: "g1"}}},
+		{ID: "none", Permissions: []api.Permission{{ARO: "User", AROForeignKey: "other"}, {ARO: "Group", AROForeignKey: "g3"}}},
+	}
+
+	exposed := exposedResources(resources, "leaver", []string{"g1", "g2"})
+	if len(exposed) != 3 {
+		t.Fatalf("got %d exposed resources, want 3: %+v", len(exposed), exposed)
+	}
+	if exposed[0].ID != "direct" || exposed[0].GroupIDs != nil {
+		t.Errorf("direct = %+v", exposed[0])
+	}
+	if exposed[1].ID != "group" || !slices.Equal(exposed[1].GroupIDs, []string{"g1", "g2"}) {
+		t.Errorf("group = %+v", exposed[1])
+	}
+	if exposed[2].ID != "both" || !slices.Equal(exposed[2].GroupIDs, []string{"g1"}) {
+		t.Errorf("both = %+v", exposed[2])
+	}
+}
@@ -0,0 +1,89 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// DefaultResourceExpiry returns when a password set at now expires according to the server's DefaultExpiryPeriod.
+// Returns nil if passwords don't expire by default
+func DefaultResourceExpiry(c *api.Client, now time.Time) *api.Time {
+	return defaultExpiry(c.GetPasswordExpirySettings(), now)
+}
+
+// MarkResourcesExpired marks the passwords of Resources as expired now
+func MarkResourcesExpired(ctx context.Context, c *api.Client, resourceIDs ...string) error {
+	now := &api.Time{Time: time.Now()}
+	resources := make([]api.PasswordExpiryResource, 0, len(resourceIDs))
+	for _, id := range resourceIDs {
+		resources = append(resources, api.PasswordExpiryResource{ID: id, Expired: now})
+	}
+
+	err := c.UpdatePasswordExpiryResources(ctx, resources)
+	if err != nil {
+		return fmt.Errorf("marking Resources expired: %w", err)
+	}
+	return nil
+}
+
+// SetResourceExpiry sets when the password of a Resource expires
+func SetResourceExpiry(ctx context.Context, c *api.Client, resourceID string, expiry time.Time) error {
+	err := c.UpdatePasswordExpiryResources(ctx, []api.PasswordExpiryResource{{ID: resourceID, Expired: &api.Time{Time: expiry}}})
+	if err != nil {
+		return fmt.Errorf("setting Resource expiry: %w", err)
+	}
+	return nil
+}
+
+// ClearResourceExpiry removes the expiry date of the password of a Resource
+func ClearResourceExpiry(ctx context.Context, c *api.Client, resourceID string) error {
+	err := c.UpdatePasswordExpiryResources(ctx, []api.PasswordExpiryResource{{ID: resourceID}})
+	if err != nil {
+		return fmt.Errorf("clearing Resource expiry: %w", err)
+	}
+	return nil
+}
+
+// IsResourceExpired returns true if the password of the Resource has expired at now
+func IsResourceExpired(resource api.Resource, now time.Time) bool {
+	return resource.Expired != nil && !resource.Expired.After(now)
+}
+
+// GetExpiringResources returns the Resources whose password has expired or expires within
+// the server's ExpiryNotificationPeriod, soonest first
+func GetExpiringResources(ctx context.Context, c *api.Client) ([]api.Resource, error) {
+	resources, err := c.GetResources(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+
+	period := time.Duration(c.GetPasswordExpirySettings().ExpiryNotificationPeriod) * 24 * time.Hour
+	return expiringResources(resources, time.Now(), period), nil
+}
+
+// defaultExpiry returns now plus the DefaultExpiryPeriod in days, nil if it is not set
+func defaultExpiry(settings api.PasswordExpirySettings, now time.Time) *api.Time {
+	if settings.DefaultExpiryPeriod <= 0 {
+		return nil
+	}
+	return &api.Time{Time: now.AddDate(0, 0, settings.DefaultExpiryPeriod)}
+}
+
+// expiringResources filters resources expiring before now plus within, soonest first
+func expiringResources(resources []api.Resource, now time.Time, within time.Duration) []api.Resource {
+	deadline := now.Add(within)
+	result := []api.Resource{}
+	for _, resource := range resources {
+		if resource.Expired != nil && !resource.Expired.After(deadline) {
+			result = append(result, resource)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Expired.Before(result[j].Expired.Time)
+	})
+	return result
+}
@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// The expiry query feeds notifications: a resource that expired long ago
+// must still be listed, and one expiring just outside the window must not.
+
+func TestExpiringResources(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(id string, offset time.Duration) api.Resource {
+		return api.Resource{ID: id, Expired: &api.Time{Time: now.Add(offset)}}
+	}
+
+	got := expiringResources([]api.Resource{
+		at("in-6-days", 6*24*time.Hour),
+		{ID: "never"},
+		at("in-8-days", 8*24*time.Hour),
+		at("expired", -30*24*time.Hour),
+		at("now", 0),
+	}, now, 7*24*time.Hour)
+
+	want := []string{"expired", "now", "in-6-days"}
+	if len(got) != len(want) {
+		t.Fatalf("expiringResources = %+v", got)
+	}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Errorf("expiringResources[%d] = %v, want %v", i, got[i].ID, id)
+		}
+	}
+}
+
+func TestIsResourceExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	if IsResourceExpired(api.Resource{}, now) {
+		t.Error("resource without expiry is expired")
+	}
+	if !IsResourceExpired(api.Resource{Expired: &api.Time{Time: now}}, now) {
+		t.Error("resource expiring now is not expired")
+	}
+	if IsResourceExpired(api.Resource{Expired: &api.Time{Time: now.Add(time.Minute)}}, now) {
+		t.Error("resource expiring later is expired")
+	}
+}
+
+func TestDefaultExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	if got := defaultExpiry(api.PasswordExpirySettings{}, now); got != nil {
+		t.Errorf("defaultExpiry without period = %v, want nil", got)
+	}
+	got := defaultExpiry(api.PasswordExpirySettings{DefaultExpiryPeriod: 90}, now)
+	if got == nil || !got.Equal(now.AddDate(0, 0, 90)) {
+		t.Errorf("defaultExpiry = %v", got)
+	}
+}
@@ -0,0 +1,259 @@
+package helper
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+//go:embed wordlist.txt
+var wordlistData string
+
+// wordlist is the bundled list of passphrase words, EFF's long wordlist.
+// Lines starting with # hold its source and license and are skipped
+var wordlist = sync.OnceValue(func() []string {
+	var words []string
+	for _, line := range strings.Split(wordlistData, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words
+})
+
+// passwordMask is a group of characters that can be enabled in the password policy
+type passwordMask struct {
+	characters []rune
+	enabled    func(settings api.PasswordGeneratorSettings) bool
+}
+
+// passwordMasks are the character groups of the Passbolt password generator
+var passwordMasks = []passwordMask{
+	{[]rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ"), func(s api.PasswordGeneratorSettings) bool { return s.MaskUpper }},
+	{[]rune("abcdefghijklmnopqrstuvwxyz"), func(s api.PasswordGeneratorSettings) bool { return s.MaskLower }},
+	{[]rune("0123456789"), func(s api.PasswordGeneratorSettings) bool { return s.MaskDigit }},
+	{[]rune("([|])"), func(s api.PasswordGeneratorSettings) bool { return s.MaskParenthesis }},
+	{[]rune("#$%&@^~"), func(s api.PasswordGeneratorSettings) bool { return s.MaskChar1 }},
+	{[]rune(".,:;"), func(s api.PasswordGeneratorSettings) bool { return s.MaskChar2 }},
+	{[]rune("'\"`"), func(s api.PasswordGeneratorSettings) bool { return s.MaskChar3 }},
+	{[]rune("/\\_-"), func(s api.PasswordGeneratorSettings) bool { return s.MaskChar4 }},
+	{[]rune("<*+!?="), func(s api.PasswordGeneratorSettings) bool { return s.MaskChar5 }},
+	{emojiRunes(), func(s api.PasswordGeneratorSettings) bool { return s.MaskEmoji }},
+}
+
+// lookAlikeChars are left out when ExcludeLookAlikeChars is set
+const lookAlikeChars = "Il|O01"
+
+const (
+	defaultPasswordLength     = 18
+	defaultPasswordMaxLength  = 128
+	defaultPassphraseWords    = 9
+	defaultPassphraseMaxWords = 40
+
+	// maxGenerateAttempts bounds the retries until every enabled mask is used
+	maxGenerateAttempts = 1000
+)
+
+// GetPasswordPolicies gets the server's password policies, or Passbolt's defaults if the plugin is not installed
+func GetPasswordPolicies(ctx context.Context, c *api.Client) (*api.PasswordPolicies, error) {
+	settings, err := c.GetServerSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Server Settings: %w", err)
+	}
+	if !settings.Passbolt.IsPluginEnabled("passwordPolicies") {
+		policies := api.DefaultPasswordPolicies()
+		return &policies, nil
+	}
+
+	policies, err := c.GetPasswordPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Password Policies: %w", err)
+	}
+	return policies, nil
+}
+
+// GeneratePassword generates a password or passphrase with the server's default generator.
+// Returns the password and its entropy in bits, which is at least minEntropy
+func GeneratePassword(ctx context.Context, c *api.Client, minEntropy float64) (string, float64, error) {
+	policies, err := GetPasswordPolicies(ctx, c)
+	if err != nil {
+		return "", 0, err
+	}
+	if policies.DefaultGenerator == "passphrase" {
+		return GeneratePassphraseFromSettings(policies.PassphraseGeneratorSettings, minEntropy)
+	}
+	return GeneratePasswordFromSettings(policies.PasswordGeneratorSettings, minEntropy)
+}
+
+// GeneratePasswordFromSettings generates a password using crypto/rand containing at least one character of every enabled mask.
+// If the configured Length has less than minEntropy bits the length is increased up to MaxLength
+func GeneratePasswordFromSettings(settings api.PasswordGeneratorSettings, minEntropy float64) (string, float64, error) {
+	masks := enabledMasks(settings)
+	alphabet := []rune{}
+	for _, mask := range masks {
+		alphabet = append(alphabet, mask...)
+	}
+	if len(alphabet) == 0 {
+		return "", 0, ErrNoCharacterMasks
+	}
+
+	length := settings.Length
+	if length <= 0 {
+		length = defaultPasswordLength
+	}
+	maxLength := settings.MaxLength
+	if maxLength <= 0 {
+		maxLength = defaultPasswordMaxLength
+	}
+	bitsPerChar := math.Log2(float64(len(alphabet)))
+	if needed := int(math.Ceil(minEntropy / bitsPerChar)); needed > length {
+		length = needed
+	}
+	if length > maxLength {
+		return "", 0, fmt.Errorf("%w: %v bits need %d characters, policy allows %d", ErrEntropyTooLow, minEntropy, length, maxLength)
+	}
+
+	password := make([]rune, length)
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		for i := range password {
+			r, err := randomElement(alphabet)
+			if err != nil {
+				return "", 0, err
+			}
+			password[i] = r
+		}
+		if length < len(masks) || usesAllMasks(password, masks) {
+			return string(password), float64(length) * bitsPerChar, nil
+		}
+	}
+	return "", 0, fmt.Errorf("generating password: no password using all %d masks after %d attempts", len(masks), maxGenerateAttempts)
+}
+
+// GeneratePassphraseFromSettings generates a passphrase from the bundled wordlist using crypto/rand.
+// If the configured number of Words has less than minEntropy bits more words are used, up to MaxWords
+func GeneratePassphraseFromSettings(settings api.PassphraseGeneratorSettings, minEntropy float64) (string, float64, error) {
+	words := wordlist()
+
+	count := settings.Words
+	if count <= 0 {
+		count = defaultPassphraseWords
+	}
+	maxWords := settings.MaxWords
+	if maxWords <= 0 {
+		maxWords = defaultPassphraseMaxWords
+	}
+	bitsPerWord := math.Log2(float64(len(words)))
+	if needed := int(math.Ceil(minEntropy / bitsPerWord)); needed > count {
+		count = needed
+	}
+	if count > maxWords {
+		return "", 0, fmt.Errorf("%w: %v bits need %d words, policy allows %d", ErrEntropyTooLow, minEntropy, count, maxWords)
+	}
+
+	passphrase := make([]string, count)
+	for i := range passphrase {
+		word, err := randomElement(words)
+		if err != nil {
+			return "", 0, err
+		}
+		switch settings.WordCase {
+		case "uppercase":
+			word = strings.ToUpper(word)
+		case "camelcase":
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		passphrase[i] = word
+	}
+	return strings.Join(passphrase, settings.WordSeparator), float64(count) * bitsPerWord, nil
+}
+
+// PasswordEntropy estimates the entropy in bits of a password from its length and the character masks it uses,
+// the same way the Passbolt UI rates user supplied passwords
+func PasswordEntropy(password string) float64 {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	alphabetSize := 0
+	for _, mask := range passwordMasks {
+		if containsAny(runes, mask.characters) {
+			alphabetSize += len(mask.characters)
+		}
+	}
+	if alphabetSize == 0 {
+		return 0
+	}
+	return float64(len(runes)) * math.Log2(float64(alphabetSize))
+}
+
+// enabledMasks returns the characters of every enabled mask, without look-alikes if they are excluded
+func enabledMasks(settings api.PasswordGeneratorSettings) [][]rune {
+	masks := [][]rune{}
+	for _, mask := range passwordMasks {
+		if !mask.enabled(settings) {
+			continue
+		}
+		chars := []rune{}
+		for _, r := range mask.characters {
+			if settings.ExcludeLookAlikeChars && strings.ContainsRune(lookAlikeChars, r) {
+				continue
+			}
+			chars = append(chars, r)
+		}
+		if len(chars) > 0 {
+			masks = append(masks, chars)
+		}
+	}
+	return masks
+}
+
+func usesAllMasks(password []rune, masks [][]rune) bool {
+	for _, mask := range masks {
+		if !containsAny(password, mask) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(runes, chars []rune) bool {
+	for _, r := range runes {
+		for _, c := range chars {
+			if r == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// randomElement picks a uniformly random element using crypto/rand
+func randomElement[T any](elements []T) (T, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(elements))))
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("reading random: %w", err)
+	}
+	return elements[n.Int64()], nil
+}
+
+// emojiRunes returns the emoticons block used by the emoji mask
+func emojiRunes() []rune {
+	runes := []rune{}
+	for r := rune(0x1F600); r <= 0x1F64F; r++ {
+		if unicode.Is(unicode.So, r) {
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
@@ -0,0 +1,142 @@
+package helper
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// Generated passwords end up as real credentials, so the tests check the
+// policy is honoured on every run rather than the exact output.
+
+func TestGeneratePasswordFromSettings_FollowsPolicy(t *testing.T) {
+	t.Parallel()
+
+	settings := api.DefaultPasswordPolicies().PasswordGeneratorSettings
+	for i := 0; i < 50; i++ {
+		password, entropy, err := GeneratePasswordFromSettings(settings, 0)
+		if err != nil {
+			t.Fatalf("GeneratePasswordFromSettings: %v", err)
+		}
+		if got := len([]rune(password)); got != settings.Length {
+			t.Fatalf("length = %d, want %d", got, settings.Length)
+		}
+		if strings.ContainsAny(password, lookAlikeChars) {
+			t.Fatalf("%q contains look-alike characters", password)
+		}
+		if !strings.ContainsFunc(password, unicode.IsUpper) || !strings.ContainsFunc(password, unicode.IsDigit) {
+			t.Fatalf("%q is missing an enabled mask", password)
+		}
+		if entropy < 100 {
+			t.Fatalf("entropy = %v", entropy)
+		}
+	}
+}
+
+func TestGeneratePasswordFromSettings_DigitsOnly(t *testing.T) {
+	t.Parallel()
+
+	password, entropy, err := GeneratePasswordFromSettings(api.PasswordGeneratorSettings{Length: 6, MaskDigit: true}, 0)
+	if err != nil {
+		t.Fatalf("GeneratePasswordFromSettings: %v", err)
+	}
+	if strings.IndexFunc(password, func(r rune) bool { return !unicode.IsDigit(r) }) != -1 {
+		t.Errorf("%q contains non digits", password)
+	}
+	if want := 6 * math.Log2(10); math.Abs(entropy-want) > 1e-9 {
+		t.Errorf("entropy = %v, want %v", entropy, want)
+	}
+}
+
+func TestGeneratePasswordFromSettings_MinEntropy(t *testing.T) {
+	t.Parallel()
+
+	settings := api.PasswordGeneratorSettings{Length: 4, MaxLength: 64, MaskLower: true}
+	password, entropy, err := GeneratePasswordFromSettings(settings, 128)
+	if err != nil {
+		t.Fatalf("GeneratePasswordFromSettings: %v", err)
+	}
+	if entropy < 128 || len(password) != 28 {
+		t.Errorf("len = %d, entropy = %v", len(password), entropy)
+	}
+
+	_, _, err = GeneratePasswordFromSettings(settings, 1000)
+	if !errors.Is(err, ErrEntropyTooLow) {
+		t.Errorf("unreachable entropy error = %v", err)
+	}
+	_, _, err = GeneratePasswordFromSettings(api.PasswordGeneratorSettings{Length: 8}, 0)
+	if !errors.Is(err, ErrNoCharacterMasks) {
+		t.Errorf("no masks error = %v", err)
+	}
+}
+
+func TestGeneratePassphraseFromSettings(t *testing.T) {
+	t.Parallel()
+
+	passphrase, entropy, err := GeneratePassphraseFromSettings(api.PassphraseGeneratorSettings{Words: 5, WordSeparator: "_", WordCase: "uppercase"}, 0)
+	if err != nil {
+		t.Fatalf("GeneratePassphraseFromSettings: %v", err)
+	}
+	words := strings.Split(passphrase, "_")
+	if len(words) != 5 || passphrase != strings.ToUpper(passphrase) {
+		t.Errorf("passphrase = %q", passphrase)
+	}
+	if want := 5 * math.Log2(float64(len(wordlist()))); math.Abs(entropy-want) > 1e-9 {
+		t.Errorf("entropy = %v, want %v", entropy, want)
+	}
+
+	passphrase, entropy, err = GeneratePassphraseFromSettings(api.PassphraseGeneratorSettings{Words: 4, WordSeparator: " ", WordCase: "camelcase"}, 80)
+	if err != nil {
+		t.Fatalf("GeneratePassphraseFromSettings: %v", err)
+	}
+	if want := int(math.Ceil(80 / math.Log2(float64(len(wordlist()))))); entropy < 80 || len(strings.Fields(passphrase)) != want {
+		t.Errorf("passphrase %q has %v bits", passphrase, entropy)
+	}
+	for _, word := range strings.Fields(passphrase) {
+		if !unicode.IsUpper([]rune(word)[0]) {
+			t.Errorf("word %q is not camel case", word)
+		}
+	}
+}
+
+func TestWordlist(t *testing.T) {
+	t.Parallel()
+
+	words := wordlist()
+	if len(words) != 7776 {
+		t.Fatalf("wordlist has %d words", len(words))
+	}
+	seen := map[string]bool{}
+	for _, word := range words {
+		if seen[word] {
+			t.Errorf("duplicate word %q", word)
+		}
+		seen[word] = true
+		if strings.ToLower(word) != word {
+			t.Errorf("word %q is not lowercase", word)
+		}
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		password string
+		want     float64
+	}{
+		{"", 0},
+		{"abcd", 4 * math.Log2(26)},
+		{"aB3", 3 * math.Log2(26+26+10)},
+		{"ééé", 0},
+	}
+	for _, tt := range tests {
+		if got := PasswordEntropy(tt.password); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("PasswordEntropy(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
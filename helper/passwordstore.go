@@ -0,0 +1,307 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// passEntryExtension is the extension of the encrypted entries of a password store
+const passEntryExtension = ".gpg"
+
+// passUsernameKeys and passURIKeys are the "key: value" lines read as username and URIs, as used by browserpass and passff
+var (
+	passUsernameKeys = []string{"login", "username", "user"}
+	passURIKeys      = []string{"url", "uri", "website"}
+)
+
+// passFieldLine matches a "key: value" line. URLs like "https://example.com" and lines without value are not fields
+var passFieldLine = regexp.MustCompile(`^(\w[\w .-]*):\s+(\S.*)$`)
+
+// ImportPasswordStore reads a pass password store with ReadPasswordStore and imports it with ImportCredentials.
+// Entries that can't be decrypted are listed in the report's warnings
+func ImportPasswordStore(ctx context.Context, c *api.Client, store fs.FS, key *crypto.Key, opts ImportOptions) (*ImportReport, error) {
+	credentials, warnings, err := ReadPasswordStore(store, key)
+	if err != nil {
+		return nil, err
+	}
+	report, err := ImportCredentials(ctx, c, credentials, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.Warnings = append(warnings, report.Warnings...)
+	return report, nil
+}
+
+// ReadPasswordStore decrypts every entry of a pass password store with the unlocked key, e.g. os.DirFS("~/.password-store").
+// Directories become the folder path, hidden files and directories like .git are skipped
+func ReadPasswordStore(store fs.FS, key *crypto.Key) ([]Credential, []ImportWarning, error) {
+	// Copy the key so clearing the Decryptor leaves the caller's key usable
+	keyCopy, err := key.Copy()
+	if err != nil {
+		return nil, nil, fmt.Errorf("copy Private Key: %w", err)
+	}
+	decHandle, err := crypto.PGP().Decryption().DecryptionKey(keyCopy).New()
+	if err != nil {
+		return nil, nil, fmt.Errorf("new Decryptor: %w", err)
+	}
+	defer decHandle.ClearPrivateParams()
+
+	credentials := []Credential{}
+	warnings := []ImportWarning{}
+	err = fs.WalkDir(store, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(p, passEntryExtension) {
+			return nil
+		}
+
+		name := strings.TrimSuffix(p, passEntryExtension)
+		data, err := fs.ReadFile(store, p)
+		if err != nil {
+			return fmt.Errorf("reading %v: %w", p, err)
+		}
+		res, err := decHandle.Decrypt(data, crypto.Auto)
+		if err != nil {
+			warnings = append(warnings, ImportWarning{Name: name, Reason: fmt.Sprintf("skipped, can't decrypt: %v", err)})
+			return nil
+		}
+
+		folderPath := []string{}
+		if dir := path.Dir(name); dir != "." {
+			folderPath = strings.Split(dir, "/")
+		}
+		credential := parsePassEntry(res.String())
+		credential.FolderPath = folderPath
+		credential.Name = path.Base(name)
+		credentials = append(credentials, credential)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("walking password store: %w", err)
+	}
+	return credentials, warnings, nil
+}
+
+// parsePassEntry maps the content of an entry: the password on the first line, an otpauth:// line as TOTP
+// and "key: value" lines as username, URIs or custom fields. All other lines are the description,
+// as are these lines escaped with a backslash by passEntry
+func parsePassEntry(content string) Credential {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	credential := Credential{
+		Password:     lines[0],
+		URIs:         []string{},
+		CustomFields: CustomFields{},
+	}
+
+	usernameSet := false
+	description := []string{}
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, `\`) && passSpecialLine(line[1:]) {
+			description = append(description, line[1:])
+			continue
+		}
+		if credential.TOTP == nil && strings.HasPrefix(line, "otpauth://") {
+			if totp, err := ParseOTPAuthURI(line); err == nil {
+				credential.TOTP = totp
+				continue
+			}
+		}
+
+		m := passFieldLine.FindStringSubmatch(line)
+		if m == nil {
+			description = append(description, line)
+			continue
+		}
+		key, value := m[1], strings.TrimSpace(m[2])
+		switch lower := strings.ToLower(key); {
+		case !usernameSet && slices.Contains(passUsernameKeys, lower):
+			credential.Username, usernameSet = value, true
+		case slices.Contains(passURIKeys, lower):
+			credential.URIs = append(credential.URIs, value)
+		default:
+			credential.CustomFields = append(credential.CustomFields, CustomField{Key: key, Type: CustomFieldTypeText, Value: value})
+		}
+	}
+	credential.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return credential
+}
+
+// ExportPasswordStore writes every resource the user can decrypt to a pass password store in dir, encrypted to the recipients
+func ExportPasswordStore(ctx context.Context, c *api.Client, dir string, recipients []*crypto.Key) error {
+	credentials, folders, err := getCredentials(ctx, c)
+	if err != nil {
+		return err
+	}
+	return WritePasswordStore(dir, credentials, folders, recipients)
+}
+
+// WritePasswordStore writes the credentials as pass entries in dir, folders become directories.
+// A .gpg-id with the recipients' fingerprints is created if dir has none. Existing entries are not overwritten,
+// an entry whose name is taken by an existing entry or another credential in the folder gets a numbered suffix
+func WritePasswordStore(dir string, credentials []Credential, folders [][]string, recipients []*crypto.Key) error {
+	if len(recipients) == 0 {
+		return ErrPasswordStoreNoRecipients
+	}
+	keyRing, err := crypto.NewKeyRing(nil)
+	if err != nil {
+		return fmt.Errorf("new Keyring: %w", err)
+	}
+	fingerprints := []string{}
+	for _, recipient := range recipients {
+		err = keyRing.AddKey(recipient)
+		if err != nil {
+			return fmt.Errorf("adding recipient: %w", err)
+		}
+		fingerprints = append(fingerprints, strings.ToUpper(recipient.GetFingerprint()))
+	}
+	encHandle, err := crypto.PGP().Encryption().Recipients(keyRing).New()
+	if err != nil {
+		return fmt.Errorf("new Encryptor: %w", err)
+	}
+
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return fmt.Errorf("creating password store: %w", err)
+	}
+	gpgID, err := os.OpenFile(filepath.Join(dir, ".gpg-id"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err == nil {
+		_, err = gpgID.WriteString(strings.Join(fingerprints, "\n") + "\n")
+		if closeErr := gpgID.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil && !os.IsExist(err) {
+		return fmt.Errorf("writing .gpg-id: %w", err)
+	}
+
+	for _, folder := range folders {
+		err = os.MkdirAll(filepath.Join(dir, passPath(folder)), 0o700)
+		if err != nil {
+			return fmt.Errorf("creating folder %v: %w", strings.Join(folder, "/"), err)
+		}
+	}
+
+	used := map[string]bool{}
+	for _, credential := range credentials {
+		folder := filepath.Join(dir, passPath(credential.FolderPath))
+		err = os.MkdirAll(folder, 0o700)
+		if err != nil {
+			return fmt.Errorf("creating folder %v: %w", strings.Join(credential.FolderPath, "/"), err)
+		}
+
+		taken := func(name string) bool {
+			if used[filepath.Join(folder, name)] {
+				return true
+			}
+			_, err := os.Lstat(filepath.Join(folder, name) + passEntryExtension)
+			return !errors.Is(err, os.ErrNotExist)
+		}
+		base := passName(credential.Name)
+		name := base
+		for i := 2; taken(name); i++ {
+			name = fmt.Sprintf("%v (%v)", base, i)
+		}
+		file := filepath.Join(folder, name)
+		used[file] = true
+
+		message, err := encHandle.Encrypt([]byte(passEntry(credential)))
+		if err != nil {
+			return fmt.Errorf("encrypting %v: %w", credential.Name, err)
+		}
+		err = writeNewFile(file+passEntryExtension, message.Bytes())
+		if err != nil {
+			return fmt.Errorf("writing %v: %w", credential.Name, err)
+		}
+	}
+	return nil
+}
+
+// passEntry formats a credential the way parsePassEntry reads it
+func passEntry(credential Credential) string {
+	lines := []string{credential.Password}
+	if credential.Username != "" {
+		lines = append(lines, "login: "+credential.Username)
+	}
+	for _, uri := range credential.URIs {
+		lines = append(lines, "url: "+uri)
+	}
+	if credential.TOTP != nil {
+		lines = append(lines, OTPAuthURI(*credential.TOTP, credential.Name))
+	}
+	description := credential.Description
+	for _, f := range credential.CustomFields {
+		value := fmt.Sprint(f.Value)
+		// Multi-line values can't be a "key: value" line
+		if strings.Contains(value, "\n") {
+			description = strings.TrimSpace(description + "\n\n" + f.Key + ":\n" + value)
+			continue
+		}
+		lines = append(lines, f.Key+": "+value)
+	}
+	if description != "" {
+		// Description lines parsePassEntry would read as fields or TOTP are escaped
+		for _, line := range strings.Split(description, "\n") {
+			if passSpecialLine(line) {
+				line = `\` + line
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// passSpecialLine reports whether parsePassEntry reads line as something else than a description line
+func passSpecialLine(line string) bool {
+	if strings.HasPrefix(line, `\`) {
+		return passSpecialLine(line[1:])
+	}
+	return strings.HasPrefix(line, "otpauth://") || passFieldLine.MatchString(line)
+}
+
+// passPath is the relative directory of a folder path
+func passPath(folderPath []string) string {
+	names := []string{}
+	for _, name := range folderPath {
+		names = append(names, passName(name))
+	}
+	return filepath.Join(names...)
+}
+
+// passName makes a resource or folder name usable as file name, pass hides names starting with a dot
+func passName(name string) string {
+	name = strings.TrimLeft(strings.ReplaceAll(name, "/", "-"), ".")
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+func writeNewFile(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+)
+
+func TestImportExportPasswordStore(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	folderID, err := CreateFolder(ctx, client, "", "pass import")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+
+	report, err := ImportPasswordStore(ctx, client, os.DirFS("testdata/password-store"), readPasswordStoreKey(t), ImportOptions{FolderParentID: folderID})
+	if report != nil {
+		defer cleanupImport(ctx, report)
+	}
+	if err != nil {
+		t.Fatalf("Importing password store %v", err)
+	}
+	if report.Count(ImportActionCreate) != 3 || len(report.Folders) != 2 || len(report.Warnings) != 2 {
+		t.Fatalf("got %+v, want 3 Resources, 2 Folders and 2 warnings", report)
+	}
+
+	_, name, username, uri, password, _, err := GetResource(ctx, client, report.Results[0].ResourceID)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if name != "Mail" || username != "alice@example.com" || uri != "https://mail.example.com" || password != "m4il-p@ss" {
+		t.Fatalf("got %q %q %q %q", name, username, uri, password)
+	}
+
+	key, err := crypto.PGP().KeyGeneration().AddUserId("pass", "pass@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("Generating key %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "store")
+	err = ExportPasswordStore(ctx, client, dir, []*crypto.Key{key})
+	if err != nil {
+		t.Fatalf("Exporting password store %v", err)
+	}
+	credentials, _, err := ReadPasswordStore(os.DirFS(dir), key)
+	if err != nil {
+		t.Fatalf("Reading export %v", err)
+	}
+	i := slices.IndexFunc(credentials, func(c Credential) bool { return c.Name == "router" })
+	if i == -1 || !slices.Equal(credentials[i].FolderPath, []string{"pass import", "Work", "Servers"}) || credentials[i].Password != "r0uter!" {
+		t.Fatalf("router missing in export: %+v", credentials)
+	}
+}
@@ -0,0 +1,169 @@
+package helper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v3/crypto"
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// The fixture store was created with gpg, Other/secret is encrypted to a different key
+func readPasswordStoreKey(t *testing.T) *crypto.Key {
+	t.Helper()
+	armored, err := os.ReadFile("testdata/password-store.key")
+	if err != nil {
+		t.Fatalf("reading key: %v", err)
+	}
+	key, err := api.GetPrivateKeyFromArmor(string(armored), nil)
+	if err != nil {
+		t.Fatalf("parsing key: %v", err)
+	}
+	return key
+}
+
+func TestReadPasswordStore(t *testing.T) {
+	credentials, warnings, err := ReadPasswordStore(os.DirFS("testdata/password-store"), readPasswordStoreKey(t))
+	if err != nil {
+		t.Fatalf("ReadPasswordStore: %v", err)
+	}
+
+	want := []Credential{
+		{
+			FolderPath:  []string{"Work"},
+			Name:        "Mail",
+			Username:    "alice@example.com",
+			Password:    "m4il-p@ss",
+			URIs:        []string{"https://mail.example.com", "https://webmail.example.com"},
+			Description: "Shared mailbox,\nsee https://wiki.example.com",
+			TOTP:        &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DPEHPK3PXP", Digits: 6, Period: 30},
+			CustomFields: CustomFields{
+				{Key: "Recovery email", Type: CustomFieldTypeText, Value: "backup@example.com"},
+			},
+		},
+		{
+			FolderPath:   []string{"Work", "Servers"},
+			Name:         "router",
+			Username:     "admin",
+			Password:     "r0uter!",
+			URIs:         []string{},
+			CustomFields: CustomFields{{Key: "Port", Type: CustomFieldTypeText, Value: "22"}},
+		},
+		{
+			FolderPath:   []string{},
+			Name:         "top",
+			Password:     "just-a-password",
+			URIs:         []string{},
+			CustomFields: CustomFields{},
+		},
+	}
+	if !reflect.DeepEqual(credentials, want) {
+		t.Errorf("got %+v\nwant %+v", credentials, want)
+	}
+	if len(warnings) != 1 || warnings[0].Name != "Other/secret" {
+		t.Errorf("got warnings %+v", warnings)
+	}
+}
+
+func TestParsePassEntry(t *testing.T) {
+	credential := parsePassEntry("pw\nLogin: bob\nusername: second\nhttps://example.com\nNotes:\nline\notpauth://hotp/x?secret=AB\n")
+	if credential.Username != "bob" {
+		t.Errorf("username = %q", credential.Username)
+	}
+	// Only the first username line is the username, HOTP URIs are kept in the description
+	if len(credential.CustomFields) != 1 || credential.CustomFields[0].Key != "username" {
+		t.Errorf("custom fields = %+v", credential.CustomFields)
+	}
+	if credential.Description != "https://example.com\nNotes:\nline\notpauth://hotp/x?secret=AB" || credential.TOTP != nil {
+		t.Errorf("description = %q", credential.Description)
+	}
+
+	if credential := parsePassEntry(""); credential.Password != "" || credential.Description != "" {
+		t.Errorf("got %+v", credential)
+	}
+}
+
+func TestWritePasswordStoreRoundTrip(t *testing.T) {
+	key, err := crypto.PGP().KeyGeneration().AddUserId("pass", "pass@example.com").New().GenerateKey()
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	credentials := []Credential{
+		{
+			FolderPath:  []string{"Infra/Prod", ".hidden"},
+			Name:        "db",
+			Username:    "postgres",
+			Password:    "pw1",
+			URIs:        []string{"postgres://db"},
+			Description: "primary\nNote: not a field\nlogin: nobody\n\\url: escaped\notpauth://totp/x?secret=AB",
+			TOTP:        &api.SecretDataTOTP{Algorithm: "SHA256", SecretKey: "JBSWY3DP", Digits: 8, Period: 30},
+			CustomFields: CustomFields{
+				{Key: "Port", Type: CustomFieldTypeNumber, Value: float64(5432)},
+				{Key: "Cert", Type: CustomFieldTypePassword, Value: "-----BEGIN-----\nabc"},
+			},
+		},
+		{FolderPath: []string{"Infra/Prod", ".hidden"}, Name: "db", Password: "pw2"},
+		{Name: "", Password: "pw3"},
+	}
+	folders := [][]string{{"Empty"}}
+
+	dir := filepath.Join(t.TempDir(), "store")
+	err = WritePasswordStore(dir, credentials, folders, []*crypto.Key{key})
+	if err != nil {
+		t.Fatalf("WritePasswordStore: %v", err)
+	}
+	gpgID, err := os.ReadFile(filepath.Join(dir, ".gpg-id"))
+	if err != nil || strings.TrimSpace(string(gpgID)) != strings.ToUpper(key.GetFingerprint()) {
+		t.Errorf("got .gpg-id %q, %v", gpgID, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "Empty")); err != nil || !info.IsDir() {
+		t.Errorf("empty folder missing: %v", err)
+	}
+
+	read, warnings, err := ReadPasswordStore(os.DirFS(dir), key)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("ReadPasswordStore: %v %+v", err, warnings)
+	}
+	// The key is still usable after reading
+	if _, _, err := ReadPasswordStore(os.DirFS(dir), key); err != nil {
+		t.Fatalf("ReadPasswordStore again: %v", err)
+	}
+	if len(read) != 3 {
+		t.Fatalf("got %+v", read)
+	}
+	// Entries are read in lexical order
+	db2, db, untitled := read[0], read[1], read[2]
+	if !reflect.DeepEqual(db.FolderPath, []string{"Infra-Prod", "hidden"}) || db.Name != "db" || db2.Name != "db (2)" || untitled.Name != "Untitled" {
+		t.Errorf("got paths %v/%v, %v, %v", db.FolderPath, db.Name, db2.Name, untitled.Name)
+	}
+	if db.Username != "postgres" || db.Password != "pw1" || !reflect.DeepEqual(db.URIs, credentials[0].URIs) || *db.TOTP != *credentials[0].TOTP {
+		t.Errorf("got %+v", db)
+	}
+	// Multi-line custom fields are written to the description, description lines that look like fields stay in it
+	wantFields := CustomFields{{Key: "Port", Type: CustomFieldTypeText, Value: "5432"}}
+	if !reflect.DeepEqual(db.CustomFields, wantFields) || db.Description != credentials[0].Description+"\n\nCert:\n-----BEGIN-----\nabc" {
+		t.Errorf("got custom fields %+v and description %q", db.CustomFields, db.Description)
+	}
+
+	// Existing entries are not overwritten, the new ones get a suffix
+	err = WritePasswordStore(dir, []Credential{{Name: "", Password: "pw4"}, {Name: "", Password: "pw5"}}, nil, []*crypto.Key{key})
+	if err != nil {
+		t.Fatalf("WritePasswordStore again: %v", err)
+	}
+	read, _, err = ReadPasswordStore(os.DirFS(dir), key)
+	if err != nil || len(read) != 5 {
+		t.Fatalf("got %+v, %v", read, err)
+	}
+	if second, third, untitled := read[2], read[3], read[4]; untitled.Password != "pw3" || second.Name != "Untitled (2)" || second.Password != "pw4" || third.Name != "Untitled (3)" || third.Password != "pw5" {
+		t.Errorf("got %+v", read[2:])
+	}
+
+	err = WritePasswordStore(dir, credentials, nil, nil)
+	if !errors.Is(err, ErrPasswordStoreNoRecipients) {
+		t.Errorf("got %v, want ErrPasswordStoreNoRecipients", err)
+	}
+}
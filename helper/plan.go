@@ -0,0 +1,554 @@
+package helper
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// PlanAction is what a PlanChange does
+type PlanAction string
+
+const (
+	PlanActionCreate PlanAction = "create"
+	PlanActionUpdate PlanAction = "update"
+	PlanActionMove   PlanAction = "move"
+	PlanActionShare  PlanAction = "share"
+	PlanActionDelete PlanAction = "delete"
+)
+
+// PlanChange is one operation of a Plan
+type PlanChange struct {
+	Action PlanAction `json:"action"`
+	// Kind is "folder" or "resource"
+	Kind string `json:"kind"`
+	// Path is the "/" separated path of the Folder or Resource
+	Path string `json:"path"`
+	// ID is the existing Folder or Resource, empty for the ones the plan creates
+	ID string `json:"id,omitempty"`
+	// Details describe what changes, secrets are only reported as changed
+	Details []string `json:"details,omitempty"`
+	// Destructive changes delete Resources or remove or reduce access
+	Destructive bool `json:"destructive,omitempty"`
+
+	// folder is the parent Folder path of created and moved Resources
+	folder         string
+	credential     Credential
+	metadataFields map[string]any
+	secretFields   map[string]any
+	shares         []ShareOperation
+}
+
+// Plan are the changes that make the server match a VaultSpec, in the order ApplyPlan executes them
+type Plan struct {
+	Changes []PlanChange `json:"changes"`
+
+	// folderIDs are the existing Folders by path
+	folderIDs map[string]string
+}
+
+// ApplyOptions configure ApplyPlan
+type ApplyOptions struct {
+	// AllowDestructive has to be set to apply a plan with destructive changes
+	AllowDestructive bool
+}
+
+// specLiveState is what the plan of a spec is computed against
+type specLiveState struct {
+	me       string
+	userIDs  map[string]string
+	groupIDs map[string]string
+	// principals are the display names of Users and Groups by ARO and ID
+	principals map[string]string
+	folders    map[string]specLiveFolder
+	// resources are the Resources in Folders the spec references
+	resources []specLiveResource
+}
+
+type specLiveFolder struct {
+	ID          string
+	Permissions []api.Permission
+}
+
+type specLiveResource struct {
+	ID          string
+	Folder      string
+	V5          bool
+	Credential  Credential
+	Permissions []api.Permission
+}
+
+// PlanVaultSpec compares the spec with the server. Resources are matched by Folder path and name,
+// or by name and username anywhere in the Folders the spec references, which makes them move.
+// Resources in the spec's Folders that are not in the spec are deleted, Folders are never deleted
+func PlanVaultSpec(ctx context.Context, c *api.Client, spec *VaultSpec) (*Plan, error) {
+	passwords := map[string]string{}
+	for _, resource := range spec.Resources {
+		if resource.Password == nil {
+			continue
+		}
+		password, err := resource.Password.resolve(spec.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("resource %q: %w", specResourcePath(resource), err)
+		}
+		passwords[specResourcePath(resource)] = password
+	}
+
+	live, err := getSpecLiveState(ctx, c, specFolderPaths(spec))
+	if err != nil {
+		return nil, err
+	}
+	return live.plan(spec, passwords)
+}
+
+func getSpecLiveState(ctx context.Context, c *api.Client, scope map[string]bool) (*specLiveState, error) {
+	users, err := c.GetUsers(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Users: %w", err)
+	}
+	groups, err := c.GetGroups(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Groups: %w", err)
+	}
+	live := &specLiveState{
+		me:         c.GetUserID(),
+		userIDs:    map[string]string{},
+		groupIDs:   map[string]string{},
+		principals: map[string]string{},
+		folders:    map[string]specLiveFolder{},
+		resources:  []specLiveResource{},
+	}
+	for _, u := range users {
+		live.userIDs[u.Username] = u.ID
+		live.principals["User:"+u.ID] = "user " + u.Username
+	}
+	for _, g := range groups {
+		live.groupIDs[g.Name] = g.ID
+		live.principals["Group:"+g.ID] = "group " + g.Name
+	}
+
+	folders, err := c.GetFolders(ctx, &api.GetFoldersOptions{
+		ContainPermissions: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Folders: %w", err)
+	}
+	tree := &folderTree{children: map[string]map[string]string{}, names: map[string]string{}, parents: map[string]string{}}
+	for _, folder := range folders {
+		parentID, name, err := GetFolderFromData(ctx, c, folder)
+		if err != nil {
+			return nil, fmt.Errorf("folder %v: %w", folder.ID, err)
+		}
+		tree.add(folder.ID, parentID, name)
+	}
+	for _, folder := range folders {
+		// Of several Folders with the same path only the first is used
+		p := strings.Join(tree.path(folder.ID), "/")
+		if _, ok := live.folders[p]; !ok {
+			live.folders[p] = specLiveFolder{ID: folder.ID, Permissions: folder.Permissions}
+		}
+	}
+
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		ContainSecret:                 true,
+		ContainPermissions:            true,
+		ContainPermissionsUserProfile: true,
+		ContainPermissionsGroup:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+	for _, resource := range resources {
+		folder := strings.Join(tree.path(resource.FolderParentID), "/")
+		if !scope[folder] {
+			continue
+		}
+		rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.ID == resource.ResourceTypeID }, ErrResourceTypeSlugNotFound, resource.ResourceTypeID)
+		if err != nil {
+			return nil, fmt.Errorf("resource %v: %w", resource.ID, err)
+		}
+		var secret api.Secret
+		if len(resource.Secrets) > 0 {
+			secret = resource.Secrets[0]
+		}
+		metadataFields, secretFields, err := resourceFieldMaps(c, resource, secret, *rType, true)
+		if err != nil {
+			return nil, fmt.Errorf("resource %v: %w", resource.ID, err)
+		}
+		credential, err := credentialFromFields(metadataFields, secretFields)
+		if err != nil {
+			return nil, fmt.Errorf("resource %v: %w", resource.ID, err)
+		}
+		live.resources = append(live.resources, specLiveResource{
+			ID:          resource.ID,
+			Folder:      folder,
+			V5:          resource.Metadata != "",
+			Credential:  *credential,
+			Permissions: resource.Permissions,
+		})
+	}
+	return live, nil
+}
+
+// specFolderPaths are the Folder paths a spec references, including the root if it has Resources there
+func specFolderPaths(spec *VaultSpec) map[string]bool {
+	paths := map[string]bool{}
+	for _, folder := range spec.Folders {
+		paths[strings.Join(splitFolderPath(folder.Path, "/"), "/")] = true
+	}
+	for _, resource := range spec.Resources {
+		paths[strings.Join(splitFolderPath(resource.Folder, "/"), "/")] = true
+	}
+	return paths
+}
+
+// plan computes the changes, passwords are the resolved secrets by resource path
+func (live *specLiveState) plan(spec *VaultSpec, passwords map[string]string) (*Plan, error) {
+	plan := &Plan{Changes: []PlanChange{}, folderIDs: map[string]string{}}
+	for p, folder := range live.folders {
+		plan.folderIDs[p] = folder.ID
+	}
+
+	// Folders, parents first
+	needed := map[string]bool{}
+	for p := range specFolderPaths(spec) {
+		names := splitFolderPath(p, "/")
+		for i := range names {
+			needed[strings.Join(names[:i+1], "/")] = true
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(needed)) {
+		if _, ok := live.folders[p]; !ok {
+			plan.Changes = append(plan.Changes, PlanChange{Action: PlanActionCreate, Kind: "folder", Path: p})
+		}
+	}
+	for _, folder := range spec.Folders {
+		if folder.Permissions == nil {
+			continue
+		}
+		p := strings.Join(splitFolderPath(folder.Path, "/"), "/")
+		existing := live.folders[p]
+		change, err := live.shareChange("folder", p, existing.ID, folder.Permissions, existing.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			plan.Changes = append(plan.Changes, *change)
+		}
+	}
+
+	// Resources are matched by path first, so moves only pick up what is left
+	matched := make([]int, len(spec.Resources))
+	taken := map[int]bool{}
+	for i, resource := range spec.Resources {
+		folder := strings.Join(splitFolderPath(resource.Folder, "/"), "/")
+		matched[i] = slices.IndexFunc(live.resources, func(r specLiveResource) bool {
+			return r.Folder == folder && r.Credential.Name == resource.Name
+		})
+		if matched[i] != -1 {
+			taken[matched[i]] = true
+		}
+	}
+	for i, resource := range spec.Resources {
+		if matched[i] != -1 {
+			continue
+		}
+		candidates := []int{}
+		for j, r := range live.resources {
+			if !taken[j] && r.Credential.Name == resource.Name && (resource.Username == "" || r.Credential.Username == resource.Username) {
+				candidates = append(candidates, j)
+			}
+		}
+		if len(candidates) == 1 {
+			matched[i] = candidates[0]
+			taken[candidates[0]] = true
+		}
+	}
+
+	creates, updates, moves, shares := []PlanChange{}, []PlanChange{}, []PlanChange{}, []PlanChange{}
+	for i, resource := range spec.Resources {
+		p := specResourcePath(resource)
+		folder := strings.Join(splitFolderPath(resource.Folder, "/"), "/")
+		password, hasPassword := passwords[p]
+
+		var existing specLiveResource
+		if matched[i] == -1 {
+			if !hasPassword {
+				return nil, fmt.Errorf("%w: resource %q: a password is needed to create it", ErrSpecInvalid, p)
+			}
+			creates = append(creates, PlanChange{
+				Action: PlanActionCreate,
+				Kind:   "resource",
+				Path:   p,
+				folder: folder,
+				credential: Credential{
+					Name:        resource.Name,
+					Username:    resource.Username,
+					URIs:        resource.URIs,
+					Password:    password,
+					Description: resource.Description,
+				},
+			})
+		} else {
+			existing = live.resources[matched[i]]
+			if update := specResourceUpdate(resource, existing, password, hasPassword); update != nil {
+				update.Path = p
+				updates = append(updates, *update)
+			}
+			if existing.Folder != folder {
+				moves = append(moves, PlanChange{
+					Action:  PlanActionMove,
+					Kind:    "resource",
+					Path:    p,
+					ID:      existing.ID,
+					Details: []string{fmt.Sprintf("from %q", "/"+existing.Folder)},
+					folder:  folder,
+				})
+			}
+		}
+
+		if resource.Permissions != nil {
+			change, err := live.shareChange("resource", p, existing.ID, resource.Permissions, existing.Permissions)
+			if err != nil {
+				return nil, err
+			}
+			if change != nil {
+				shares = append(shares, *change)
+			}
+		}
+	}
+
+	deletes := []PlanChange{}
+	managed := map[string]bool{}
+	for _, folder := range spec.Folders {
+		managed[strings.Join(splitFolderPath(folder.Path, "/"), "/")] = true
+	}
+	for j, r := range live.resources {
+		if !taken[j] && managed[r.Folder] {
+			deletes = append(deletes, PlanChange{
+				Action:      PlanActionDelete,
+				Kind:        "resource",
+				Path:        strings.Join(append(splitFolderPath(r.Folder, "/"), r.Credential.Name), "/"),
+				ID:          r.ID,
+				Destructive: true,
+			})
+		}
+	}
+
+	plan.Changes = slices.Concat(plan.Changes, creates, updates, moves, shares, deletes)
+	return plan, nil
+}
+
+// specResourceUpdate compares the managed fields of a resource, nil if nothing changed
+func specResourceUpdate(resource SpecResource, existing specLiveResource, password string, hasPassword bool) *PlanChange {
+	change := &PlanChange{
+		Action:         PlanActionUpdate,
+		Kind:           "resource",
+		ID:             existing.ID,
+		Details:        []string{},
+		metadataFields: map[string]any{},
+		secretFields:   map[string]any{},
+	}
+	current := existing.Credential
+	if resource.Username != "" && resource.Username != current.Username {
+		change.Details = append(change.Details, fmt.Sprintf("username: %q -> %q", current.Username, resource.Username))
+		change.metadataFields["username"] = resource.Username
+	}
+	if len(resource.URIs) > 0 && !slices.Equal(resource.URIs, current.URIs) {
+		change.Details = append(change.Details, fmt.Sprintf("uris: %q -> %q", current.URIs, resource.URIs))
+		if existing.V5 {
+			change.metadataFields["uris"] = resource.URIs
+		} else {
+			change.metadataFields["uri"] = resource.URIs[0]
+		}
+	}
+	// Descriptions can hold sensitive notes, so they are not printed
+	if resource.Description != "" && resource.Description != current.Description {
+		change.Details = append(change.Details, "description changed")
+		change.secretFields["description"] = resource.Description
+	}
+	if hasPassword && password != current.Password {
+		change.Details = append(change.Details, "password changed")
+		change.secretFields["password"] = password
+	}
+	if len(change.Details) == 0 {
+		return nil
+	}
+	return change
+}
+
+// shareChange compares the declared with the current permissions, nil if they match.
+// The applying User's own permission is left alone so it can't lock itself out
+func (live *specLiveState) shareChange(kind, p, id string, declared []SpecPermission, current []api.Permission) (*PlanChange, error) {
+	change := &PlanChange{Action: PlanActionShare, Kind: kind, Path: p, ID: id, Details: []string{}, shares: []ShareOperation{}}
+
+	want := map[string]int{}
+	for _, permission := range declared {
+		aro, aroID, ok := "User", live.userIDs[permission.User], false
+		if permission.User != "" {
+			_, ok = live.userIDs[permission.User]
+		} else {
+			aro, aroID = "Group", live.groupIDs[permission.Group]
+			_, ok = live.groupIDs[permission.Group]
+		}
+		if !ok {
+			return nil, fmt.Errorf("%v %q: %w: %v%v", kind, p, ErrSpecUnknownPrincipal, permission.User, permission.Group)
+		}
+		if aro == "User" && aroID == live.me {
+			continue
+		}
+		want[aro+":"+aroID] = specPermissionType(permission.Permission)
+	}
+	have := map[string]int{}
+	for _, permission := range current {
+		if permission.ARO == "User" && permission.AROForeignKey == live.me {
+			continue
+		}
+		have[permission.ARO+":"+permission.AROForeignKey] = permission.Type
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(want)) {
+		aro, aroID, _ := strings.Cut(key, ":")
+		switch old, ok := have[key]; {
+		case !ok:
+			change.Details = append(change.Details, fmt.Sprintf("+ %v: %v", live.principals[key], permissionTypeName(want[key])))
+		case old != want[key]:
+			change.Details = append(change.Details, fmt.Sprintf("~ %v: %v -> %v", live.principals[key], permissionTypeName(old), permissionTypeName(want[key])))
+			change.Destructive = change.Destructive || want[key] < old
+		default:
+			continue
+		}
+		change.shares = append(change.shares, ShareOperation{Type: want[key], ARO: aro, AROID: aroID})
+	}
+	for _, key := range slices.Sorted(maps.Keys(have)) {
+		if _, ok := want[key]; ok {
+			continue
+		}
+		aro, aroID, _ := strings.Cut(key, ":")
+		name, ok := live.principals[key]
+		if !ok {
+			name = strings.ToLower(aro) + " " + aroID
+		}
+		change.Details = append(change.Details, fmt.Sprintf("- %v: %v", name, permissionTypeName(have[key])))
+		change.Destructive = true
+		change.shares = append(change.shares, ShareOperation{Type: -1, ARO: aro, AROID: aroID})
+	}
+
+	if len(change.shares) == 0 {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// Destructive reports whether the plan deletes Resources or removes or reduces access
+func (p *Plan) Destructive() bool {
+	return slices.ContainsFunc(p.Changes, func(c PlanChange) bool { return c.Destructive })
+}
+
+// Empty reports whether the server already matches the spec
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// WriteText writes the plan in a human readable form, one change per line followed by its details
+func (p *Plan) WriteText(w io.Writer) error {
+	symbols := map[PlanAction]string{
+		PlanActionCreate: "+",
+		PlanActionUpdate: "~",
+		PlanActionMove:   ">",
+		PlanActionShare:  "~",
+		PlanActionDelete: "-",
+	}
+	counts := map[PlanAction]int{}
+	for _, change := range p.Changes {
+		counts[change.Action]++
+		line := fmt.Sprintf("%v %v %v %q", symbols[change.Action], change.Action, change.Kind, "/"+change.Path)
+		if change.Destructive {
+			line += " (destructive)"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		for _, detail := range change.Details {
+			if _, err := fmt.Fprintln(w, "    "+detail); err != nil {
+				return err
+			}
+		}
+	}
+
+	summary := []string{}
+	for _, action := range []PlanAction{PlanActionCreate, PlanActionUpdate, PlanActionMove, PlanActionShare, PlanActionDelete} {
+		summary = append(summary, fmt.Sprintf("%v to %v", counts[action], action))
+	}
+	_, err := fmt.Fprintf(w, "Plan: %v\n", strings.Join(summary, ", "))
+	return err
+}
+
+// ApplyPlan executes the changes of a plan in order. It stops at the first change that fails,
+// the changes before it stay applied. A plan with destructive changes needs opts.AllowDestructive
+func ApplyPlan(ctx context.Context, c *api.Client, plan *Plan, opts ApplyOptions) error {
+	if plan.Destructive() && !opts.AllowDestructive {
+		return ErrPlanDestructive
+	}
+
+	folderIDs := maps.Clone(plan.folderIDs)
+	resourceIDs := map[string]string{}
+	var rTypes []api.ResourceType
+	for _, change := range plan.Changes {
+		var err error
+		switch {
+		case change.Kind == "folder" && change.Action == PlanActionCreate:
+			var id string
+			id, err = CreateFolder(ctx, c, folderIDs[specParentPath(change.Path)], path.Base(change.Path))
+			folderIDs[change.Path] = id
+		case change.Kind == "folder" && change.Action == PlanActionShare:
+			err = ShareFolder(ctx, c, cmp.Or(change.ID, folderIDs[change.Path]), change.shares)
+		case change.Action == PlanActionCreate:
+			if rTypes == nil {
+				rTypes, err = c.GetResourceTypesCached(ctx)
+				if err != nil {
+					return fmt.Errorf("getting Resource Types: %w", err)
+				}
+			}
+			var slug string
+			var metadataFields, secretFields map[string]any
+			v5 := c.MetadataTypeSettings().DefaultResourceType == api.PassboltAPIVersionTypeV5
+			// Specs have no custom fields, so there is nothing to warn about
+			slug, metadataFields, secretFields, _, err = credentialFields(change.credential, v5, rTypes, false)
+			if err == nil {
+				var id string
+				id, err = CreateResourceGeneric(ctx, c, slug, folderIDs[change.folder], metadataFields, secretFields)
+				resourceIDs[change.Path] = id
+			}
+		case change.Action == PlanActionUpdate:
+			err = UpdateResourceGeneric(ctx, c, change.ID, maps.Clone(change.metadataFields), maps.Clone(change.secretFields))
+		case change.Action == PlanActionMove:
+			err = MoveResource(ctx, c, change.ID, folderIDs[change.folder])
+		case change.Action == PlanActionShare:
+			err = ShareResource(ctx, c, cmp.Or(change.ID, resourceIDs[change.Path]), change.shares)
+		case change.Action == PlanActionDelete:
+			err = DeleteResource(ctx, c, change.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("%v %v %q: %w", change.Action, change.Kind, change.Path, err)
+		}
+	}
+	return nil
+}
+
+// specParentPath is the path of the parent Folder, empty for the root
+func specParentPath(p string) string {
+	names := strings.Split(p, "/")
+	return strings.Join(names[:len(names)-1], "/")
+}
@@ -0,0 +1,169 @@
+//go:build integration
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestPlanApplyVaultSpec(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	t.Setenv("TEST_SPEC_PASSWORD", "spec-secret")
+	spec, err := ReadVaultSpec(strings.NewReader(`
+folders:
+  - path: spec/managed
+resources:
+  - name: spec-db
+    folder: spec/managed
+    username: spec-user
+    uris: [https://spec.example.com]
+    password: {env: TEST_SPEC_PASSWORD}
+`))
+	if err != nil {
+		t.Fatalf("Reading spec %v", err)
+	}
+
+	plan, err := PlanVaultSpec(ctx, client, spec)
+	if err != nil {
+		t.Fatalf("Planning %v", err)
+	}
+	if len(plan.Changes) != 3 || plan.Destructive() {
+		t.Fatalf("got %+v", plan.Changes)
+	}
+	err = ApplyPlan(ctx, client, plan, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("Applying %v", err)
+	}
+	parentID, err := EnsureFolderPath(ctx, client, "", "spec")
+	if err != nil {
+		t.Fatalf("Getting Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, parentID) }()
+	folderID, err := EnsureFolderPath(ctx, client, parentID, "managed")
+	if err != nil {
+		t.Fatalf("Getting Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+
+	// Applying again has nothing left to do
+	plan, err = PlanVaultSpec(ctx, client, spec)
+	if err != nil {
+		t.Fatalf("Planning %v", err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("got %+v", plan.Changes)
+	}
+
+	// A changed secret is an update, a Resource that is not in the spec is deleted
+	extraID, err := CreateResource(ctx, client, folderID, "spec-extra", "", "", "extra-secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, extraID) }()
+	t.Setenv("TEST_SPEC_PASSWORD", "rotated-secret")
+	plan, err = PlanVaultSpec(ctx, client, spec)
+	if err != nil {
+		t.Fatalf("Planning %v", err)
+	}
+	var buf bytes.Buffer
+	err = plan.WriteText(&buf)
+	if err != nil {
+		t.Fatalf("Writing plan %v", err)
+	}
+	if strings.Contains(buf.String(), "rotated-secret") || !strings.Contains(buf.String(), "password changed") {
+		t.Fatalf("got plan:\n%v", buf.String())
+	}
+	err = ApplyPlan(ctx, client, plan, ApplyOptions{})
+	if !errors.Is(err, ErrPlanDestructive) {
+		t.Fatalf("got %v, want ErrPlanDestructive", err)
+	}
+	err = ApplyPlan(ctx, client, plan, ApplyOptions{AllowDestructive: true})
+	if err != nil {
+		t.Fatalf("Applying %v", err)
+	}
+
+	resources, err := client.GetResources(ctx, &api.GetResourcesOptions{FilterHasParent: []string{folderID}})
+	if err != nil {
+		t.Fatalf("Getting Resources %v", err)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("got %v Resources, want 1", len(resources))
+	}
+	defer func() { _ = DeleteResource(ctx, client, resources[0].ID) }()
+	_, name, username, _, password, _, err := GetResource(ctx, client, resources[0].ID)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if name != "spec-db" || username != "spec-user" || password != "rotated-secret" {
+		t.Fatalf("got %q %q %q", name, username, password)
+	}
+}
+
+func TestPlanApplyVaultSpecShares(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	groupID, err := CreateGroup(ctx, client, "spec-readers", []GroupMembershipOperation{
+		{UserID: client.GetUserID(), IsGroupManager: true},
+	})
+	if err != nil {
+		t.Fatalf("Creating Group %v", err)
+	}
+	defer func() { _ = DeleteGroup(ctx, client, groupID) }()
+
+	spec, err := ReadVaultSpec(strings.NewReader(`
+folders:
+  - path: spec-shares
+resources:
+  - name: spec-shared
+    folder: spec-shares
+    password: {value: shared-secret}
+    permissions:
+      - group: spec-readers
+        permission: read
+`))
+	if err != nil {
+		t.Fatalf("Reading spec %v", err)
+	}
+	plan, err := PlanVaultSpec(ctx, client, spec)
+	if err != nil {
+		t.Fatalf("Planning %v", err)
+	}
+	err = ApplyPlan(ctx, client, plan, ApplyOptions{})
+	if err != nil {
+		t.Fatalf("Applying %v", err)
+	}
+	folderID, err := EnsureFolderPath(ctx, client, "", "spec-shares")
+	if err != nil {
+		t.Fatalf("Getting Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+	resources, err := client.GetResources(ctx, &api.GetResourcesOptions{FilterHasParent: []string{folderID}})
+	if err != nil {
+		t.Fatalf("Getting Resources %v", err)
+	}
+	for _, r := range resources {
+		defer func() { _ = DeleteResource(ctx, client, r.ID) }()
+	}
+
+	// The share is read back, so it is neither granted again nor revoked
+	plan, err = PlanVaultSpec(ctx, client, spec)
+	if err != nil {
+		t.Fatalf("Planning %v", err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("got %+v", plan.Changes)
+	}
+}
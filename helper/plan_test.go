@@ -0,0 +1,150 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func testSpecLiveState() *specLiveState {
+	return &specLiveState{
+		me:       "u0",
+		userIDs:  map[string]string{"me@example.com": "u0", "alice@example.com": "u1", "bob@example.com": "u2"},
+		groupIDs: map[string]string{"Ops": "g1"},
+		principals: map[string]string{
+			"User:u0":  "user me@example.com",
+			"User:u1":  "user alice@example.com",
+			"User:u2":  "user bob@example.com",
+			"Group:g1": "group Ops",
+		},
+		folders: map[string]specLiveFolder{
+			"Ops": {ID: "f1", Permissions: []api.Permission{
+				{ARO: "User", AROForeignKey: "u0", Type: 15},
+				{ARO: "User", AROForeignKey: "u2", Type: 15},
+			}},
+		},
+		resources: []specLiveResource{
+			{ID: "r1", Folder: "Ops", V5: true, Credential: Credential{Name: "db", Username: "admin", URIs: []string{"https://db"}, Password: "old-secret"}},
+			{ID: "r2", Folder: "Ops", V5: true, Credential: Credential{Name: "mail", Username: "mailer", Password: "mail-secret"}},
+			{ID: "r3", Folder: "Ops", V5: true, Credential: Credential{Name: "stale", Password: "stale-secret"}},
+			{ID: "r4", Folder: "Ops", V5: false, Credential: Credential{Name: "web", URIs: []string{"https://web"}, Password: "web-secret"},
+				Permissions: []api.Permission{{ARO: "User", AROForeignKey: "u0", Type: 15}, {ARO: "User", AROForeignKey: "u1", Type: 1}}},
+		},
+	}
+}
+
+var testPlanSpec = &VaultSpec{
+	Folders: []SpecFolder{{Path: "Ops", Permissions: []SpecPermission{
+		{User: "me@example.com", Permission: "owner"},
+		{Group: "Ops", Permission: "update"},
+	}}},
+	Resources: []SpecResource{
+		{Name: "db", Folder: "Ops", Username: "admin", Password: &SpecSecret{Value: "x"}},
+		{Name: "mail", Folder: "Ops/Mail", Username: "mailer"},
+		{Name: "web", Folder: "Ops", URIs: []string{"https://web.example.com"}, Permissions: []SpecPermission{{User: "alice@example.com", Permission: "owner"}}},
+		{Name: "new", Folder: "Ops/New", Password: &SpecSecret{Value: "x"}},
+	},
+}
+
+func TestPlanVaultSpec(t *testing.T) {
+	passwords := map[string]string{"Ops/db": "new-secret", "Ops/New/new": "created-secret"}
+	plan, err := testSpecLiveState().plan(testPlanSpec, passwords)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+
+	type change struct {
+		Action      PlanAction
+		Path        string
+		ID          string
+		Details     []string
+		Destructive bool
+	}
+	got := []change{}
+	for _, c := range plan.Changes {
+		got = append(got, change{c.Action, c.Path, c.ID, c.Details, c.Destructive})
+	}
+	want := []change{
+		{PlanActionCreate, "Ops/Mail", "", nil, false},
+		{PlanActionCreate, "Ops/New", "", nil, false},
+		{PlanActionShare, "Ops", "f1", []string{"+ group Ops: update", "- user bob@example.com: owner"}, true},
+		{PlanActionCreate, "Ops/New/new", "", nil, false},
+		{PlanActionUpdate, "Ops/db", "r1", []string{"password changed"}, false},
+		{PlanActionUpdate, "Ops/web", "r4", []string{`uris: ["https://web"] -> ["https://web.example.com"]`}, false},
+		{PlanActionMove, "Ops/Mail/mail", "r2", []string{`from "/Ops"`}, false},
+		{PlanActionShare, "Ops/web", "r4", []string{"~ user alice@example.com: read -> owner"}, false},
+		{PlanActionDelete, "Ops/stale", "r3", nil, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+	if !plan.Destructive() || plan.Empty() {
+		t.Errorf("got Destructive %v, Empty %v", plan.Destructive(), plan.Empty())
+	}
+
+	// v4 Resources only have a single uri
+	if update := plan.Changes[5]; !reflect.DeepEqual(update.metadataFields, map[string]any{"uri": "https://web.example.com"}) {
+		t.Errorf("got metadata %v", update.metadataFields)
+	}
+	if create := plan.Changes[3]; create.folder != "Ops/New" || create.credential.Password != "created-secret" {
+		t.Errorf("got create %+v", create)
+	}
+
+	var buf bytes.Buffer
+	err = plan.WriteText(&buf)
+	if err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	text := buf.String()
+	for _, secret := range []string{"new-secret", "created-secret", "old-secret"} {
+		if strings.Contains(text, secret) {
+			t.Errorf("plan text contains secret %q", secret)
+		}
+	}
+	for _, line := range []string{
+		`+ create folder "/Ops/Mail"`,
+		`- delete resource "/Ops/stale" (destructive)`,
+		"    password changed",
+		"Plan: 3 to create, 2 to update, 1 to move, 2 to share, 1 to delete",
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("plan text misses %q:\n%v", line, text)
+		}
+	}
+}
+
+func TestPlanVaultSpecNoChanges(t *testing.T) {
+	live := testSpecLiveState()
+	spec := &VaultSpec{Resources: []SpecResource{
+		{Name: "db", Folder: "Ops", Username: "admin", URIs: []string{"https://db"}, Password: &SpecSecret{Value: "x"}},
+	}}
+	plan, err := live.plan(spec, map[string]string{"Ops/db": "old-secret"})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if !plan.Empty() || plan.Destructive() {
+		t.Errorf("got %+v", plan.Changes)
+	}
+}
+
+func TestPlanVaultSpecErrors(t *testing.T) {
+	_, err := testSpecLiveState().plan(&VaultSpec{Resources: []SpecResource{{Name: "missing"}}}, map[string]string{})
+	if !errors.Is(err, ErrSpecInvalid) {
+		t.Errorf("create without password: got %v, want ErrSpecInvalid", err)
+	}
+
+	spec := &VaultSpec{Folders: []SpecFolder{{Path: "Ops", Permissions: []SpecPermission{{User: "nobody@example.com", Permission: "read"}}}}}
+	_, err = testSpecLiveState().plan(spec, map[string]string{})
+	if !errors.Is(err, ErrSpecUnknownPrincipal) {
+		t.Errorf("unknown user: got %v, want ErrSpecUnknownPrincipal", err)
+	}
+
+	err = ApplyPlan(t.Context(), nil, &Plan{Changes: []PlanChange{{Action: PlanActionDelete, Destructive: true}}}, ApplyOptions{})
+	if !errors.Is(err, ErrPlanDestructive) {
+		t.Errorf("apply: got %v, want ErrPlanDestructive", err)
+	}
+}
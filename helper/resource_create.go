@@ -142,11 +142,7 @@ func CreateResourceGeneric(ctx context.Context, c *api.Client, slug string, fold
 	resource.Secrets = []api.Secret{{Data: encSecretData}}
 
 	// Handle password expiry
-	passwordExpirySettings := c.GetPasswordExpirySettings()
-	if passwordExpirySettings.DefaultExpiryPeriod != 0 {
-		expiry := time.Now().Add(time.Hour * 24 * time.Duration(passwordExpirySettings.DefaultExpiryPeriod))
-		resource.Expired = &api.Time{Time: expiry}
-	}
+	resource.Expired = DefaultResourceExpiry(c, time.Now())
 
 	newresource, err := c.CreateResource(ctx, resource)
 	if err != nil {
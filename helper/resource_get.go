@@ -125,6 +125,34 @@ func GetResourceFromDataWithOptions(c *api.Client, resource api.Resource, secret
 // name/username/uri/password/description; those standard fields can be read from the
 // returned maps with GetStringField.
 func GetResourceFieldMaps(c *api.Client, resource api.Resource, secret api.Secret, rType api.ResourceType, decryptSecret bool) (folderParentID string, metadataFields, secretFields map[string]any, err error) {
+	metadataFields, secretFields, err = resourceFieldMaps(c, resource, secret, rType, decryptSecret)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// Normalize maps so CEL filters can use consistent keys regardless of v4/v5.
+	// Always add "uri" to metadata if missing (v5 schema uses "uris" array).
+	if _, ok := metadataFields["uri"]; !ok {
+		uri := ""
+		if uris, ok := metadataFields["uris"].([]any); ok && len(uris) > 0 {
+			if s, ok := uris[0].(string); ok {
+				uri = s
+			}
+		}
+		metadataFields["uri"] = uri
+	}
+	// Always add "description" to metadata if missing or empty: v5 and v4
+	// password-and-description both store description in the encrypted secret,
+	// leaving the metadata-side value blank.
+	if d, _ := metadataFields["description"].(string); d == "" {
+		metadataFields["description"] = GetStringField(secretFields, "description")
+	}
+
+	return resource.FolderParentID, metadataFields, secretFields, nil
+}
+
+// resourceFieldMaps decrypts a resource into its metadata and secret field maps as they are stored, without normalization
+func resourceFieldMaps(c *api.Client, resource api.Resource, secret api.Secret, rType api.ResourceType, decryptSecret bool) (metadataFields, secretFields map[string]any, err error) {
 	ctx := context.TODO()
 
 	// Decrypt secret data if requested
@@ -132,12 +160,12 @@ func GetResourceFieldMaps(c *api.Client, resource api.Resource, secret api.Secre
 	if decryptSecret && secret.Data != "" {
 		rawSecretData, err = c.DecryptSecretWithResourceID(resource.ID, secret.Data)
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("decrypting secret data: %w", err)
+			return nil, nil, fmt.Errorf("decrypting secret data: %w", err)
 		}
 
 		err = validateSecretData(&rType, rawSecretData)
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("validate secret data: %w", err)
+			return nil, nil, fmt.Errorf("validate secret data: %w", err)
 		}
 	}
 
@@ -147,12 +175,12 @@ func GetResourceFieldMaps(c *api.Client, resource api.Resource, secret api.Secre
 	if isV5 {
 		rawMetadata, err := GetResourceMetadata(ctx, c, &resource, &rType)
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("getting metadata: %w", err)
+			return nil, nil, fmt.Errorf("getting metadata: %w", err)
 		}
 
 		metadataFields = make(map[string]any)
 		if err := json.Unmarshal([]byte(rawMetadata), &metadataFields); err != nil {
-			return "", nil, nil, fmt.Errorf("parsing decrypted metadata: %w", err)
+			return nil, nil, fmt.Errorf("parsing decrypted metadata: %w", err)
 		}
 	} else {
 		metadataFields = map[string]any{
@@ -172,30 +200,12 @@ func GetResourceFieldMaps(c *api.Client, resource api.Resource, secret api.Secre
 		} else {
 			secretFields = make(map[string]any)
 			if err := json.Unmarshal([]byte(rawSecretData), &secretFields); err != nil {
-				return "", nil, nil, fmt.Errorf("parsing decrypted secret data: %w", err)
-			}
-		}
-	}
-
-	// Normalize maps so CEL filters can use consistent keys regardless of v4/v5.
-	// Always add "uri" to metadata if missing (v5 schema uses "uris" array).
-	if _, ok := metadataFields["uri"]; !ok {
-		uri := ""
-		if uris, ok := metadataFields["uris"].([]any); ok && len(uris) > 0 {
-			if s, ok := uris[0].(string); ok {
-				uri = s
+				return nil, nil, fmt.Errorf("parsing decrypted secret data: %w", err)
 			}
 		}
-		metadataFields["uri"] = uri
-	}
-	// Always add "description" to metadata if missing or empty: v5 and v4
-	// password-and-description both store description in the encrypted secret,
-	// leaving the metadata-side value blank.
-	if d, _ := metadataFields["description"].(string); d == "" {
-		metadataFields["description"] = GetStringField(secretFields, "description")
 	}
 
-	return resource.FolderParentID, metadataFields, secretFields, nil
+	return metadataFields, secretFields, nil
 }
 
 // GetStringField safely extracts a string from a map. Returns "" if the key is
@@ -211,3 +221,20 @@ func GetStringField(m map[string]any, key string) string {
 	}
 	return s
 }
+
+// resourceNameAndURI decrypts the name and first URI of a resource, falling back to the cleartext v4 fields
+func resourceNameAndURI(c *api.Client, resource api.Resource, rTypes []api.ResourceType) (string, string) {
+	rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.ID == resource.ResourceTypeID }, ErrResourceTypeSlugNotFound, resource.ResourceTypeID)
+	if err != nil {
+		return resource.Name, resource.URI
+	}
+	metadataFields, _, err := resourceFieldMaps(c, resource, api.Secret{}, *rType, false)
+	if err != nil {
+		return resource.Name, resource.URI
+	}
+	uri := ""
+	if uris := resourceURIs(metadataFields); len(uris) > 0 {
+		uri = uris[0]
+	}
+	return GetStringField(metadataFields, "name"), uri
+}
@@ -0,0 +1,289 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// TypedSecret is implemented by the secret struct of each resource type.
+// M is the metadata struct of the same resource type, so passing metadata and secret
+// of different resource types to CreateTyped, GetTyped or UpdateTyped does not compile
+type TypedSecret[M any] interface {
+	// ResourceTypeSlug returns the slug of the resource type this secret belongs to
+	ResourceTypeSlug() string
+	metadataType(M)
+}
+
+// V5Metadata is the metadata of the "v5-default", "v5-default-with-totp" and "v5-password-string" resource types
+type V5Metadata struct {
+	Name     string   `json:"name"`
+	Username string   `json:"username,omitempty"`
+	URIs     []string `json:"uris,omitempty"`
+	// Description is shown as the note in the Passbolt UI. Only "v5-password-string" stores it here,
+	// the other types keep it in the encrypted secret
+	Description string `json:"description,omitempty"`
+}
+
+// V5TOTPMetadata is the metadata of the "v5-totp-standalone" resource type
+type V5TOTPMetadata struct {
+	Name        string   `json:"name"`
+	URIs        []string `json:"uris,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// V5CustomFieldsMetadata is the metadata of the "v5-custom-fields" resource type
+type V5CustomFieldsMetadata struct {
+	Name         string                `json:"name"`
+	URIs         []string              `json:"uris,omitempty"`
+	Description  string                `json:"description,omitempty"`
+	CustomFields []CustomFieldMetadata `json:"custom_fields"`
+}
+
+// V4Metadata is the cleartext metadata of all v4 resource types
+type V4Metadata struct {
+	Name        string `json:"name"`
+	Username    string `json:"username,omitempty"`
+	URI         string `json:"uri,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// CustomFieldType is the type of the value of a custom field
+type CustomFieldType string
+
+const (
+	CustomFieldTypeText     CustomFieldType = "text"
+	CustomFieldTypePassword CustomFieldType = "password"
+	CustomFieldTypeBoolean  CustomFieldType = "boolean"
+	CustomFieldTypeNumber   CustomFieldType = "number"
+	CustomFieldTypeURI      CustomFieldType = "uri"
+)
+
+// CustomFieldMetadata is the metadata half of a custom field, the entry with the same ID in the secret holds the value
+type CustomFieldMetadata struct {
+	ID            string          `json:"id"`
+	Type          CustomFieldType `json:"type"`
+	MetadataKey   string          `json:"metadata_key"`
+	MetadataValue any             `json:"metadata_value,omitempty"`
+}
+
+// CustomFieldSecret is the secret half of a custom field
+type CustomFieldSecret struct {
+	ID          string          `json:"id"`
+	Type        CustomFieldType `json:"type"`
+	SecretKey   string          `json:"secret_key,omitempty"`
+	SecretValue any             `json:"secret_value"`
+}
+
+// V5DefaultSecret is the secret of the "v5-default" resource type
+type V5DefaultSecret struct {
+	Password    string `json:"password"`
+	Description string `json:"description,omitempty"`
+}
+
+func (V5DefaultSecret) ResourceTypeSlug() string { return "v5-default" }
+func (V5DefaultSecret) metadataType(V5Metadata)  {}
+
+// V5DefaultWithTOTPSecret is the secret of the "v5-default-with-totp" resource type
+type V5DefaultWithTOTPSecret struct {
+	Password    string             `json:"password,omitempty"`
+	Description string             `json:"description,omitempty"`
+	TOTP        api.SecretDataTOTP `json:"totp"`
+}
+
+func (V5DefaultWithTOTPSecret) ResourceTypeSlug() string { return "v5-default-with-totp" }
+func (V5DefaultWithTOTPSecret) metadataType(V5Metadata)  {}
+
+// V5TOTPStandaloneSecret is the secret of the "v5-totp-standalone" resource type
+type V5TOTPStandaloneSecret struct {
+	TOTP api.SecretDataTOTP `json:"totp"`
+}
+
+func (V5TOTPStandaloneSecret) ResourceTypeSlug() string    { return "v5-totp-standalone" }
+func (V5TOTPStandaloneSecret) metadataType(V5TOTPMetadata) {}
+
+// V5PasswordStringSecret is the secret of the "v5-password-string" resource type, stored as the plain password
+type V5PasswordStringSecret struct {
+	Password string `json:"password"`
+}
+
+func (V5PasswordStringSecret) ResourceTypeSlug() string { return "v5-password-string" }
+func (V5PasswordStringSecret) metadataType(V5Metadata)  {}
+
+// V5CustomFieldsSecret is the secret of the "v5-custom-fields" resource type
+type V5CustomFieldsSecret struct {
+	CustomFields []CustomFieldSecret `json:"custom_fields"`
+}
+
+func (V5CustomFieldsSecret) ResourceTypeSlug() string            { return "v5-custom-fields" }
+func (V5CustomFieldsSecret) metadataType(V5CustomFieldsMetadata) {}
+
+// V4PasswordStringSecret is the secret of the "password-string" resource type, stored as the plain password
+type V4PasswordStringSecret struct {
+	Password string `json:"password"`
+}
+
+func (V4PasswordStringSecret) ResourceTypeSlug() string { return "password-string" }
+func (V4PasswordStringSecret) metadataType(V4Metadata)  {}
+
+// V4PasswordAndDescriptionSecret is the secret of the "password-and-description" resource type
+type V4PasswordAndDescriptionSecret struct {
+	Password    string `json:"password"`
+	Description string `json:"description,omitempty"`
+}
+
+func (V4PasswordAndDescriptionSecret) ResourceTypeSlug() string { return "password-and-description" }
+func (V4PasswordAndDescriptionSecret) metadataType(V4Metadata)  {}
+
+// V4TOTPSecret is the secret of the "totp" resource type
+type V4TOTPSecret struct {
+	TOTP api.SecretDataTOTP `json:"totp"`
+}
+
+func (V4TOTPSecret) ResourceTypeSlug() string { return "totp" }
+func (V4TOTPSecret) metadataType(V4Metadata)  {}
+
+// V4PasswordDescriptionTOTPSecret is the secret of the "password-description-totp" resource type
+type V4PasswordDescriptionTOTPSecret struct {
+	Password    string             `json:"password"`
+	Description string             `json:"description,omitempty"`
+	TOTP        api.SecretDataTOTP `json:"totp"`
+}
+
+func (V4PasswordDescriptionTOTPSecret) ResourceTypeSlug() string { return "password-description-totp" }
+func (V4PasswordDescriptionTOTPSecret) metadataType(V4Metadata)  {}
+
+// CreateTyped creates a resource from typed metadata and secret. The resource type is inferred from the secret
+// and the fields are validated against the resource type's JSON schema like with CreateResourceGeneric.
+func CreateTyped[M any, S TypedSecret[M]](ctx context.Context, c *api.Client, folderParentID string, metadata M, secret S) (string, error) {
+	metadataFields, secretFields, err := typedFieldMaps(metadata, secret)
+	if err != nil {
+		return "", err
+	}
+	return CreateResourceGeneric(ctx, c, secret.ResourceTypeSlug(), folderParentID, metadataFields, secretFields)
+}
+
+// GetTyped gets a resource by ID and decrypts it into typed metadata and secret.
+// Returns ErrResourceTypeMismatch if the resource is of a different resource type than S.
+func GetTyped[M any, S TypedSecret[M]](ctx context.Context, c *api.Client, resourceID string) (folderParentID string, metadata M, secret S, err error) {
+	resource, err := c.GetResource(ctx, resourceID)
+	if err != nil {
+		return "", metadata, secret, fmt.Errorf("getting resource: %w", err)
+	}
+	rType, err := c.GetResourceType(ctx, resource.ResourceTypeID)
+	if err != nil {
+		return "", metadata, secret, fmt.Errorf("getting resource type: %w", err)
+	}
+	apiSecret, err := c.GetSecret(ctx, resource.ID)
+	if err != nil {
+		return "", metadata, secret, fmt.Errorf("getting resource secret: %w", err)
+	}
+	return GetTypedFromData[M, S](c, *resource, *apiSecret, *rType)
+}
+
+// GetTypedFromData decrypts already fetched resource data into typed metadata and secret, see GetResourceFromData.
+// Returns ErrResourceTypeMismatch if the resource is of a different resource type than S.
+func GetTypedFromData[M any, S TypedSecret[M]](c *api.Client, resource api.Resource, apiSecret api.Secret, rType api.ResourceType) (folderParentID string, metadata M, secret S, err error) {
+	if rType.Slug != secret.ResourceTypeSlug() {
+		return "", metadata, secret, fmt.Errorf("%w: resource is %q, not %q", ErrResourceTypeMismatch, rType.Slug, secret.ResourceTypeSlug())
+	}
+
+	metadataFields, secretFields, err := resourceFieldMaps(c, resource, apiSecret, rType, true)
+	if err != nil {
+		return "", metadata, secret, err
+	}
+
+	err = fromFieldMap(metadataFields, &metadata)
+	if err != nil {
+		return "", metadata, secret, fmt.Errorf("decoding metadata: %w", err)
+	}
+	err = fromFieldMap(secretFields, &secret)
+	if err != nil {
+		return "", metadata, secret, fmt.Errorf("decoding secret data: %w", err)
+	}
+	return resource.FolderParentID, metadata, secret, nil
+}
+
+// UpdateTyped replaces the fields M and S model with the typed values. Unlike UpdateResourceGeneric
+// zero values clear the existing value, fields the structs don't model, like the icon, are kept.
+// Returns ErrResourceTypeMismatch if the resource is of a different resource type than S.
+func UpdateTyped[M any, S TypedSecret[M]](ctx context.Context, c *api.Client, resourceID string, metadata M, secret S) error {
+	resource, err := c.GetResource(ctx, resourceID)
+	if err != nil {
+		return fmt.Errorf("getting resource: %w", err)
+	}
+	rType, err := c.GetResourceType(ctx, resource.ResourceTypeID)
+	if err != nil {
+		return fmt.Errorf("getting resource type: %w", err)
+	}
+	if rType.Slug != secret.ResourceTypeSlug() {
+		return fmt.Errorf("%w: resource is %q, not %q", ErrResourceTypeMismatch, rType.Slug, secret.ResourceTypeSlug())
+	}
+
+	metadataFields, secretFields, err := typedFieldMaps(metadata, secret)
+	if err != nil {
+		return err
+	}
+	return updateResource(ctx, c, resource, rType, metadataFields, secretFields, typedFieldDrop(metadata, secret))
+}
+
+// typedFieldDrop drops the existing fields the typed values model, so the typed values replace them
+func typedFieldDrop(values ...any) func(key string) bool {
+	modeled := map[string]bool{}
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		for i := range t.NumField() {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name == "" {
+				name = t.Field(i).Name
+			}
+			if name != "-" {
+				modeled[name] = true
+			}
+		}
+	}
+	return func(key string) bool { return modeled[key] }
+}
+
+// typedFieldMaps converts typed metadata and secret into the field maps used by the generic helpers
+func typedFieldMaps(metadata, secret any) (metadataFields, secretFields map[string]any, err error) {
+	metadataFields, err = toFieldMap(metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding metadata: %w", err)
+	}
+	secretFields, err = toFieldMap(secret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding secret data: %w", err)
+	}
+	return metadataFields, secretFields, nil
+}
+
+// toFieldMap round trips v through JSON so the map holds the same types as a decrypted resource
+func toFieldMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// fromFieldMap decodes a field map into v, unknown keys like object_type are ignored
+func fromFieldMap(fields map[string]any, v any) error {
+	if fields == nil {
+		return nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
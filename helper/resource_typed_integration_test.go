@@ -0,0 +1,93 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// TestTypedResource creates, reads and replaces a v5-default-with-totp resource through the typed helpers.
+func TestTypedResource(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	if client.MetadataTypeSettings().DefaultResourceType != api.PassboltAPIVersionTypeV5 {
+		t.Skip("server does not default to v5 resources")
+	}
+	ctx := context.TODO()
+
+	id, err := CreateTyped(ctx, client, "",
+		V5Metadata{Name: "typed", Username: "user", URIs: []string{"https://url.lan"}},
+		V5DefaultWithTOTPSecret{Password: "password123", Description: "note", TOTP: testTOTP},
+	)
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	_, metadata, secret, err := GetTyped[V5Metadata, V5DefaultWithTOTPSecret](ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	equal(t, "Name", metadata.Name, "typed")
+	equal(t, "Password", secret.Password, "password123")
+	equal(t, "TOTP Secret", secret.TOTP.SecretKey, testTOTP.SecretKey)
+
+	metadata.Username = ""
+	secret.Description = ""
+	err = UpdateTyped(ctx, client, id, metadata, secret)
+	if err != nil {
+		t.Fatalf("Updating Resource %v", err)
+	}
+
+	_, metadata, secret, err = GetTyped[V5Metadata, V5DefaultWithTOTPSecret](ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	equal(t, "Username", metadata.Username, "")
+	equal(t, "Description", secret.Description, "")
+
+	_, _, _, err = GetTyped[V5Metadata, V5DefaultSecret](ctx, client, id)
+	if !errors.Is(err, ErrResourceTypeMismatch) {
+		t.Errorf("Getting with wrong type returned %v", err)
+	}
+}
+
+// TestTypedResourceV4 clears the cleartext fields of a password-string resource through UpdateTyped.
+func TestTypedResourceV4(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	if !client.MetadataTypeSettings().AllowCreationOfV4Resources {
+		t.Skip("server does not allow v4 resources")
+	}
+	ctx := context.TODO()
+
+	id, err := CreateTyped(ctx, client, "",
+		V4Metadata{Name: "typed v4", Username: "user", URI: "https://url.lan", Description: "note"},
+		V4PasswordStringSecret{Password: "password123"},
+	)
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	err = UpdateTyped(ctx, client, id, V4Metadata{Name: "typed v4"}, V4PasswordStringSecret{Password: "password123"})
+	if err != nil {
+		t.Fatalf("Updating Resource %v", err)
+	}
+
+	_, metadata, secret, err := GetTyped[V4Metadata, V4PasswordStringSecret](ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	equal(t, "Name", metadata.Name, "typed v4")
+	equal(t, "Username", metadata.Username, "")
+	equal(t, "URI", metadata.URI, "")
+	equal(t, "Description", metadata.Description, "")
+	equal(t, "Password", secret.Password, "password123")
+}
@@ -0,0 +1,159 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// The typed structs are only useful if what they marshal to is accepted by
+// the resource type's schema. These tests run each v5 pair through the same
+// conversion and validation CreateTyped uses, against the bundled schemas.
+
+var testTOTP = api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DPEHPK3PXP", Digits: 6, Period: 30}
+
+// validateTyped converts a typed pair the way CreateResourceGeneric would
+// and validates it against the fallback schema of its slug.
+func validateTyped(t *testing.T, slug string, metadata, secret any) {
+	t.Helper()
+
+	rType := &api.ResourceType{ID: "typed-test-" + slug, Slug: slug, Definition: json.RawMessage("[]")}
+	metadataFields, secretFields, err := typedFieldMaps(metadata, secret)
+	if err != nil {
+		t.Fatalf("typedFieldMaps: %v", err)
+	}
+	if err := validateCustomFields(metadataFields, secretFields); err != nil {
+		t.Fatalf("validateCustomFields: %v", err)
+	}
+
+	metadataFields["object_type"] = api.PassboltObjectTypeResourceMetadata
+	metaData, _ := json.Marshal(metadataFields)
+	if err := validateMetadata(rType, string(metaData)); err != nil {
+		t.Errorf("%v metadata: %v", slug, err)
+	}
+
+	var secretData string
+	if rType.IsSecretString() {
+		secretData = GetStringField(secretFields, "password")
+	} else {
+		secretFields["object_type"] = api.PassboltObjectTypeSecretData
+		raw, _ := json.Marshal(secretFields)
+		secretData = string(raw)
+	}
+	if err := validateSecretData(rType, secretData); err != nil {
+		t.Errorf("%v secret: %v", slug, err)
+	}
+}
+
+func TestTypedStructsMatchSchemas(t *testing.T) {
+	t.Parallel()
+
+	metadata := V5Metadata{Name: "name", Username: "user", URIs: []string{"https://example.com"}, Description: "note"}
+
+	validateTyped(t, V5DefaultSecret{}.ResourceTypeSlug(), metadata, V5DefaultSecret{Password: "pw", Description: "note"})
+	validateTyped(t, V5DefaultSecret{}.ResourceTypeSlug(), V5Metadata{Name: "only name"}, V5DefaultSecret{})
+	validateTyped(t, V5DefaultWithTOTPSecret{}.ResourceTypeSlug(), metadata, V5DefaultWithTOTPSecret{Password: "pw", TOTP: testTOTP})
+	validateTyped(t, V5PasswordStringSecret{}.ResourceTypeSlug(), metadata, V5PasswordStringSecret{Password: "pw"})
+	validateTyped(t, V5TOTPStandaloneSecret{}.ResourceTypeSlug(), V5TOTPMetadata{Name: "otp"}, V5TOTPStandaloneSecret{TOTP: testTOTP})
+
+	id := "8e3874ae-4b40-590b-968a-418f704b9d9a"
+	validateTyped(t, V5CustomFieldsSecret{}.ResourceTypeSlug(),
+		V5CustomFieldsMetadata{Name: "fields", CustomFields: []CustomFieldMetadata{{ID: id, Type: CustomFieldTypeText, MetadataKey: "pin"}}},
+		V5CustomFieldsSecret{CustomFields: []CustomFieldSecret{{ID: id, Type: CustomFieldTypeText, SecretValue: "1234"}}},
+	)
+}
+
+func TestTypedSecretSlugsHaveSchemas(t *testing.T) {
+	t.Parallel()
+
+	for _, slug := range []string{
+		V5DefaultSecret{}.ResourceTypeSlug(),
+		V5DefaultWithTOTPSecret{}.ResourceTypeSlug(),
+		V5TOTPStandaloneSecret{}.ResourceTypeSlug(),
+		V5PasswordStringSecret{}.ResourceTypeSlug(),
+		V5CustomFieldsSecret{}.ResourceTypeSlug(),
+	} {
+		if _, ok := api.ResourceSchemas[slug]; !ok {
+			t.Errorf("no schema for %q", slug)
+		}
+	}
+}
+
+func TestFromFieldMap_DecryptedShape(t *testing.T) {
+	t.Parallel()
+
+	// Shape as returned by resourceFieldMaps for a decrypted v5 resource
+	fields := map[string]any{
+		"object_type":      api.PassboltObjectTypeResourceMetadata,
+		"resource_type_id": "ignored",
+		"name":             "name",
+		"uris":             []any{"https://a", "https://b"},
+	}
+	var metadata V5Metadata
+	if err := fromFieldMap(fields, &metadata); err != nil {
+		t.Fatalf("fromFieldMap: %v", err)
+	}
+	want := V5Metadata{Name: "name", URIs: []string{"https://a", "https://b"}}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("metadata = %+v, want %+v", metadata, want)
+	}
+
+	var secret V5DefaultSecret
+	if err := fromFieldMap(nil, &secret); err != nil || secret != (V5DefaultSecret{}) {
+		t.Errorf("nil fields = %+v, %v", secret, err)
+	}
+}
+
+func TestUpdateTypedKeepsUnknownFields(t *testing.T) {
+	existing := map[string]any{
+		"object_type": api.PassboltObjectTypeResourceMetadata,
+		"name":        "old",
+		"username":    "user",
+		"icon":        map[string]any{"type": "keepass-icon-set", "value": float64(1)},
+	}
+	metadata, secret := V5Metadata{Name: "new"}, V5DefaultSecret{Password: "secret"}
+	metadataFields, _, err := typedFieldMaps(metadata, secret)
+	if err != nil {
+		t.Fatalf("typedFieldMaps: %v", err)
+	}
+
+	// The cleared username is gone, the icon no typed struct models is kept
+	got := mergeFields(existing, metadataFields, typedFieldDrop(metadata, secret))
+	want := map[string]any{
+		"object_type": api.PassboltObjectTypeResourceMetadata,
+		"name":        "new",
+		"icon":        map[string]any{"type": "keepass-icon-set", "value": float64(1)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestUpdateTypedClearsV4Fields(t *testing.T) {
+	existing := &api.Resource{ID: "id", Name: "old", Username: "user", URI: "https://old", Description: "note"}
+	metadata, secret := V4Metadata{Name: "new"}, V4PasswordStringSecret{Password: "secret"}
+	metadataFields, _, err := typedFieldMaps(metadata, secret)
+	if err != nil {
+		t.Fatalf("typedFieldMaps: %v", err)
+	}
+	newResource := api.Resource{ID: "id"}
+	mergeV4Fields(&newResource, existing, metadataFields, typedFieldDrop(metadata, secret))
+
+	// The cleared fields are sent as empty values, leaving them out would keep them on the server
+	data, err := json.Marshal(newV4ResourceUpdate(newResource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{"name": "new", "username": "", "uri": "", "description": ""} {
+		if value, ok := got[key]; !ok || value != want {
+			t.Errorf("%v: got %v, want %q", key, value, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"time"
 
 	"github.com/passbolt/go-passbolt/api"
@@ -64,6 +65,13 @@ func UpdateResourceGeneric(ctx context.Context, c *api.Client, resourceID string
 		return fmt.Errorf("getting resource type: %w", err)
 	}
 
+	return updateResource(ctx, c, resource, rType, metadataUpdates, secretUpdates, nil)
+}
+
+// updateResource updates an already fetched resource. Existing fields drop returns true for are removed
+// before the updates are merged into the metadata and secret, with a nil drop all existing fields are kept.
+func updateResource(ctx context.Context, c *api.Client, resource *api.Resource, rType *api.ResourceType, metadataUpdates map[string]any, secretUpdates map[string]any, drop func(key string) bool) error {
+	resourceID := resource.ID
 	opts := &api.GetUsersOptions{
 		FilterHasAccess: []string{resourceID},
 	}
@@ -106,11 +114,7 @@ func UpdateResourceGeneric(ctx context.Context, c *api.Client, resourceID string
 		if err != nil {
 			return fmt.Errorf("parsing metadata: %w", err)
 		}
-
-		// Merge updates
-		for k, v := range metadataUpdates {
-			metadataMap[k] = v
-		}
+		metadataMap = mergeFields(metadataMap, metadataUpdates, drop)
 
 		newMetadata, err := json.Marshal(metadataMap)
 		if err != nil {
@@ -137,32 +141,18 @@ func UpdateResourceGeneric(ctx context.Context, c *api.Client, resourceID string
 		newResource.Metadata = encMetadata
 	} else {
 		// V4: set cleartext fields, preserving existing values
-		newResource.Name = resource.Name
-		newResource.Username = resource.Username
-		newResource.URI = resource.URI
-		newResource.Description = resource.Description
-
-		if v := GetStringField(metadataUpdates, "name"); v != "" {
-			newResource.Name = v
-		}
-		if v := GetStringField(metadataUpdates, "username"); v != "" {
-			newResource.Username = v
-		}
-		if v := GetStringField(metadataUpdates, "uri"); v != "" {
-			newResource.URI = v
-		}
-		if v := GetStringField(metadataUpdates, "description"); v != "" {
-			newResource.Description = v
-		}
+		mergeV4Fields(&newResource, resource, metadataUpdates, drop)
 	}
 
 	// --- Handle secret ---
 	var secretDataStr string
+	var passwordChanged bool
 
 	if rType.IsSecretString() {
 		// Secret is a plain string (password)
-		if pw := GetStringField(secretUpdates, "password"); pw != "" {
+		if pw := GetStringField(secretUpdates, "password"); pw != "" || (drop != nil && drop("password")) {
 			secretDataStr = pw
+			passwordChanged = true
 		} else {
 			// Preserve existing secret
 			secret, err := c.GetSecret(ctx, resourceID)
@@ -190,11 +180,9 @@ func UpdateResourceGeneric(ctx context.Context, c *api.Client, resourceID string
 		if err != nil {
 			return fmt.Errorf("parsing decrypted secret data: %w", err)
 		}
-
-		// Merge updates
-		for k, v := range secretUpdates {
-			secretMap[k] = v
-		}
+		oldPassword := GetStringField(secretMap, "password")
+		secretMap = mergeFields(secretMap, secretUpdates, drop)
+		passwordChanged = GetStringField(secretMap, "password") != oldPassword
 
 		res, err := json.Marshal(secretMap)
 		if err != nil {
@@ -229,16 +217,86 @@ func UpdateResourceGeneric(ctx context.Context, c *api.Client, resourceID string
 		})
 	}
 
-	// Handle password expiry
+	// Handle password expiry: a changed password gets a fresh default expiry, or none if the server has no default period
 	passwordExpirySettings := c.GetPasswordExpirySettings()
-	if resource.Expired != nil && passwordExpirySettings.AutomaticUpdate {
-		expiry := time.Now().Add(time.Hour * 24 * time.Duration(passwordExpirySettings.DefaultExpiryPeriod))
-		newResource.Expired = &api.Time{Time: expiry}
+	clearExpiry := false
+	if passwordChanged && passwordExpirySettings.AutomaticUpdate {
+		newResource.Expired = defaultExpiry(passwordExpirySettings, time.Now())
+		clearExpiry = newResource.Expired == nil && resource.Expired != nil
 	}
 
-	_, err = c.UpdateResource(ctx, resourceID, newResource)
+	if isV5 {
+		_, err = c.UpdateResource(ctx, resourceID, newResource)
+	} else {
+		_, err = c.DoCustomRequest(ctx, "PUT", "/resources/"+resourceID+".json", "v2", newV4ResourceUpdate(newResource), nil)
+	}
 	if err != nil {
 		return fmt.Errorf("updating resource: %w", err)
 	}
+
+	if clearExpiry {
+		return ClearResourceExpiry(ctx, c, resourceID)
+	}
 	return nil
 }
+
+// dropAllFields makes updateResource replace the metadata and secret with the updates
+func dropAllFields(string) bool { return true }
+
+// mergeV4Fields sets the cleartext fields of newResource to the updates, or the existing values unless drop returns true for them
+func mergeV4Fields(newResource, resource *api.Resource, metadataUpdates map[string]any, drop func(key string) bool) {
+	keep := func(key string) bool { return drop == nil || !drop(key) }
+	if keep("name") {
+		newResource.Name = resource.Name
+	}
+	if keep("username") {
+		newResource.Username = resource.Username
+	}
+	if keep("uri") {
+		newResource.URI = resource.URI
+	}
+	if keep("description") {
+		newResource.Description = resource.Description
+	}
+
+	if v := GetStringField(metadataUpdates, "name"); v != "" {
+		newResource.Name = v
+	}
+	if v := GetStringField(metadataUpdates, "username"); v != "" {
+		newResource.Username = v
+	}
+	if v := GetStringField(metadataUpdates, "uri"); v != "" {
+		newResource.URI = v
+	}
+	if v := GetStringField(metadataUpdates, "description"); v != "" {
+		newResource.Description = v
+	}
+}
+
+// v4ResourceUpdate sends the cleartext v4 fields even if they are empty. api.Resource omits empty values,
+// which makes the server keep the existing ones
+type v4ResourceUpdate struct {
+	api.Resource
+	Username    string `json:"username"`
+	URI         string `json:"uri"`
+	Description string `json:"description"`
+}
+
+func newV4ResourceUpdate(resource api.Resource) v4ResourceUpdate {
+	return v4ResourceUpdate{Resource: resource, Username: resource.Username, URI: resource.URI, Description: resource.Description}
+}
+
+// mergeFields removes the existing fields drop returns true for and merges the updates into the rest.
+// object_type and resource_type_id describe the data itself and are never dropped
+func mergeFields(existing, updates map[string]any, drop func(key string) bool) map[string]any {
+	if existing == nil {
+		existing = map[string]any{}
+	}
+	if drop != nil {
+		maps.DeleteFunc(existing, func(key string, _ any) bool {
+			return key != "object_type" && key != "resource_type_id" && drop(key)
+		})
+	}
+	maps.Copy(existing, updates)
+	return existing
+}
@@ -0,0 +1,318 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// RotationPreviousPasswordMarker starts the line of the secret description holding the quoted previous password
+// while a rotation is verified
+const RotationPreviousPasswordMarker = "go-passbolt-rotation-previous-password: "
+
+// RotationTarget describes the account a Rotator changes the credential of
+type RotationTarget struct {
+	ResourceID string
+	Name       string
+	Username   string
+	URIs       []string
+}
+
+// Rotator changes credentials on the system they belong to
+type Rotator interface {
+	// Rotate changes the password of the target account from oldPassword to newPassword
+	Rotate(ctx context.Context, target RotationTarget, oldPassword, newPassword string) error
+	// Verify checks the target account accepts password
+	Verify(ctx context.Context, target RotationTarget, password string) error
+}
+
+// RotationOptions configure RotateResource
+type RotationOptions struct {
+	// Generate returns the new password, defaults to GeneratePassword with the server's password policy
+	Generate func(ctx context.Context) (string, error)
+	// MinEntropy is passed to the default generator
+	MinEntropy float64
+	// WithoutPreviousPassword allows rotating resources whose type has no secret description to keep the previous password in.
+	// If the rotation is interrupted after the new password is saved, the previous password is lost
+	WithoutPreviousPassword bool
+}
+
+// RotationSchedule decides which resources are due for rotation
+type RotationSchedule struct {
+	// MaxAge rotates resources not modified for longer, 0 disables
+	MaxAge time.Duration
+	// BeforeExpiry rotates resources expiring within this duration, expired resources are always due
+	BeforeExpiry time.Duration
+}
+
+// RotateResource rotates the password of a resource using rotator.
+//
+// The new password and the previous password are saved in Passbolt before the target is changed, so no credential
+// only exists in memory. The previous password is kept on a RotationPreviousPasswordMarker line of the secret description
+// and removed once the new password is verified. If changing or verifying the target fails, the target and the resource
+// are rolled back. A resource that still has the previous password, e.g. because a rotation was interrupted, returns
+// ErrRotationPending until ResolveRotation or ClearPendingRotation is called.
+// Resource types without secret description can't keep the previous password, they return ErrRotationNoPreviousPassword
+// unless opts.WithoutPreviousPassword is set
+func RotateResource(ctx context.Context, c *api.Client, resourceID string, rotator Rotator, opts RotationOptions) error {
+	res, target, state, err := getRotationResource(ctx, c, resourceID)
+	if err != nil {
+		return err
+	}
+	if state.Previous != "" {
+		return ErrRotationPending
+	}
+	if !res.keepsPrevious() && !opts.WithoutPreviousPassword {
+		return fmt.Errorf("%w: %q has no secret description", ErrRotationNoPreviousPassword, res.rType.Slug)
+	}
+	newPassword, err := generateRotationPassword(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+	return rotate(ctx, res, target, state, rotator, newPassword)
+}
+
+// ResolveRotation finishes a pending rotation by keeping whichever of the current and the previous password the
+// target accepts. It does nothing if no rotation is pending
+func ResolveRotation(ctx context.Context, c *api.Client, resourceID string, rotator Rotator) error {
+	res, target, state, err := getRotationResource(ctx, c, resourceID)
+	if err != nil {
+		return err
+	}
+	return resolveRotation(ctx, res, target, state, rotator)
+}
+
+// ClearPendingRotation removes the previous password of a pending rotation and keeps the current password,
+// for when the target was checked by other means. It does nothing if no rotation is pending
+func ClearPendingRotation(ctx context.Context, c *api.Client, resourceID string) error {
+	res, _, state, err := getRotationResource(ctx, c, resourceID)
+	if err != nil {
+		return err
+	}
+	if state.Previous == "" {
+		return nil
+	}
+	err = res.save(ctx, rotationState{Password: state.Password})
+	if err != nil {
+		return fmt.Errorf("removing previous password: %w", err)
+	}
+	return nil
+}
+
+// rotationState is the password of a resource and, while a rotation is verified, the previous password
+type rotationState struct {
+	Password string
+	Previous string
+}
+
+// rotationResource saves the rotationState of a resource
+type rotationResource interface {
+	save(ctx context.Context, state rotationState) error
+	// restoreExpiry puts back the expiry a rolled back password change may have reset
+	restoreExpiry(ctx context.Context) error
+}
+
+// rotate saves newPassword with the previous password, changes and verifies the target and rolls back on failure
+func rotate(ctx context.Context, res rotationResource, target RotationTarget, state rotationState, rotator Rotator, newPassword string) error {
+	oldPassword := state.Password
+	err := res.save(ctx, rotationState{Password: newPassword, Previous: oldPassword})
+	if err != nil {
+		return fmt.Errorf("saving new password: %w", err)
+	}
+
+	rollback := func(cause error, rotated bool) error {
+		errs := []error{fmt.Errorf("%w: %w", ErrRotationFailed, cause)}
+		if rotated {
+			if err := rotator.Rotate(ctx, target, newPassword, oldPassword); err != nil {
+				errs = append(errs, fmt.Errorf("%w: restoring target password: %w", ErrRollbackFailed, err))
+				// The target still has the new password, which is what the resource holds now
+				return errors.Join(errs...)
+			}
+		}
+		if err := res.save(ctx, rotationState{Password: oldPassword}); err != nil {
+			errs = append(errs, fmt.Errorf("%w: restoring resource password: %w", ErrRollbackFailed, err))
+		} else if err := res.restoreExpiry(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%w: restoring expiry: %w", ErrRollbackFailed, err))
+		}
+		return errors.Join(errs...)
+	}
+
+	err = rotator.Rotate(ctx, target, oldPassword, newPassword)
+	if err != nil {
+		return rollback(fmt.Errorf("changing target password: %w", err), false)
+	}
+	err = rotator.Verify(ctx, target, newPassword)
+	if err != nil {
+		return rollback(fmt.Errorf("verifying target password: %w", err), true)
+	}
+
+	err = res.save(ctx, rotationState{Password: newPassword})
+	if err != nil {
+		return fmt.Errorf("removing previous password: %w", err)
+	}
+	return nil
+}
+
+// resolveRotation keeps the password of a pending rotation the target accepts
+func resolveRotation(ctx context.Context, res rotationResource, target RotationTarget, state rotationState, rotator Rotator) error {
+	if state.Previous == "" {
+		return nil
+	}
+	password := state.Password
+	currentErr := rotator.Verify(ctx, target, password)
+	if currentErr != nil {
+		if previousErr := rotator.Verify(ctx, target, state.Previous); previousErr != nil {
+			return fmt.Errorf("%w: target accepts neither password: %w", ErrRotationFailed, errors.Join(currentErr, previousErr))
+		}
+		password = state.Previous
+	}
+	err := res.save(ctx, rotationState{Password: password})
+	if err != nil {
+		return fmt.Errorf("saving resolved password: %w", err)
+	}
+	return nil
+}
+
+// apiRotationResource is a rotationResource on the server
+type apiRotationResource struct {
+	c        *api.Client
+	resource *api.Resource
+	rType    *api.ResourceType
+	// description is the secret description without the previous password
+	description string
+}
+
+// getRotationResource reads the rotation target and state of a resource
+func getRotationResource(ctx context.Context, c *api.Client, resourceID string) (*apiRotationResource, RotationTarget, rotationState, error) {
+	resource, err := c.GetResource(ctx, resourceID)
+	if err != nil {
+		return nil, RotationTarget{}, rotationState{}, fmt.Errorf("getting resource: %w", err)
+	}
+	rType, err := c.GetResourceType(ctx, resource.ResourceTypeID)
+	if err != nil {
+		return nil, RotationTarget{}, rotationState{}, fmt.Errorf("getting resource type: %w", err)
+	}
+	if !rType.IsSecretString() && !rType.HasSecretField("password") {
+		return nil, RotationTarget{}, rotationState{}, fmt.Errorf("%w: %q has no password", ErrResourceTypeMismatch, rType.Slug)
+	}
+	secret, err := c.GetSecret(ctx, resource.ID)
+	if err != nil {
+		return nil, RotationTarget{}, rotationState{}, fmt.Errorf("getting resource secret: %w", err)
+	}
+	metadataFields, secretFields, err := resourceFieldMaps(c, *resource, *secret, *rType, true)
+	if err != nil {
+		return nil, RotationTarget{}, rotationState{}, err
+	}
+
+	res := &apiRotationResource{c: c, resource: resource, rType: rType}
+	state := rotationState{Password: GetStringField(secretFields, "password")}
+	if res.keepsPrevious() {
+		res.description, state.Previous = splitPreviousPassword(GetStringField(secretFields, "description"))
+	}
+	target := RotationTarget{
+		ResourceID: resource.ID,
+		Name:       GetStringField(metadataFields, "name"),
+		Username:   GetStringField(metadataFields, "username"),
+		URIs:       resourceURIs(metadataFields),
+	}
+	return res, target, state, nil
+}
+
+// keepsPrevious is true if the resource type has a secret description to keep the previous password in
+func (r *apiRotationResource) keepsPrevious() bool {
+	return !r.rType.IsSecretString() && r.rType.HasSecretField("description")
+}
+
+// save updates the password and, if the type keeps it, the previous password in one update
+func (r *apiRotationResource) save(ctx context.Context, state rotationState) error {
+	secretUpdates := map[string]any{"password": state.Password}
+	if r.keepsPrevious() {
+		secretUpdates["description"] = joinPreviousPassword(r.description, state.Previous)
+	}
+	return UpdateResourceGeneric(ctx, r.c, r.resource.ID, map[string]any{}, secretUpdates)
+}
+
+func (r *apiRotationResource) restoreExpiry(ctx context.Context) error {
+	if !r.c.GetPasswordExpirySettings().AutomaticUpdate {
+		return nil
+	}
+	if r.resource.Expired == nil {
+		return ClearResourceExpiry(ctx, r.c, r.resource.ID)
+	}
+	return SetResourceExpiry(ctx, r.c, r.resource.ID, r.resource.Expired.Time)
+}
+
+// joinPreviousPassword appends the RotationPreviousPasswordMarker line for previous to description, if previous is set
+func joinPreviousPassword(description, previous string) string {
+	if previous == "" {
+		return description
+	}
+	line := RotationPreviousPasswordMarker + strconv.Quote(previous)
+	if description == "" {
+		return line
+	}
+	return description + "\n\n" + line
+}
+
+// splitPreviousPassword removes the RotationPreviousPasswordMarker line from description and returns the previous password
+func splitPreviousPassword(description string) (string, string) {
+	i := strings.LastIndex(description, RotationPreviousPasswordMarker)
+	if i < 0 || (i > 0 && description[i-1] != '\n') {
+		return description, ""
+	}
+	before, line := description[:i], description[i+len(RotationPreviousPasswordMarker):]
+	after := ""
+	if j := strings.IndexByte(line, '\n'); j >= 0 {
+		line, after = line[:j], line[j+1:]
+	}
+	previous, err := strconv.Unquote(line)
+	if err != nil || previous == "" {
+		return description, ""
+	}
+	if after == "" {
+		before = strings.TrimSuffix(before, "\n\n")
+	}
+	return before + after, previous
+}
+
+// GetResourcesDueForRotation returns the resources due for rotation according to schedule
+func GetResourcesDueForRotation(ctx context.Context, c *api.Client, schedule RotationSchedule) ([]api.Resource, error) {
+	resources, err := c.GetResources(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	return dueForRotation(resources, schedule, time.Now()), nil
+}
+
+// dueForRotation filters resources that are too old or expire within schedule.BeforeExpiry
+func dueForRotation(resources []api.Resource, schedule RotationSchedule, now time.Time) []api.Resource {
+	due := []api.Resource{}
+	for _, resource := range resources {
+		tooOld := schedule.MaxAge > 0 && resource.Modified != nil && resource.Modified.Before(now.Add(-schedule.MaxAge))
+		expiring := resource.Expired != nil && resource.Expired.Before(now.Add(schedule.BeforeExpiry))
+		if tooOld || expiring {
+			due = append(due, resource)
+		}
+	}
+	return due
+}
+
+func generateRotationPassword(ctx context.Context, c *api.Client, opts RotationOptions) (string, error) {
+	if opts.Generate != nil {
+		password, err := opts.Generate(ctx)
+		if err != nil {
+			return "", fmt.Errorf("generating password: %w", err)
+		}
+		return password, nil
+	}
+	password, _, err := GeneratePassword(ctx, c, opts.MinEntropy)
+	if err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+	return password, nil
+}
@@ -0,0 +1,65 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeRotator is an in-memory Rotator for tests. It keeps a password per resource ID
+type FakeRotator struct {
+	// RotateErr is returned by Rotate if set
+	RotateErr error
+	// VerifyErr is returned by Verify if set
+	VerifyErr error
+
+	mu        sync.Mutex
+	passwords map[string]string
+}
+
+// NewFakeRotator returns a FakeRotator with the current passwords keyed by resource ID
+func NewFakeRotator(passwords map[string]string) *FakeRotator {
+	f := &FakeRotator{passwords: map[string]string{}}
+	for id, password := range passwords {
+		f.passwords[id] = password
+	}
+	return f
+}
+
+// Rotate changes the stored password if oldPassword matches
+func (f *FakeRotator) Rotate(ctx context.Context, target RotationTarget, oldPassword, newPassword string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.RotateErr != nil {
+		return f.RotateErr
+	}
+	if current, ok := f.passwords[target.ResourceID]; ok && current != oldPassword {
+		return fmt.Errorf("fake rotator: old password of %v does not match", target.ResourceID)
+	}
+	if f.passwords == nil {
+		f.passwords = map[string]string{}
+	}
+	f.passwords[target.ResourceID] = newPassword
+	return nil
+}
+
+// Verify checks password against the stored password
+func (f *FakeRotator) Verify(ctx context.Context, target RotationTarget, password string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.VerifyErr != nil {
+		return f.VerifyErr
+	}
+	if f.passwords[target.ResourceID] != password {
+		return fmt.Errorf("fake rotator: password of %v does not match", target.ResourceID)
+	}
+	return nil
+}
+
+// Password returns the stored password of resourceID
+func (f *FakeRotator) Password(resourceID string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	password, ok := f.passwords[resourceID]
+	return password, ok
+}
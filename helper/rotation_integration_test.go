@@ -0,0 +1,83 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRotateResource(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	id, err := CreateResource(ctx, client, "", "rotate", "admin", "https://db.example.com", "initial", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	rotator := NewFakeRotator(map[string]string{id: "initial"})
+	generate := func(context.Context) (string, error) { return "rotated", nil }
+
+	err = RotateResource(ctx, client, id, rotator, RotationOptions{Generate: generate})
+	if err != nil {
+		t.Fatalf("Rotating Resource %v", err)
+	}
+	_, _, _, _, password, description, err := GetResource(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if password != "rotated" || description != "" {
+		t.Fatalf("got password %q, description %q", password, description)
+	}
+	if current, _ := rotator.Password(id); current != "rotated" {
+		t.Fatalf("got target password %q, want %q", current, "rotated")
+	}
+
+	// A failed verification restores the previous password everywhere
+	rotator.VerifyErr = errors.New("login failed")
+	generate = func(context.Context) (string, error) { return "broken", nil }
+	err = RotateResource(ctx, client, id, rotator, RotationOptions{Generate: generate})
+	if !errors.Is(err, ErrRotationFailed) {
+		t.Fatalf("got %v, want %v", err, ErrRotationFailed)
+	}
+	_, _, _, _, password, description, err = GetResource(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if password != "rotated" || description != "" {
+		t.Fatalf("got password %q, description %q after rollback", password, description)
+	}
+	if current, _ := rotator.Password(id); current != "rotated" {
+		t.Fatalf("got target password %q after rollback, want %q", current, "rotated")
+	}
+
+	// An interrupted rotation is pending until it is resolved
+	err = UpdateResourceGeneric(ctx, client, id, map[string]any{}, map[string]any{
+		"password":    "interrupted",
+		"description": joinPreviousPassword("", "rotated"),
+	})
+	if err != nil {
+		t.Fatalf("Updating Resource %v", err)
+	}
+	rotator.VerifyErr = nil
+	err = RotateResource(ctx, client, id, rotator, RotationOptions{Generate: generate})
+	if !errors.Is(err, ErrRotationPending) {
+		t.Fatalf("got %v, want %v", err, ErrRotationPending)
+	}
+	err = ResolveRotation(ctx, client, id, rotator)
+	if err != nil {
+		t.Fatalf("Resolving rotation %v", err)
+	}
+	_, _, _, _, password, description, err = GetResource(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Resource %v", err)
+	}
+	if password != "rotated" || description != "" {
+		t.Fatalf("got password %q, description %q after resolving", password, description)
+	}
+}
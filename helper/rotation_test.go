@@ -0,0 +1,229 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestDueForRotation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) *api.Time {
+		return &api.Time{Time: now.Add(offset)}
+	}
+	resources := []api.Resource{
+		{ID: "fresh", Modified: at(-time.Hour)},
+		{ID: "old", Modified: at(-100 * 24 * time.Hour)},
+		{ID: "expired", Modified: at(-time.Hour), Expired: at(-time.Hour)},
+		{ID: "expiring", Modified: at(-time.Hour), Expired: at(2 * 24 * time.Hour)},
+		{ID: "later", Modified: at(-time.Hour), Expired: at(30 * 24 * time.Hour)},
+		{ID: "unknown"},
+	}
+	ids := func(resources []api.Resource) []string {
+		out := []string{}
+		for _, r := range resources {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		schedule RotationSchedule
+		want     []string
+	}{
+		{"expired only", RotationSchedule{}, []string{"expired"}},
+		{"max age", RotationSchedule{MaxAge: 90 * 24 * time.Hour}, []string{"old", "expired"}},
+		{"before expiry", RotationSchedule{BeforeExpiry: 7 * 24 * time.Hour}, []string{"expired", "expiring"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(dueForRotation(resources, tt.schedule, now))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFakeRotator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	target := RotationTarget{ResourceID: "id"}
+	f := NewFakeRotator(map[string]string{"id": "old"})
+
+	if err := f.Rotate(ctx, target, "wrong", "new"); err == nil {
+		t.Fatal("expected error for wrong old password")
+	}
+	if err := f.Rotate(ctx, target, "old", "new"); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if err := f.Verify(ctx, target, "old"); err == nil {
+		t.Fatal("expected old password to be rejected")
+	}
+	if err := f.Verify(ctx, target, "new"); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	f.VerifyErr = errors.New("unreachable")
+	if err := f.Verify(ctx, target, "new"); !errors.Is(err, f.VerifyErr) {
+		t.Fatalf("got %v, want %v", err, f.VerifyErr)
+	}
+	if password, _ := f.Password("id"); password != "new" {
+		t.Fatalf("got password %q, want %q", password, "new")
+	}
+}
+
+// fakeRotationResource keeps the saved rotationState in memory
+type fakeRotationResource struct {
+	state    rotationState
+	saveErr  error
+	restored bool
+}
+
+func (f *fakeRotationResource) save(ctx context.Context, state rotationState) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.state = state
+	return nil
+}
+
+func (f *fakeRotationResource) restoreExpiry(ctx context.Context) error {
+	f.restored = true
+	return nil
+}
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	target := RotationTarget{ResourceID: "id"}
+
+	t.Run("verified", func(t *testing.T) {
+		res := &fakeRotationResource{state: rotationState{Password: "old"}}
+		rotator := NewFakeRotator(map[string]string{"id": "old"})
+		err := rotate(ctx, res, target, res.state, rotator, "new")
+		if err != nil {
+			t.Fatalf("rotate: %v", err)
+		}
+		if res.state != (rotationState{Password: "new"}) {
+			t.Errorf("got state %+v", res.state)
+		}
+		if password, _ := rotator.Password("id"); password != "new" {
+			t.Errorf("got target password %q", password)
+		}
+	})
+
+	t.Run("verify failure", func(t *testing.T) {
+		res := &fakeRotationResource{state: rotationState{Password: "old"}}
+		rotator := NewFakeRotator(map[string]string{"id": "old"})
+		rotator.VerifyErr = errors.New("login failed")
+		err := rotate(ctx, res, target, res.state, rotator, "new")
+		if !errors.Is(err, ErrRotationFailed) || errors.Is(err, ErrRollbackFailed) {
+			t.Fatalf("got %v", err)
+		}
+		if res.state != (rotationState{Password: "old"}) || !res.restored {
+			t.Errorf("got state %+v, expiry restored %v", res.state, res.restored)
+		}
+		if password, _ := rotator.Password("id"); password != "old" {
+			t.Errorf("got target password %q", password)
+		}
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		// The target changed but saving the verified password failed, the previous password is kept
+		res := &fakeRotationResource{state: rotationState{Password: "old"}}
+		rotator := &interruptingRotator{FakeRotator: NewFakeRotator(map[string]string{"id": "old"}), res: res}
+		err := rotate(ctx, res, target, res.state, rotator, "new")
+		if err == nil {
+			t.Fatal("rotate succeeded without saving")
+		}
+		if res.state != (rotationState{Password: "new", Previous: "old"}) {
+			t.Fatalf("got state %+v", res.state)
+		}
+
+		// The rerun resolves to the password the target accepts
+		res.saveErr = nil
+		err = resolveRotation(ctx, res, target, res.state, rotator.FakeRotator)
+		if err != nil {
+			t.Fatalf("resolveRotation: %v", err)
+		}
+		if res.state != (rotationState{Password: "new"}) {
+			t.Errorf("got state %+v", res.state)
+		}
+	})
+
+	t.Run("resolve to previous", func(t *testing.T) {
+		res := &fakeRotationResource{state: rotationState{Password: "new", Previous: "old"}}
+		rotator := NewFakeRotator(map[string]string{"id": "old"})
+		err := resolveRotation(ctx, res, target, res.state, rotator)
+		if err != nil {
+			t.Fatalf("resolveRotation: %v", err)
+		}
+		if res.state != (rotationState{Password: "old"}) {
+			t.Errorf("got state %+v", res.state)
+		}
+
+		res.state = rotationState{Password: "new", Previous: "old"}
+		rotator = NewFakeRotator(map[string]string{"id": "other"})
+		err = resolveRotation(ctx, res, target, res.state, rotator)
+		if !errors.Is(err, ErrRotationFailed) {
+			t.Fatalf("got %v, want ErrRotationFailed", err)
+		}
+		if res.state != (rotationState{Password: "new", Previous: "old"}) {
+			t.Errorf("unresolved state changed to %+v", res.state)
+		}
+	})
+}
+
+// interruptingRotator makes saving fail once the target is verified
+type interruptingRotator struct {
+	*FakeRotator
+	res *fakeRotationResource
+}
+
+func (r *interruptingRotator) Verify(ctx context.Context, target RotationTarget, password string) error {
+	err := r.FakeRotator.Verify(ctx, target, password)
+	r.res.saveErr = errors.New("server unreachable")
+	return err
+}
+
+func TestPreviousPasswordDescription(t *testing.T) {
+	t.Parallel()
+
+	for _, description := range []string{"", "note", "two\nlines\n"} {
+		for _, previous := range []string{"old", "with \"quotes\"\nand newline"} {
+			joined := joinPreviousPassword(description, previous)
+			gotDescription, gotPrevious := splitPreviousPassword(joined)
+			if gotDescription != description || gotPrevious != previous {
+				t.Errorf("%q: got %q, %q", joined, gotDescription, gotPrevious)
+			}
+		}
+		if got, previous := splitPreviousPassword(joinPreviousPassword(description, "")); got != description || previous != "" {
+			t.Errorf("without previous password got %q, %q", got, previous)
+		}
+	}
+
+	// Text added below the marker line is kept
+	got, previous := splitPreviousPassword("note\n\n" + RotationPreviousPasswordMarker + `"old"` + "\nadded")
+	if got != "note\n\nadded" || previous != "old" {
+		t.Errorf("got %q, %q", got, previous)
+	}
+	// The marker in the middle of a line is no previous password
+	description := "see " + RotationPreviousPasswordMarker + `"old"`
+	if got, previous := splitPreviousPassword(description); got != description || previous != "" {
+		t.Errorf("got %q, %q", got, previous)
+	}
+}
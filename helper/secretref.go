@@ -0,0 +1,315 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// SecretRefScheme is the prefix of secret references
+const SecretRefScheme = "passbolt://"
+
+// Secret reference fields, any other field is the key of a custom field
+const (
+	SecretRefFieldPassword = "password"
+	SecretRefFieldUsername = "username"
+	SecretRefFieldURI      = "uri"
+	SecretRefFieldTOTP     = "totp"
+)
+
+// secretRefPattern finds references in text. Characters it stops at have to be percent-encoded in names
+var secretRefPattern = regexp.MustCompile(`passbolt://[^\s"'` + "`" + `<>,;(){}\[\]]+`)
+
+// SecretRef references a field of a Resource, either by ID or by Folder path and name.
+// As a string it is "passbolt://<folder path>/<resource name>#<field>" or "passbolt://id/<uuid>#<field>",
+// with the names percent-encoded where needed. Without a field it references the password
+type SecretRef struct {
+	// ID is set for references by ID, FolderPath and Name otherwise
+	ID string
+	// FolderPath are the names of the parent Folders from the root
+	FolderPath []string
+	Name       string
+	// Field is "password", "username", "uri", "totp" or the key of a custom field
+	Field string
+}
+
+// ParseSecretRef parses a passbolt:// reference
+func ParseSecretRef(ref string) (SecretRef, error) {
+	rest, ok := strings.CutPrefix(ref, SecretRefScheme)
+	if !ok {
+		return SecretRef{}, fmt.Errorf("%w: %q does not start with %v", ErrInvalidSecretRef, ref, SecretRefScheme)
+	}
+	rest, field, _ := strings.Cut(rest, "#")
+	field, err := url.PathUnescape(field)
+	if err != nil {
+		return SecretRef{}, fmt.Errorf("%w: %q: %w", ErrInvalidSecretRef, ref, err)
+	}
+	result := SecretRef{Field: field}
+	if result.Field == "" {
+		result.Field = SecretRefFieldPassword
+	}
+
+	names := []string{}
+	for _, segment := range strings.Split(rest, "/") {
+		if segment == "" {
+			continue
+		}
+		name, err := url.PathUnescape(segment)
+		if err != nil {
+			return SecretRef{}, fmt.Errorf("%w: %q: %w", ErrInvalidSecretRef, ref, err)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return SecretRef{}, fmt.Errorf("%w: %q has no resource name", ErrInvalidSecretRef, ref)
+	}
+	// A Folder named "id" with a Resource named like a UUID can only be referenced by ID
+	if len(names) == 2 && names[0] == "id" && uuid.Validate(names[1]) == nil {
+		result.ID = names[1]
+		return result, nil
+	}
+	result.FolderPath, result.Name = names[:len(names)-1], names[len(names)-1]
+	return result, nil
+}
+
+// String formats the reference so ParseSecretRef parses it back
+func (r SecretRef) String() string {
+	names := []string{"id", r.ID}
+	if r.ID == "" {
+		names = append(append([]string{}, r.FolderPath...), r.Name)
+	}
+	segments := []string{}
+	for _, name := range names {
+		segments = append(segments, url.PathEscape(name))
+	}
+	return SecretRefScheme + strings.Join(segments, "/") + "#" + url.PathEscape(r.Field)
+}
+
+// SecretResolver resolves secret references. It caches the Folders, Resources and decrypted secrets it looked up,
+// so it is meant to be used for one run and not to pick up changes made after its first lookup
+type SecretResolver struct {
+	c *api.Client
+
+	mu          sync.Mutex
+	tree        *folderTree
+	resources   []api.Resource
+	rTypes      []api.ResourceType
+	names       map[string]string
+	credentials map[string]*Credential
+}
+
+// NewSecretResolver returns a SecretResolver that looks up references with the client
+func NewSecretResolver(c *api.Client) *SecretResolver {
+	return &SecretResolver{
+		c:           c,
+		names:       map[string]string{},
+		credentials: map[string]*Credential{},
+	}
+}
+
+// Resolve returns the value of the field a passbolt:// reference points to
+func (r *SecretResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	parsed, err := ParseSecretRef(ref)
+	if err != nil {
+		return "", err
+	}
+	return r.ResolveRef(ctx, parsed)
+}
+
+// ResolveRef returns the value of the referenced field. TOTPs are generated for the current time
+func (r *SecretResolver) ResolveRef(ctx context.Context, ref SecretRef) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resourceID := ref.ID
+	if resourceID == "" {
+		var err error
+		resourceID, err = r.findResource(ctx, ref.FolderPath, ref.Name)
+		if err != nil {
+			return "", fmt.Errorf("%v: %w", ref, err)
+		}
+	}
+	credential, err := r.credential(ctx, resourceID)
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", ref, err)
+	}
+	value, err := secretRefField(credential, ref.Field, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", ref, err)
+	}
+	return value, nil
+}
+
+// FuncMap returns the template function "passbolt", which resolves a reference, for use with text/template
+func (r *SecretResolver) FuncMap(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"passbolt": func(ref string) (string, error) {
+			return r.Resolve(ctx, ref)
+		},
+	}
+}
+
+// Inject copies in to out with every passbolt:// reference replaced by its value.
+// Nothing is written if a reference can't be resolved
+func (r *SecretResolver) Inject(ctx context.Context, in io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("reading input: %w", err)
+	}
+	var resolveErr error
+	result := secretRefPattern.ReplaceAllStringFunc(string(data), func(ref string) string {
+		if resolveErr != nil {
+			return ""
+		}
+		value, err := r.Resolve(ctx, ref)
+		if err != nil {
+			resolveErr = err
+		}
+		return value
+	})
+	if resolveErr != nil {
+		return resolveErr
+	}
+	_, err = io.WriteString(out, result)
+	if err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+// findResource returns the ID of the only Resource with the name in the Folder at the path
+func (r *SecretResolver) findResource(ctx context.Context, folderPath []string, name string) (string, error) {
+	if r.tree == nil {
+		err := r.load(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	folderID := ""
+	for _, folderName := range folderPath {
+		id, ok := r.tree.children[folderID][folderName]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", ErrFolderNotFound, folderName)
+		}
+		folderID = id
+	}
+	matches := []string{}
+	for _, resource := range r.resources {
+		if resource.FolderParentID != folderID {
+			continue
+		}
+		if _, ok := r.names[resource.ID]; !ok {
+			r.names[resource.ID], _ = resourceNameAndURI(r.c, resource, r.rTypes)
+		}
+		if r.names[resource.ID] == name {
+			matches = append(matches, resource.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", ErrResourceNotFound
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: %v Resources are named %q", ErrSecretRefAmbiguous, len(matches), name)
+	}
+}
+
+// load gets the Folders and Resources path references are resolved against
+func (r *SecretResolver) load(ctx context.Context) error {
+	folders, err := r.c.GetFolders(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("getting Folders: %w", err)
+	}
+	tree := &folderTree{children: map[string]map[string]string{}, names: map[string]string{}, parents: map[string]string{}}
+	for _, folder := range folders {
+		parentID, name, err := GetFolderFromData(ctx, r.c, folder)
+		if err != nil {
+			return fmt.Errorf("folder %v: %w", folder.ID, err)
+		}
+		tree.add(folder.ID, parentID, name)
+	}
+	resources, err := r.c.GetResources(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("getting Resources: %w", err)
+	}
+	rTypes, err := r.c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return fmt.Errorf("getting Resource Types: %w", err)
+	}
+	r.tree, r.resources, r.rTypes = tree, resources, rTypes
+	return nil
+}
+
+// credential gets and decrypts a Resource, each Resource is only decrypted once
+func (r *SecretResolver) credential(ctx context.Context, resourceID string) (*Credential, error) {
+	if credential, ok := r.credentials[resourceID]; ok {
+		return credential, nil
+	}
+	resource, err := r.c.GetResource(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource: %w", err)
+	}
+	rType, err := r.c.GetResourceType(ctx, resource.ResourceTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Type: %w", err)
+	}
+	secret, err := r.c.GetSecret(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("getting Secret: %w", err)
+	}
+	metadataFields, secretFields, err := resourceFieldMaps(r.c, *resource, *secret, *rType, true)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := credentialFromFields(metadataFields, secretFields)
+	if err != nil {
+		return nil, err
+	}
+	r.credentials[resourceID] = credential
+	return credential, nil
+}
+
+// secretRefField returns a field of a credential, TOTPs are generated for when
+func secretRefField(credential *Credential, field string, when time.Time) (string, error) {
+	switch field {
+	case SecretRefFieldPassword:
+		return credential.Password, nil
+	case SecretRefFieldUsername:
+		return credential.Username, nil
+	case SecretRefFieldURI:
+		if len(credential.URIs) == 0 {
+			return "", nil
+		}
+		return credential.URIs[0], nil
+	case SecretRefFieldTOTP:
+		totp := credential.TOTP
+		if totp == nil {
+			return "", fmt.Errorf("%w: resource has no TOTP", ErrSecretRefField)
+		}
+		// GenerateOTPCode only supports the default parameters
+		if (totp.Algorithm != "" && !strings.EqualFold(totp.Algorithm, "SHA1")) || (totp.Digits != 0 && totp.Digits != codeLength) || (totp.Period != 0 && totp.Period != timeSplitInSeconds) {
+			return "", fmt.Errorf("%w: unsupported TOTP parameters", ErrSecretRefField)
+		}
+		return GenerateOTPCode(totp.SecretKey, when)
+	}
+	for _, customField := range credential.CustomFields {
+		if customField.Key == field {
+			if customField.Value == nil {
+				return "", nil
+			}
+			return fmt.Sprint(customField.Value), nil
+		}
+	}
+	return "", fmt.Errorf("%w: resource has no field %q", ErrSecretRefField, field)
+}
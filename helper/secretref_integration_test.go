@@ -0,0 +1,40 @@
+//go:build integration
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSecretResolver(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	folderID, err := CreateFolder(ctx, client, "", "secretref folder")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+	id, err := CreateResource(ctx, client, folderID, "secretref", "ref-user", "https://ref.example.com", "ref-secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	r := NewSecretResolver(client)
+	in := "url=passbolt://secretref%20folder/secretref#uri\nuser=passbolt://secretref%20folder/secretref#username\npass=passbolt://id/" + id + "\n"
+	var out bytes.Buffer
+	err = r.Inject(ctx, strings.NewReader(in), &out)
+	if err != nil {
+		t.Fatalf("Inject %v", err)
+	}
+	want := "url=https://ref.example.com\nuser=ref-user\npass=ref-secret\n"
+	if out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,156 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	tests := map[string]SecretRef{
+		"passbolt://Ops/Databases/db#username":                       {FolderPath: []string{"Ops", "Databases"}, Name: "db", Field: "username"},
+		"passbolt://db":                                              {FolderPath: []string{}, Name: "db", Field: "password"},
+		"passbolt://My%20Folder/a%2Fb#API%20Key":                     {FolderPath: []string{"My Folder"}, Name: "a/b", Field: "API Key"},
+		"passbolt://id/7f2a3c1e-5d4b-4a6e-9f8d-1c2b3a4d5e6f#totp":    {ID: "7f2a3c1e-5d4b-4a6e-9f8d-1c2b3a4d5e6f", Field: "totp"},
+		"passbolt://id/not-a-uuid":                                   {FolderPath: []string{"id"}, Name: "not-a-uuid", Field: "password"},
+		"passbolt://Ops/id/7f2a3c1e-5d4b-4a6e-9f8d-1c2b3a4d5e6f#uri": {FolderPath: []string{"Ops", "id"}, Name: "7f2a3c1e-5d4b-4a6e-9f8d-1c2b3a4d5e6f", Field: "uri"},
+	}
+	for ref, want := range tests {
+		got, err := ParseSecretRef(ref)
+		if err != nil {
+			t.Errorf("%v: %v", ref, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got %+v, want %+v", ref, got, want)
+		}
+		again, err := ParseSecretRef(got.String())
+		if err != nil || !reflect.DeepEqual(again, got) {
+			t.Errorf("%v: String %q parses to %+v, %v", ref, got.String(), again, err)
+		}
+	}
+
+	for _, ref := range []string{"https://db", "passbolt://", "passbolt:///#password", "passbolt://a/%zz"} {
+		_, err := ParseSecretRef(ref)
+		if !errors.Is(err, ErrInvalidSecretRef) {
+			t.Errorf("%v: got %v, want ErrInvalidSecretRef", ref, err)
+		}
+	}
+}
+
+func TestSecretRefField(t *testing.T) {
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	credential := &Credential{
+		Username: "admin",
+		URIs:     []string{"https://db", "https://db2"},
+		Password: "s3cret",
+		TOTP:     &api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DPEHPK3PXP", Digits: 6, Period: 30},
+		CustomFields: CustomFields{
+			{Key: "API Key", Type: CustomFieldTypePassword, Value: "key"},
+			{Key: "port", Type: CustomFieldTypeNumber, Value: float64(5432)},
+		},
+	}
+	code, err := GenerateOTPCode("JBSWY3DPEHPK3PXP", when)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"password": "s3cret",
+		"username": "admin",
+		"uri":      "https://db",
+		"totp":     code,
+		"API Key":  "key",
+		"port":     "5432",
+	}
+	for field, want := range tests {
+		got, err := secretRefField(credential, field, when)
+		if err != nil || got != want {
+			t.Errorf("%v: got %q, %v, want %q", field, got, err, want)
+		}
+	}
+
+	_, err = secretRefField(credential, "missing", when)
+	if !errors.Is(err, ErrSecretRefField) {
+		t.Errorf("missing field: got %v, want ErrSecretRefField", err)
+	}
+	credential.TOTP.Digits = 8
+	_, err = secretRefField(credential, "totp", when)
+	if !errors.Is(err, ErrSecretRefField) {
+		t.Errorf("8 digit TOTP: got %v, want ErrSecretRefField", err)
+	}
+	_, err = secretRefField(&Credential{}, "totp", when)
+	if !errors.Is(err, ErrSecretRefField) {
+		t.Errorf("no TOTP: got %v, want ErrSecretRefField", err)
+	}
+}
+
+// testSecretResolver is a SecretResolver with everything cached, so it never uses its client
+func testSecretResolver() *SecretResolver {
+	r := NewSecretResolver(nil)
+	r.tree = &folderTree{children: map[string]map[string]string{}, names: map[string]string{}, parents: map[string]string{}}
+	r.tree.add("f1", "", "Ops")
+	r.tree.add("f2", "f1", "My Folder")
+	r.resources = []api.Resource{
+		{ID: "r1", FolderParentID: "f1", Name: "db"},
+		{ID: "r2", FolderParentID: "f2", Name: "mail"},
+		{ID: "r3", FolderParentID: "", Name: "dup"},
+		{ID: "r4", FolderParentID: "", Name: "dup"},
+	}
+	r.rTypes = []api.ResourceType{}
+	r.credentials["r1"] = &Credential{Username: "admin", Password: "db-secret"}
+	r.credentials["r2"] = &Credential{Username: "mailer", Password: "mail-secret"}
+	return r
+}
+
+func TestSecretResolverInject(t *testing.T) {
+	ctx := context.TODO()
+	r := testSecretResolver()
+
+	in := "user: passbolt://Ops/db#username\npassword: \"passbolt://Ops/db\"\nmail: [passbolt://Ops/My%20Folder/mail#password, x]\n"
+	var out bytes.Buffer
+	err := r.Inject(ctx, strings.NewReader(in), &out)
+	if err != nil {
+		t.Fatalf("Inject: %v", err)
+	}
+	want := "user: admin\npassword: \"db-secret\"\nmail: [mail-secret, x]\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	err = r.Inject(ctx, strings.NewReader("a: passbolt://Ops/db\nb: passbolt://Ops/missing\n"), &out)
+	if !errors.Is(err, ErrResourceNotFound) || out.Len() != 0 {
+		t.Errorf("got %v and %q, want ErrResourceNotFound and no output", err, out.String())
+	}
+
+	_, err = r.Resolve(ctx, "passbolt://dup")
+	if !errors.Is(err, ErrSecretRefAmbiguous) {
+		t.Errorf("got %v, want ErrSecretRefAmbiguous", err)
+	}
+	_, err = r.Resolve(ctx, "passbolt://Missing/db")
+	if !errors.Is(err, ErrFolderNotFound) {
+		t.Errorf("got %v, want ErrFolderNotFound", err)
+	}
+}
+
+func TestSecretResolverFuncMap(t *testing.T) {
+	tmpl, err := template.New("config").Funcs(testSecretResolver().FuncMap(context.TODO())).Parse(`{{ passbolt "passbolt://Ops/db#username" }}:{{ passbolt "passbolt://Ops/db" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, nil)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.String() != "admin:db-secret" {
+		t.Errorf("got %q", out.String())
+	}
+}
@@ -182,6 +182,23 @@ func ShareFolder(ctx context.Context, c *api.Client, folderID string, changes []
 		return fmt.Errorf("generating Folder Permission Changes: %w", err)
 	}
 
+	// Like for Resources, v5 Metadata encrypted with our Personal Key needs to be moved to the Shared Metadata Key first
+	if oldFolder.Metadata != "" && oldFolder.MetadataKeyType == api.MetadataKeyTypeUserKey {
+		metadata, err := GetFolderMetadata(ctx, c, oldFolder)
+		if err != nil {
+			return fmt.Errorf("get Metadata: %w", err)
+		}
+		newFolder := api.Folder{}
+		err = setFolderMetadata(ctx, c, &newFolder, *metadata, false)
+		if err != nil {
+			return err
+		}
+		_, err = c.UpdateFolder(ctx, folderID, newFolder)
+		if err != nil {
+			return fmt.Errorf("update Folder Metadata to Shared key: %w", err)
+		}
+	}
+
 	err = c.ShareFolder(ctx, folderID, permissionChanges)
 	if err != nil {
 		return fmt.Errorf("sharing Folder: %w", err)
@@ -0,0 +1,195 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VaultSpec is the declared state of Folders and Resources and who they are shared with, see PlanVaultSpec
+type VaultSpec struct {
+	// Folders are managed: Resources in them that are not part of the spec are deleted
+	Folders   []SpecFolder   `json:"folders" yaml:"folders"`
+	Resources []SpecResource `json:"resources" yaml:"resources"`
+	// Dir is the directory relative secret files are read from, set by ReadVaultSpecFile
+	Dir string `json:"-" yaml:"-"`
+}
+
+// SpecFolder is a Folder of a VaultSpec
+type SpecFolder struct {
+	// Path is the "/" separated path from the root
+	Path string `json:"path" yaml:"path"`
+	// Permissions are not managed if nil
+	Permissions []SpecPermission `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+}
+
+// SpecResource is a Resource of a VaultSpec, identified by its Folder path and name.
+// Empty fields are not managed, they are left as they are
+type SpecResource struct {
+	Name string `json:"name" yaml:"name"`
+	// Folder is the "/" separated path of the parent Folder, empty for the root
+	Folder      string   `json:"folder,omitempty" yaml:"folder,omitempty"`
+	Username    string   `json:"username,omitempty" yaml:"username,omitempty"`
+	URIs        []string `json:"uris,omitempty" yaml:"uris,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	// Password is needed to create the Resource
+	Password *SpecSecret `json:"password,omitempty" yaml:"password,omitempty"`
+	// Permissions are not managed if nil
+	Permissions []SpecPermission `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+}
+
+// SpecSecret is where a secret is read from, exactly one source has to be set
+type SpecSecret struct {
+	// Env is the name of an environment variable
+	Env string `json:"env,omitempty" yaml:"env,omitempty"`
+	// File is read without its trailing newline, relative to the spec's Dir
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+	// Value is the secret itself, for tests and non-sensitive values
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// SpecPermission grants a User, by username, or a Group, by name, access
+type SpecPermission struct {
+	User  string `json:"user,omitempty" yaml:"user,omitempty"`
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+	// Permission is "read", "update" or "owner"
+	Permission string `json:"permission" yaml:"permission"`
+}
+
+// ReadVaultSpec reads a spec in YAML or JSON and validates it. Unknown keys are an error
+func ReadVaultSpec(r io.Reader) (*VaultSpec, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	spec := &VaultSpec{}
+	err := decoder.Decode(spec)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrSpecInvalid, err)
+	}
+	err = spec.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// ReadVaultSpecFile reads a spec file with ReadVaultSpec, secret files are relative to its directory
+func ReadVaultSpecFile(name string) (*VaultSpec, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening spec: %w", err)
+	}
+	defer f.Close()
+	spec, err := ReadVaultSpec(f)
+	if err != nil {
+		return nil, err
+	}
+	spec.Dir = filepath.Dir(name)
+	return spec, nil
+}
+
+// Validate checks names, paths, secret sources and permissions and that nothing is declared twice
+func (s *VaultSpec) Validate() error {
+	folders := map[string]bool{}
+	for i, folder := range s.Folders {
+		path := strings.Join(splitFolderPath(folder.Path, "/"), "/")
+		if path == "" {
+			return fmt.Errorf("%w: folder %v has no path", ErrSpecInvalid, i)
+		}
+		if folders[path] {
+			return fmt.Errorf("%w: folder %q is declared twice", ErrSpecInvalid, path)
+		}
+		folders[path] = true
+		err := validateSpecPermissions(folder.Permissions)
+		if err != nil {
+			return fmt.Errorf("folder %q: %w", path, err)
+		}
+	}
+
+	resources := map[string]bool{}
+	for i, resource := range s.Resources {
+		if resource.Name == "" {
+			return fmt.Errorf("%w: resource %v has no name", ErrSpecInvalid, i)
+		}
+		path := specResourcePath(resource)
+		if resources[path] {
+			return fmt.Errorf("%w: resource %q is declared twice", ErrSpecInvalid, path)
+		}
+		resources[path] = true
+		if resource.Password != nil {
+			sources := 0
+			for _, source := range []string{resource.Password.Env, resource.Password.File, resource.Password.Value} {
+				if source != "" {
+					sources++
+				}
+			}
+			if sources != 1 {
+				return fmt.Errorf("%w: resource %q: password needs exactly one of env, file or value", ErrSpecInvalid, path)
+			}
+		}
+		err := validateSpecPermissions(resource.Permissions)
+		if err != nil {
+			return fmt.Errorf("resource %q: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func validateSpecPermissions(permissions []SpecPermission) error {
+	for _, p := range permissions {
+		if (p.User == "") == (p.Group == "") {
+			return fmt.Errorf("%w: a permission needs either a user or a group", ErrSpecInvalid)
+		}
+		if specPermissionType(p.Permission) == 0 {
+			return fmt.Errorf("%w: unknown permission %q", ErrSpecInvalid, p.Permission)
+		}
+	}
+	return nil
+}
+
+// specPermissionType returns the permission Type of "read", "update" or "owner", 0 for anything else
+func specPermissionType(permission string) int {
+	switch permission {
+	case "read":
+		return 1
+	case "update":
+		return 7
+	case "owner":
+		return 15
+	default:
+		return 0
+	}
+}
+
+// specResourcePath is the normalized folder path and name of a resource
+func specResourcePath(resource SpecResource) string {
+	return strings.Join(append(splitFolderPath(resource.Folder, "/"), resource.Name), "/")
+}
+
+// resolve reads the secret from its source
+func (s SpecSecret) resolve(dir string) (string, error) {
+	switch {
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %v is not set", ErrSpecSecret, s.Env)
+		}
+		return value, nil
+	case s.File != "":
+		name := s.File
+		if !filepath.IsAbs(name) && dir != "" {
+			name = filepath.Join(dir, name)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrSpecSecret, err)
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+	default:
+		return s.Value, nil
+	}
+}
@@ -0,0 +1,121 @@
+package helper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testVaultSpecYAML = `
+folders:
+  - path: /Ops/Databases/
+    permissions:
+      - group: Ops
+        permission: owner
+resources:
+  - name: db
+    folder: Ops/Databases
+    username: admin
+    uris: [https://db.example.com]
+    password:
+      env: DB_PASSWORD
+    permissions:
+      - user: alice@example.com
+        permission: read
+  - name: notes
+    description: root level
+`
+
+func TestReadVaultSpec(t *testing.T) {
+	spec, err := ReadVaultSpec(strings.NewReader(testVaultSpecYAML))
+	if err != nil {
+		t.Fatalf("ReadVaultSpec: %v", err)
+	}
+	want := &VaultSpec{
+		Folders: []SpecFolder{{Path: "/Ops/Databases/", Permissions: []SpecPermission{{Group: "Ops", Permission: "owner"}}}},
+		Resources: []SpecResource{
+			{
+				Name:        "db",
+				Folder:      "Ops/Databases",
+				Username:    "admin",
+				URIs:        []string{"https://db.example.com"},
+				Password:    &SpecSecret{Env: "DB_PASSWORD"},
+				Permissions: []SpecPermission{{User: "alice@example.com", Permission: "read"}},
+			},
+			{Name: "notes", Description: "root level"},
+		},
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("got %+v\nwant %+v", spec, want)
+	}
+
+	// JSON is valid YAML
+	spec, err = ReadVaultSpec(strings.NewReader(`{"resources": [{"name": "db", "password": {"value": "x"}}]}`))
+	if err != nil {
+		t.Fatalf("ReadVaultSpec JSON: %v", err)
+	}
+	if len(spec.Resources) != 1 || spec.Resources[0].Password.Value != "x" {
+		t.Errorf("got %+v", spec)
+	}
+
+	spec, err = ReadVaultSpec(strings.NewReader(""))
+	if err != nil || len(spec.Resources) != 0 {
+		t.Errorf("empty spec: got %+v, %v", spec, err)
+	}
+}
+
+func TestReadVaultSpecErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":        "resources:\n  - name: db\n    pasword: {value: x}\n",
+		"no name":            "resources:\n  - username: x\n",
+		"duplicate resource": "resources:\n  - {name: db, folder: a}\n  - {name: db, folder: /a/}\n",
+		"no folder path":     "folders:\n  - path: /\n",
+		"duplicate folder":   "folders:\n  - path: a/b\n  - path: /a/b\n",
+		"two secret sources": "resources:\n  - name: db\n    password: {env: X, value: y}\n",
+		"no secret source":   "resources:\n  - name: db\n    password: {}\n",
+		"user and group":     "folders:\n  - path: a\n    permissions: [{user: a, group: b, permission: read}]\n",
+		"no principal":       "folders:\n  - path: a\n    permissions: [{permission: read}]\n",
+		"unknown permission": "resources:\n  - name: db\n    permissions: [{user: a, permission: write}]\n",
+	}
+	for name, spec := range tests {
+		_, err := ReadVaultSpec(strings.NewReader(spec))
+		if !errors.Is(err, ErrSpecInvalid) {
+			t.Errorf("%v: got %v, want ErrSpecInvalid", name, err)
+		}
+	}
+}
+
+func TestSpecSecretResolve(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("from-file\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SPEC_SECRET", "from-env")
+
+	tests := []struct {
+		secret SpecSecret
+		want   string
+	}{
+		{SpecSecret{Env: "TEST_SPEC_SECRET"}, "from-env"},
+		{SpecSecret{File: "secret.txt"}, "from-file"},
+		{SpecSecret{File: filepath.Join(dir, "secret.txt")}, "from-file"},
+		{SpecSecret{Value: "from-value"}, "from-value"},
+	}
+	for _, test := range tests {
+		got, err := test.secret.resolve(dir)
+		if err != nil || got != test.want {
+			t.Errorf("%+v: got %q, %v, want %q", test.secret, got, err, test.want)
+		}
+	}
+
+	for _, secret := range []SpecSecret{{Env: "TEST_SPEC_SECRET_UNSET"}, {File: "missing.txt"}} {
+		_, err := secret.resolve(dir)
+		if !errors.Is(err, ErrSpecSecret) {
+			t.Errorf("%+v: got %v, want ErrSpecSecret", secret, err)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package helper
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSHAgentKey is a key served by an SSHAgent
+type SSHAgentKey struct {
+	ResourceID string
+	// Name is the Resource name, used as the key comment
+	Name      string
+	PublicKey ssh.PublicKey
+}
+
+// SSHAgentOptions configure NewSSHAgent
+type SSHAgentOptions struct {
+	// FolderPath selects the Resources in the Folder at the "/" separated path
+	FolderPath string
+	// Tag selects the Resources with the Tag slug, with FolderPath only the ones in that Folder
+	Tag string
+	// KeyField is the field with the OpenSSH or PEM private key, "password" by default or the key of a custom field
+	KeyField string
+	// PassphraseField is the field with the passphrase of encrypted private keys, usually a custom field
+	PassphraseField string
+	// Confirm is called before each signature, the signature is refused if it returns false.
+	// ctx is cancelled when the agent is closed, a Confirm waiting for a user should return then
+	Confirm func(ctx context.Context, key SSHAgentKey) bool
+}
+
+// SSHAgent serves the ssh-agent protocol with private keys stored in Passbolt Resources.
+// Keys can't be added or removed through the protocol, locking the agent is supported.
+// The parsed private keys are zeroed by Close, the decrypted secrets they were parsed from are Go strings
+// that can't be overwritten and stay in memory until they are garbage collected
+type SSHAgent struct {
+	mu       sync.Mutex
+	keys     []*sshAgentKey
+	skipped  []ImportWarning
+	confirm  func(ctx context.Context, key SSHAgentKey) bool
+	locked   bool
+	lockHash [sha256.Size]byte
+
+	// ctx is cancelled by Close to end pending confirmations
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closed    bool
+	listeners []net.Listener
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+type sshAgentKey struct {
+	SSHAgentKey
+	// raw is the parsed private key, zeroed by Close
+	raw    any
+	signer ssh.Signer
+}
+
+var _ agent.ExtendedAgent = &SSHAgent{}
+
+// NewSSHAgent decrypts the private keys of the selected Resources. Resources without a usable private key are skipped, see Skipped
+func NewSSHAgent(ctx context.Context, c *api.Client, opts SSHAgentOptions) (*SSHAgent, error) {
+	if opts.FolderPath == "" && opts.Tag == "" {
+		return nil, ErrSSHAgentNoSelector
+	}
+	keyField := opts.KeyField
+	if keyField == "" {
+		keyField = SecretRefFieldPassword
+	}
+
+	getOpts := &api.GetResourcesOptions{
+		ContainSecret: true,
+		ContainTags:   opts.Tag != "",
+	}
+	if opts.FolderPath != "" {
+		tree, err := getFolderTree(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		folderID := ""
+		for _, name := range splitFolderPath(opts.FolderPath, "/") {
+			id, ok := tree.children[folderID][name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrFolderNotFound, opts.FolderPath)
+			}
+			folderID = id
+		}
+		getOpts.FilterHasParent = []string{folderID}
+	}
+	resources, err := c.GetResources(ctx, getOpts)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resources: %w", err)
+	}
+	rTypes, err := c.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource Types: %w", err)
+	}
+
+	a := newSSHAgent(opts.Confirm)
+	for _, resource := range resources {
+		if opts.Tag != "" {
+			tags, err := decryptTags(ctx, c, resource.Tags)
+			if err != nil {
+				return nil, fmt.Errorf("resource %v: %w", resource.ID, err)
+			}
+			if !slices.Contains(tagSlugs(tags), opts.Tag) {
+				continue
+			}
+		}
+		key, err := sshAgentKeyFromResource(c, resource, rTypes, keyField, opts.PassphraseField)
+		if err != nil {
+			name, _ := resourceNameAndURI(c, resource, rTypes)
+			a.skipped = append(a.skipped, ImportWarning{Name: name, Reason: err.Error()})
+			continue
+		}
+		a.keys = append(a.keys, key)
+	}
+	return a, nil
+}
+
+func newSSHAgent(confirm func(ctx context.Context, key SSHAgentKey) bool) *SSHAgent {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SSHAgent{
+		keys:    []*sshAgentKey{},
+		skipped: []ImportWarning{},
+		confirm: confirm,
+		ctx:     ctx,
+		cancel:  cancel,
+		conns:   map[net.Conn]struct{}{},
+	}
+}
+
+func sshAgentKeyFromResource(c *api.Client, resource api.Resource, rTypes []api.ResourceType, keyField, passphraseField string) (*sshAgentKey, error) {
+	rType, err := findBy(rTypes, func(t api.ResourceType) bool { return t.ID == resource.ResourceTypeID }, ErrResourceTypeSlugNotFound, resource.ResourceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(resource.Secrets) == 0 {
+		return nil, ErrSecretNotFound
+	}
+	metadataFields, secretFields, err := resourceFieldMaps(c, resource, resource.Secrets[0], *rType, true)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := credentialFromFields(metadataFields, secretFields)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := secretRefField(credential, keyField, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	passphrase := ""
+	if passphraseField != "" {
+		passphrase, err = secretRefField(credential, passphraseField, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseSSHAgentKey(resource.ID, credential.Name, []byte(privateKey), []byte(passphrase))
+}
+
+// parseSSHAgentKey parses a private key and zeroes pemBytes and passphrase, which only clears these copies
+func parseSSHAgentKey(resourceID, name string, pemBytes, passphrase []byte) (*sshAgentKey, error) {
+	defer clear(pemBytes)
+	defer clear(passphrase)
+
+	var raw any
+	var err error
+	if len(passphrase) > 0 {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase(pemBytes, passphrase)
+	} else {
+		raw, err = ssh.ParseRawPrivateKey(pemBytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing SSH private key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		zeroSSHPrivateKey(raw)
+		return nil, fmt.Errorf("parsing SSH private key: %w", err)
+	}
+	return &sshAgentKey{
+		SSHAgentKey: SSHAgentKey{ResourceID: resourceID, Name: name, PublicKey: signer.PublicKey()},
+		raw:         raw,
+		signer:      signer,
+	}, nil
+}
+
+// Keys returns the keys the agent serves
+func (a *SSHAgent) Keys() []SSHAgentKey {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	keys := []SSHAgentKey{}
+	for _, k := range a.keys {
+		keys = append(keys, k.SSHAgentKey)
+	}
+	return keys
+}
+
+// Skipped are the selected Resources whose private key could not be loaded
+func (a *SSHAgent) Skipped() []ImportWarning {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.skipped)
+}
+
+// List returns the public keys, none while the agent is locked
+func (a *SSHAgent) List() ([]*agent.Key, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	keys := []*agent.Key{}
+	if a.locked {
+		return keys, nil
+	}
+	for _, k := range a.keys {
+		keys = append(keys, &agent.Key{
+			Format:  k.PublicKey.Type(),
+			Blob:    k.PublicKey.Marshal(),
+			Comment: k.Name,
+		})
+	}
+	return keys, nil
+}
+
+// Sign signs data with the private key of the public key
+func (a *SSHAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags signs data like Sign, with the flags selecting SHA-256 or SHA-512 signatures for RSA keys.
+// The Confirm callback is called without holding the agent's lock, so it can wait for a user
+func (a *SSHAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	k, err := a.key(key)
+	if err != nil {
+		return nil, err
+	}
+	if a.confirm != nil && !a.confirm(a.ctx, k.SSHAgentKey) {
+		return nil, ErrSSHAgentRefused
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// The agent may have been closed or locked while waiting for confirmation
+	if a.closed {
+		return nil, ErrSSHAgentClosed
+	}
+	if a.locked {
+		return nil, ErrSSHAgentLocked
+	}
+	if flags == 0 {
+		return k.signer.Sign(rand.Reader, data)
+	}
+	algorithmSigner, ok := k.signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v keys do not support signature flags", ErrSSHAgentUnsupported, key.Type())
+	}
+	switch flags {
+	case agent.SignatureFlagRsaSha256:
+		return algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA256)
+	case agent.SignatureFlagRsaSha512:
+		return algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	default:
+		return nil, fmt.Errorf("%w: signature flags %v", ErrSSHAgentUnsupported, flags)
+	}
+}
+
+// key finds the key of a public key
+func (a *SSHAgent) key(key ssh.PublicKey) (*sshAgentKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil, ErrSSHAgentClosed
+	}
+	if a.locked {
+		return nil, ErrSSHAgentLocked
+	}
+	wanted := key.Marshal()
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(k.PublicKey.Marshal(), wanted) == 1 {
+			return k, nil
+		}
+	}
+	return nil, ErrSSHAgentKeyNotFound
+}
+
+// Add is not supported, the keys come from Passbolt
+func (a *SSHAgent) Add(key agent.AddedKey) error {
+	return ErrSSHAgentReadOnly
+}
+
+// Remove is not supported, the keys come from Passbolt
+func (a *SSHAgent) Remove(key ssh.PublicKey) error {
+	return ErrSSHAgentReadOnly
+}
+
+// RemoveAll is not supported, the keys come from Passbolt
+func (a *SSHAgent) RemoveAll() error {
+	return ErrSSHAgentReadOnly
+}
+
+// Lock hides the keys and refuses signatures until Unlock is called with the same passphrase
+func (a *SSHAgent) Lock(passphrase []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.locked {
+		return ErrSSHAgentLocked
+	}
+	a.locked, a.lockHash = true, sha256.Sum256(passphrase)
+	return nil
+}
+
+// Unlock undoes Lock
+func (a *SSHAgent) Unlock(passphrase []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hash := sha256.Sum256(passphrase)
+	if !a.locked || subtle.ConstantTimeCompare(hash[:], a.lockHash[:]) != 1 {
+		return ErrSSHAgentUnlock
+	}
+	a.locked, a.lockHash = false, [sha256.Size]byte{}
+	return nil
+}
+
+// Signers returns signers that sign through the agent, so they are subject to Lock and Confirm
+func (a *SSHAgent) Signers() ([]ssh.Signer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed || a.locked {
+		return nil, ErrSSHAgentLocked
+	}
+	signers := []ssh.Signer{}
+	for _, k := range a.keys {
+		signers = append(signers, sshAgentSigner{agent: a, publicKey: k.PublicKey})
+	}
+	return signers, nil
+}
+
+// Extension is not supported
+func (a *SSHAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// ListenAndServe serves the agent on a Unix socket at path that only the current user can access, see Serve.
+// The socket is removed when Serve returns
+func (a *SSHAgent) ListenAndServe(path string) error {
+	// Listening at path directly creates the socket with the umask's permissions, anyone could connect before a chmod.
+	// It is created in a private directory instead and linked to path once it is restricted
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".ssh-agent-")
+	if err != nil {
+		return fmt.Errorf("creating socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	private := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", private)
+	if err != nil {
+		return fmt.Errorf("listening: %w", err)
+	}
+	err = os.Chmod(private, 0o600)
+	if err == nil {
+		// Unlike a rename, linking fails if path exists
+		err = os.Link(private, path)
+	}
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("restricting socket: %w", err)
+	}
+	defer os.Remove(path)
+	return a.Serve(l)
+}
+
+// Serve serves the agent on the connections accepted by l until Close is called, which returns nil
+func (a *SSHAgent) Serve(l net.Listener) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		l.Close()
+		return ErrSSHAgentClosed
+	}
+	a.listeners = append(a.listeners, l)
+	a.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			a.mu.Lock()
+			closed := a.closed
+			a.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return fmt.Errorf("accepting: %w", err)
+		}
+
+		a.mu.Lock()
+		if a.closed {
+			a.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		a.conns[conn] = struct{}{}
+		a.wg.Add(1)
+		a.mu.Unlock()
+		go func() {
+			defer a.wg.Done()
+			// The connection ends when the client disconnects or Close closes it
+			_ = agent.ServeAgent(a, conn)
+			conn.Close()
+			a.mu.Lock()
+			delete(a.conns, conn)
+			a.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops serving, cancels pending confirmations, zeroes the private keys and waits for the connections to end
+func (a *SSHAgent) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.cancel()
+	for _, l := range a.listeners {
+		l.Close()
+	}
+	for conn := range a.conns {
+		conn.Close()
+	}
+	// Signatures hold the lock and check closed first, so the keys are no longer used
+	for _, k := range a.keys {
+		zeroSSHPrivateKey(k.raw)
+		k.raw, k.signer = nil, nil
+	}
+	a.keys = []*sshAgentKey{}
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	return nil
+}
+
+// sshAgentSigner signs through an SSHAgent
+type sshAgentSigner struct {
+	agent     *SSHAgent
+	publicKey ssh.PublicKey
+}
+
+func (s sshAgentSigner) PublicKey() ssh.PublicKey {
+	return s.publicKey
+}
+
+func (s sshAgentSigner) Sign(random io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.agent.Sign(s.publicKey, data)
+}
+
+// zeroSSHPrivateKey overwrites the private parts of a parsed key. Copies the crypto packages keep internally can't be reached
+func zeroSSHPrivateKey(raw any) {
+	zeroInt := func(i *big.Int) {
+		if i != nil {
+			clear(i.Bits())
+			i.SetInt64(0)
+		}
+	}
+	switch k := raw.(type) {
+	case *rsa.PrivateKey:
+		zeroInt(k.D)
+		for _, p := range k.Primes {
+			zeroInt(p)
+		}
+		zeroInt(k.Precomputed.Dp)
+		zeroInt(k.Precomputed.Dq)
+		zeroInt(k.Precomputed.Qinv)
+	case *ecdsa.PrivateKey:
+		zeroInt(k.D)
+	case *ed25519.PrivateKey:
+		clear(*k)
+	case ed25519.PrivateKey:
+		clear(k)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestNewSSHAgent(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	folderID, err := CreateFolder(ctx, client, "", "ssh-keys")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, folderID) }()
+	keyID, err := CreateResource(ctx, client, folderID, "deploy key", "", "", string(pem.EncodeToMemory(block)), "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, keyID) }()
+	otherID, err := CreateResource(ctx, client, folderID, "not a key", "", "", "hunter2", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, otherID) }()
+	err = AddResourceTags(ctx, client, keyID, "ssh")
+	if err != nil {
+		t.Fatalf("Tagging Resource %v", err)
+	}
+
+	a, err := NewSSHAgent(ctx, client, SSHAgentOptions{FolderPath: "ssh-keys"})
+	if err != nil {
+		t.Fatalf("NewSSHAgent %v", err)
+	}
+	defer a.Close()
+	keys := a.Keys()
+	if len(keys) != 1 || keys[0].ResourceID != keyID || keys[0].Name != "deploy key" {
+		t.Fatalf("got keys %+v", keys)
+	}
+	if skipped := a.Skipped(); len(skipped) != 1 || skipped[0].Name != "not a key" {
+		t.Fatalf("got skipped %+v", skipped)
+	}
+	signature, err := a.Sign(keys[0].PublicKey, []byte("data"))
+	if err != nil {
+		t.Fatalf("Sign %v", err)
+	}
+	err = keys[0].PublicKey.Verify([]byte("data"), signature)
+	if err != nil {
+		t.Fatalf("Verify %v", err)
+	}
+
+	tagged, err := NewSSHAgent(ctx, client, SSHAgentOptions{Tag: "ssh"})
+	if err != nil {
+		t.Fatalf("NewSSHAgent %v", err)
+	}
+	defer tagged.Close()
+	if keys := tagged.Keys(); len(keys) != 1 || keys[0].ResourceID != keyID {
+		t.Fatalf("got tagged keys %+v", keys)
+	}
+}
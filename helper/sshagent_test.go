@@ -0,0 +1,234 @@
+package helper
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func testSSHAgentKeys(t *testing.T) (*sshAgentKey, *sshAgentKey) {
+	t.Helper()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(edKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := parseSSHAgentKey("r1", "ed25519 key", pem.EncodeToMemory(block), nil)
+	if err != nil {
+		t.Fatalf("parseSSHAgentKey ed25519: %v", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err = ssh.MarshalPrivateKeyWithPassphrase(rsaKey, "", []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := pem.EncodeToMemory(block)
+	_, err = parseSSHAgentKey("r2", "rsa key", encrypted, nil)
+	if err == nil {
+		t.Fatalf("parsed an encrypted key without passphrase")
+	}
+	encrypted = pem.EncodeToMemory(block)
+	r, err := parseSSHAgentKey("r2", "rsa key", encrypted, []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("parseSSHAgentKey rsa: %v", err)
+	}
+	for _, b := range encrypted {
+		if b != 0 {
+			t.Fatalf("key text was not zeroed")
+		}
+	}
+	return ed, r
+}
+
+func TestSSHAgent(t *testing.T) {
+	ed, r := testSSHAgentKeys(t)
+	var confirmed atomic.Int32
+	refuse := atomic.Bool{}
+	a := newSSHAgent(func(ctx context.Context, key SSHAgentKey) bool {
+		confirmed.Add(1)
+		return !refuse.Load()
+	})
+	a.keys = append(a.keys, ed, r)
+
+	// Unix socket paths are limited to about 100 bytes, t.TempDir can be longer
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+	served := make(chan error, 1)
+	go func() { served <- a.ListenAndServe(socket) }()
+	var conn net.Conn
+	for conn == nil {
+		conn, _ = net.Dial("unix", socket)
+	}
+	defer conn.Close()
+	info, err := os.Stat(socket)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode: %v, %v", info, err)
+	}
+	// An existing file is not replaced
+	if err := newSSHAgent(nil).ListenAndServe(socket); err == nil {
+		t.Errorf("listened at an existing path")
+	}
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(keys) != 2 || keys[0].Comment != "ed25519 key" || keys[1].Comment != "rsa key" {
+		t.Fatalf("got %v", keys)
+	}
+
+	data := []byte("session data")
+	for _, key := range keys {
+		signature, err := client.Sign(key, data)
+		if err != nil {
+			t.Fatalf("Sign %v: %v", key.Comment, err)
+		}
+		err = key.Verify(data, signature)
+		if err != nil {
+			t.Errorf("Verify %v: %v", key.Comment, err)
+		}
+	}
+	signature, err := client.SignWithFlags(keys[1], data, agent.SignatureFlagRsaSha512)
+	if err != nil {
+		t.Fatalf("SignWithFlags: %v", err)
+	}
+	if signature.Format != ssh.KeyAlgoRSASHA512 || keys[1].Verify(data, signature) != nil {
+		t.Errorf("got signature %v", signature.Format)
+	}
+	if confirmed.Load() != 3 {
+		t.Errorf("confirmed %v signatures, want 3", confirmed.Load())
+	}
+
+	refuse.Store(true)
+	_, err = client.Sign(keys[0], data)
+	if err == nil {
+		t.Errorf("signed without confirmation")
+	}
+	refuse.Store(false)
+
+	err = client.Lock([]byte("lock"))
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	keys, err = client.List()
+	if err != nil || len(keys) != 0 {
+		t.Errorf("locked List: got %v, %v", keys, err)
+	}
+	if client.Unlock([]byte("wrong")) == nil {
+		t.Errorf("unlocked with the wrong passphrase")
+	}
+	err = client.Unlock([]byte("lock"))
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	if client.RemoveAll() == nil {
+		t.Errorf("removed keys from a read-only agent")
+	}
+
+	edRaw := ed.raw.(*ed25519.PrivateKey)
+	err = a.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("ListenAndServe: %v", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("left behind %v, %v", entries, err)
+	}
+	for _, b := range *edRaw {
+		if b != 0 {
+			t.Fatalf("key was not zeroed")
+		}
+	}
+	if r.raw != nil || len(a.Keys()) != 0 {
+		t.Errorf("keys kept after Close")
+	}
+	_, err = client.List()
+	if err == nil {
+		t.Errorf("connection still served after Close")
+	}
+	_, err = a.Sign(ed.PublicKey, data)
+	if !errors.Is(err, ErrSSHAgentClosed) {
+		t.Errorf("got %v, want ErrSSHAgentClosed", err)
+	}
+}
+
+func TestSSHAgentCloseWhileConfirming(t *testing.T) {
+	ed, _ := testSSHAgentKeys(t)
+	confirming := make(chan struct{})
+	a := newSSHAgent(func(ctx context.Context, key SSHAgentKey) bool {
+		close(confirming)
+		<-ctx.Done()
+		return true
+	})
+	a.keys = append(a.keys, ed)
+
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+	served := make(chan error, 1)
+	go func() { served <- a.ListenAndServe(socket) }()
+	var conn net.Conn
+	for conn == nil {
+		conn, _ = net.Dial("unix", socket)
+	}
+	defer conn.Close()
+	signed := make(chan error, 1)
+	go func() {
+		_, err := agent.NewClient(conn).Sign(ed.PublicKey, []byte("data"))
+		signed <- err
+	}()
+	<-confirming
+
+	edRaw := ed.raw.(*ed25519.PrivateKey)
+	closed := make(chan error, 1)
+	go func() { closed <- a.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waits for the pending confirmation")
+	}
+	for _, b := range *edRaw {
+		if b != 0 {
+			t.Fatalf("key was not zeroed")
+		}
+	}
+	if err := <-signed; err == nil {
+		t.Errorf("signed after Close")
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
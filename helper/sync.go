@@ -0,0 +1,455 @@
+package helper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// SyncStateVersion is the version of the sync state format written by WriteSyncStateFile
+const SyncStateVersion = 1
+
+// SyncState maps the source's Folders and Resources to the ones SyncVault created on the destination.
+// The hashes are of decrypted data, so the state should be kept as private as the secrets
+type SyncState struct {
+	Version int `json:"version"`
+	// Folders and Resources are keyed by source ID
+	Folders   map[string]SyncStateEntry `json:"folders"`
+	Resources map[string]SyncStateEntry `json:"resources"`
+}
+
+// SyncStateEntry is a synchronized Folder or Resource
+type SyncStateEntry struct {
+	// ID is the ID on the destination
+	ID string `json:"id"`
+	// Modified is when the source was last modified at the time it was synchronized
+	Modified time.Time `json:"modified,omitzero"`
+	// Hash is the SHA-256 of the decrypted source data at the time it was synchronized
+	Hash string `json:"hash"`
+}
+
+// SyncFolderSelector decides by its "/" separated path whether a source Folder is synchronized, the root has the path ""
+type SyncFolderSelector func(path string) bool
+
+// SyncOptions configure SyncVault
+type SyncOptions struct {
+	// Folders selects the source Folders, Resources are synchronized with their Folder. Resources in the root are synchronized to FolderParentID if "" is selected
+	Folders SyncFolderSelector
+	// FolderParentID is the destination Folder selected Folders are created in if their parent is not selected, empty for the root
+	FolderParentID string
+}
+
+// SyncResult is a change SyncVault made or failed to make on the destination
+type SyncResult struct {
+	// Kind is "folder" or "resource"
+	Kind   string     `json:"kind"`
+	Action PlanAction `json:"action"`
+	// SourceID is the ID on the source, ID the one on the destination
+	SourceID string `json:"source_id"`
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SyncReport is the result of SyncVault
+type SyncReport struct {
+	Results []SyncResult `json:"results"`
+	// Unchanged is the number of Folders and Resources that were already up to date
+	Unchanged int `json:"unchanged"`
+}
+
+// NewSyncState returns an empty SyncState for a first synchronization
+func NewSyncState() *SyncState {
+	return &SyncState{
+		Version:   SyncStateVersion,
+		Folders:   map[string]SyncStateEntry{},
+		Resources: map[string]SyncStateEntry{},
+	}
+}
+
+// ReadSyncStateFile reads a SyncState written by WriteSyncStateFile, a missing file is an empty state
+func ReadSyncStateFile(name string) (*SyncState, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewSyncState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading sync state: %w", err)
+	}
+	state := NewSyncState()
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sync state: %w", err)
+	}
+	if state.Version != SyncStateVersion {
+		return nil, fmt.Errorf("%w: %v", ErrSyncStateVersion, state.Version)
+	}
+	return state, nil
+}
+
+// WriteSyncStateFile atomically replaces the state file, it is only readable by the owner
+func WriteSyncStateFile(name string, state *SyncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling sync state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating sync state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing sync state: %w", err)
+	}
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return fmt.Errorf("replacing sync state: %w", err)
+	}
+	return nil
+}
+
+// SyncFolderPaths selects the Folders at the "/" separated paths and everything below them, "/" selects everything including the root
+func SyncFolderPaths(paths ...string) SyncFolderSelector {
+	selected := []string{}
+	for _, p := range paths {
+		selected = append(selected, strings.Join(splitFolderPath(p, "/"), "/"))
+	}
+	return func(path string) bool {
+		return slices.ContainsFunc(selected, func(s string) bool {
+			return s == "" || path == s || strings.HasPrefix(path, s+"/")
+		})
+	}
+}
+
+// SyncVault makes the selected Folders and their Resources on the destination match the source.
+// Resources are created on the destination with its metadata keys and its User as owner, permissions are not synchronized.
+// Resources whose Modified time did not change since the last run are not decrypted, the others are compared by a hash of their data.
+// Folders and Resources that were synchronized before but are no longer selected or were deleted are deleted on the destination.
+// Failed changes are reported and left out of the state so the next run retries them, only errors reading either server are returned
+func SyncVault(ctx context.Context, src, dst *api.Client, state *SyncState, opts SyncOptions) (*SyncReport, error) {
+	if opts.Folders == nil {
+		return nil, ErrSyncNoFolders
+	}
+	if state.Folders == nil {
+		state.Folders = map[string]SyncStateEntry{}
+	}
+	if state.Resources == nil {
+		state.Resources = map[string]SyncStateEntry{}
+	}
+	state.Version = SyncStateVersion
+
+	srcFolders, err := src.GetFolders(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting source Folders: %w", err)
+	}
+	srcResources, err := src.GetResources(ctx, &api.GetResourcesOptions{
+		ContainSecret: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting source Resources: %w", err)
+	}
+	srcTypes, err := src.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting source Resource Types: %w", err)
+	}
+	dstFolderList, err := dst.GetFolders(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting destination Folders: %w", err)
+	}
+	dstResourceList, err := dst.GetResources(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting destination Resources: %w", err)
+	}
+	dstTypes, err := dst.GetResourceTypesCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting destination Resource Types: %w", err)
+	}
+	dstFolders := map[string]api.Folder{}
+	for _, f := range dstFolderList {
+		dstFolders[f.ID] = f
+	}
+	dstResources := map[string]api.Resource{}
+	for _, r := range dstResourceList {
+		dstResources[r.ID] = r
+	}
+
+	tree := &folderTree{children: map[string]map[string]string{}, names: map[string]string{}, parents: map[string]string{}}
+	for _, f := range srcFolders {
+		parentID, name, err := GetFolderFromData(ctx, src, f)
+		if err != nil {
+			return nil, fmt.Errorf("source folder %v: %w", f.ID, err)
+		}
+		tree.add(f.ID, parentID, name)
+	}
+	// Parents before their children
+	selected := []string{}
+	paths := map[string][]string{}
+	for _, f := range srcFolders {
+		paths[f.ID] = tree.path(f.ID)
+		if opts.Folders(strings.Join(paths[f.ID], "/")) {
+			selected = append(selected, f.ID)
+		}
+	}
+	slices.SortFunc(selected, func(a, b string) int {
+		return cmpFolderPaths(paths[a], paths[b])
+	})
+
+	report := &SyncReport{Results: []SyncResult{}}
+	isSelected := map[string]bool{}
+	for _, id := range selected {
+		isSelected[id] = true
+	}
+
+	for _, id := range selected {
+		result := syncFolder(ctx, dst, state, id, tree.names[id], tree.parents[id], isSelected, dstFolders, opts)
+		if result == nil {
+			report.Unchanged++
+			continue
+		}
+		report.Results = append(report.Results, *result)
+	}
+
+	// The root is no Folder, its Resources are synchronized to FolderParentID
+	rootSelected := opts.Folders("")
+	synced := map[string]bool{}
+	for _, r := range srcResources {
+		if !isSelected[r.FolderParentID] && (r.FolderParentID != "" || !rootSelected) {
+			continue
+		}
+		synced[r.ID] = true
+		results := syncResource(ctx, src, dst, state, r, srcTypes, dstTypes, dstResources, opts)
+		if len(results) == 0 {
+			report.Unchanged++
+		}
+		report.Results = append(report.Results, results...)
+	}
+
+	// Resources before Folders, deleting a Folder moves what is left in it to the root
+	for _, sourceID := range slices.Sorted(maps.Keys(state.Resources)) {
+		if synced[sourceID] {
+			continue
+		}
+		entry := state.Resources[sourceID]
+		if _, ok := dstResources[entry.ID]; !ok {
+			delete(state.Resources, sourceID)
+			continue
+		}
+		result := SyncResult{Kind: "resource", Action: PlanActionDelete, SourceID: sourceID, ID: entry.ID}
+		err := DeleteResource(ctx, dst, entry.ID)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			delete(state.Resources, sourceID)
+		}
+		report.Results = append(report.Results, result)
+	}
+	for _, sourceID := range slices.Sorted(maps.Keys(state.Folders)) {
+		if isSelected[sourceID] {
+			continue
+		}
+		entry := state.Folders[sourceID]
+		if _, ok := dstFolders[entry.ID]; !ok {
+			delete(state.Folders, sourceID)
+			continue
+		}
+		result := SyncResult{Kind: "folder", Action: PlanActionDelete, SourceID: sourceID, ID: entry.ID}
+		err := DeleteFolder(ctx, dst, entry.ID)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			delete(state.Folders, sourceID)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// syncFolder creates, renames or moves the destination Folder of a source Folder, nil if it is up to date
+func syncFolder(ctx context.Context, dst *api.Client, state *SyncState, sourceID, name, sourceParentID string, isSelected map[string]bool, dstFolders map[string]api.Folder, opts SyncOptions) *SyncResult {
+	result := &SyncResult{Kind: "folder", SourceID: sourceID, Name: name}
+	parentID := opts.FolderParentID
+	if isSelected[sourceParentID] {
+		parent, ok := state.Folders[sourceParentID]
+		if !ok {
+			result.Action = PlanActionCreate
+			result.Error = "parent Folder is not synchronized"
+			return result
+		}
+		parentID = parent.ID
+	}
+	hash := syncHash(name)
+
+	entry, ok := state.Folders[sourceID]
+	existing, exists := dstFolders[entry.ID]
+	if !ok || !exists {
+		result.Action = PlanActionCreate
+		id, err := CreateFolder(ctx, dst, parentID, name)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.ID = id
+		state.Folders[sourceID] = SyncStateEntry{ID: id, Hash: hash}
+		return result
+	}
+
+	result.ID = entry.ID
+	switch {
+	case entry.Hash != hash:
+		result.Action = PlanActionUpdate
+		err := UpdateFolder(ctx, dst, entry.ID, name)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		entry.Hash = hash
+	case existing.FolderParentID != parentID:
+		result.Action = PlanActionMove
+	default:
+		return nil
+	}
+	if existing.FolderParentID != parentID {
+		err := MoveFolder(ctx, dst, entry.ID, parentID)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+	state.Folders[sourceID] = entry
+	return result
+}
+
+// syncResource creates, updates or moves the destination Resource of a source Resource, nothing if it is up to date
+func syncResource(ctx context.Context, src, dst *api.Client, state *SyncState, r api.Resource, srcTypes, dstTypes []api.ResourceType, dstResources map[string]api.Resource, opts SyncOptions) []SyncResult {
+	result := SyncResult{Kind: "resource", SourceID: r.ID}
+	parentID := opts.FolderParentID
+	if r.FolderParentID != "" {
+		parent, ok := state.Folders[r.FolderParentID]
+		if !ok {
+			result.Action = PlanActionCreate
+			result.Error = "parent Folder is not synchronized"
+			return []SyncResult{result}
+		}
+		parentID = parent.ID
+	}
+	var modified time.Time
+	if r.Modified != nil {
+		modified = r.Modified.Time
+	}
+
+	entry, ok := state.Resources[r.ID]
+	existing, exists := dstResources[entry.ID]
+	changed := !ok || !exists || modified.IsZero() || !modified.Equal(entry.Modified)
+
+	results := []SyncResult{}
+	if changed {
+		slug, metadataFields, secretFields, err := syncResourceFields(src, r, srcTypes)
+		if err != nil {
+			result.Action = PlanActionUpdate
+			result.Error = err.Error()
+			return []SyncResult{result}
+		}
+		result.Name = GetStringField(metadataFields, "name")
+		hash := syncHash(map[string]any{"resource_type": slug, "metadata": metadataFields, "secret": secretFields})
+
+		// A Resource that changed its Resource Type is replaced, updates keep the type
+		replace := ok && exists && hash != entry.Hash
+		if replace {
+			dstType, err := findBy(dstTypes, func(t api.ResourceType) bool { return t.ID == existing.ResourceTypeID }, ErrResourceTypeSlugNotFound, existing.ResourceTypeID)
+			if err == nil && dstType.Slug == slug {
+				result.Action, result.ID = PlanActionUpdate, entry.ID
+				err = updateResource(ctx, dst, &existing, dstType, metadataFields, secretFields, dropAllFields)
+				if err != nil {
+					result.Error = err.Error()
+					return []SyncResult{result}
+				}
+				replace = false
+			}
+		}
+		if replace {
+			err := DeleteResource(ctx, dst, entry.ID)
+			if err != nil {
+				result.Action, result.ID, result.Error = PlanActionDelete, entry.ID, err.Error()
+				return []SyncResult{result}
+			}
+			results = append(results, SyncResult{Kind: "resource", Action: PlanActionDelete, SourceID: r.ID, ID: entry.ID, Name: result.Name})
+		}
+		if !ok || !exists || replace {
+			result.Action = PlanActionCreate
+			id, err := CreateResourceGeneric(ctx, dst, slug, parentID, metadataFields, secretFields)
+			if err != nil {
+				delete(state.Resources, r.ID)
+				result.Error = err.Error()
+				return append(results, result)
+			}
+			result.ID = id
+			state.Resources[r.ID] = SyncStateEntry{ID: id, Modified: modified, Hash: hash}
+			return append(results, result)
+		}
+		if result.Action != "" {
+			results = append(results, result)
+		}
+		entry.Modified, entry.Hash = modified, hash
+		state.Resources[r.ID] = entry
+	}
+
+	if existing.FolderParentID != parentID {
+		move := SyncResult{Kind: "resource", Action: PlanActionMove, SourceID: r.ID, ID: entry.ID, Name: result.Name}
+		err := MoveResource(ctx, dst, entry.ID, parentID)
+		if err != nil {
+			move.Error = err.Error()
+		}
+		results = append(results, move)
+	}
+	return results
+}
+
+// syncResourceFields decrypts a source Resource into the fields it is created with on the destination
+func syncResourceFields(src *api.Client, r api.Resource, srcTypes []api.ResourceType) (string, map[string]any, map[string]any, error) {
+	rType, err := findBy(srcTypes, func(t api.ResourceType) bool { return t.ID == r.ResourceTypeID }, ErrResourceTypeSlugNotFound, r.ResourceTypeID)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(r.Secrets) == 0 {
+		return "", nil, nil, ErrSecretNotFound
+	}
+	metadataFields, secretFields, err := resourceFieldMaps(src, r, r.Secrets[0], *rType, true)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	// Set again for the destination's Resource Type
+	delete(metadataFields, "object_type")
+	delete(metadataFields, "resource_type_id")
+	delete(secretFields, "object_type")
+	return rType.Slug, metadataFields, secretFields, nil
+}
+
+// syncHash is the hex SHA-256 of the JSON encoding of v, map keys are encoded sorted so equal data has equal hashes
+func syncHash(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cmpFolderPaths orders Folder paths parents first
+func cmpFolderPaths(a, b []string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return slices.Compare(a, b)
+}
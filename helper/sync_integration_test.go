@@ -0,0 +1,146 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSyncVault(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	// The test server is both source and destination, the copies are made in another Folder
+	srcID, err := CreateFolder(ctx, client, "", "sync-source")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, srcID) }()
+	subID, err := CreateFolder(ctx, client, srcID, "sub")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, subID) }()
+	resourceID, err := CreateResource(ctx, client, subID, "sync", "sync-user", "https://sync.example.com", "sync-secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, resourceID) }()
+	dstID, err := CreateFolder(ctx, client, "", "sync-destination")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, dstID) }()
+
+	state := NewSyncState()
+	opts := SyncOptions{Folders: SyncFolderPaths("sync-source"), FolderParentID: dstID}
+	report, err := SyncVault(ctx, client, client, state, opts)
+	if err != nil {
+		t.Fatalf("Syncing %v", err)
+	}
+	for _, entry := range state.Resources {
+		defer func() { _ = DeleteResource(ctx, client, entry.ID) }()
+	}
+	for _, entry := range state.Folders {
+		defer func() { _ = DeleteFolder(ctx, client, entry.ID) }()
+	}
+	if len(report.Results) != 3 || len(state.Folders) != 2 || len(state.Resources) != 1 {
+		t.Fatalf("got %+v", report)
+	}
+	for _, result := range report.Results {
+		if result.Error != "" || result.Action != PlanActionCreate {
+			t.Fatalf("got %+v", result)
+		}
+	}
+	copyID := state.Resources[resourceID].ID
+	folderParentID, name, username, _, password, _, err := GetResource(ctx, client, copyID)
+	if err != nil {
+		t.Fatalf("Getting copy %v", err)
+	}
+	if folderParentID != state.Folders[subID].ID || name != "sync" || username != "sync-user" || password != "sync-secret" {
+		t.Fatalf("got %q %q %q %q", folderParentID, name, username, password)
+	}
+
+	// A rerun without changes does nothing
+	report, err = SyncVault(ctx, client, client, state, opts)
+	if err != nil {
+		t.Fatalf("Syncing %v", err)
+	}
+	if len(report.Results) != 0 || report.Unchanged != 3 {
+		t.Fatalf("got %+v", report)
+	}
+
+	err = UpdateResource(ctx, client, resourceID, "", "", "", "rotated-secret", "")
+	if err != nil {
+		t.Fatalf("Updating Resource %v", err)
+	}
+	report, err = SyncVault(ctx, client, client, state, opts)
+	if err != nil {
+		t.Fatalf("Syncing %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Action != PlanActionUpdate || report.Results[0].Error != "" {
+		t.Fatalf("got %+v", report)
+	}
+	_, _, _, _, password, _, err = GetResource(ctx, client, copyID)
+	if err != nil || password != "rotated-secret" {
+		t.Fatalf("got %q, %v", password, err)
+	}
+
+	err = DeleteResource(ctx, client, resourceID)
+	if err != nil {
+		t.Fatalf("Deleting Resource %v", err)
+	}
+	report, err = SyncVault(ctx, client, client, state, opts)
+	if err != nil {
+		t.Fatalf("Syncing %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Action != PlanActionDelete || len(state.Resources) != 0 {
+		t.Fatalf("got %+v", report)
+	}
+}
+
+func TestSyncVaultRoot(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	resourceID, err := CreateResource(ctx, client, "", "sync-root", "", "", "root-secret", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, resourceID) }()
+	dstID, err := CreateFolder(ctx, client, "", "sync-root-destination")
+	if err != nil {
+		t.Fatalf("Creating Folder %v", err)
+	}
+	defer func() { _ = DeleteFolder(ctx, client, dstID) }()
+
+	// Only the root, its copies in the destination Folder are not synchronized again
+	state := NewSyncState()
+	opts := SyncOptions{Folders: func(path string) bool { return path == "" }, FolderParentID: dstID}
+	_, err = SyncVault(ctx, client, client, state, opts)
+	if err != nil {
+		t.Fatalf("Syncing %v", err)
+	}
+	for _, entry := range state.Resources {
+		defer func() { _ = DeleteResource(ctx, client, entry.ID) }()
+	}
+	if len(state.Folders) != 0 {
+		t.Fatalf("got Folders %+v", state.Folders)
+	}
+	entry, ok := state.Resources[resourceID]
+	if !ok {
+		t.Fatalf("root Resource not synchronized: %+v", state.Resources)
+	}
+	folderParentID, name, _, _, password, _, err := GetResource(ctx, client, entry.ID)
+	if err != nil {
+		t.Fatalf("Getting copy %v", err)
+	}
+	if folderParentID != dstID || name != "sync-root" || password != "root-secret" {
+		t.Fatalf("got %q %q %q", folderParentID, name, password)
+	}
+}
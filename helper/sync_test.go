@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSyncFolderPaths(t *testing.T) {
+	selector := SyncFolderPaths("/Prod/Databases/", "Shared")
+	tests := map[string]bool{
+		"Prod":                  false,
+		"Prod/Databases":        true,
+		"Prod/Databases/Backup": true,
+		"Prod/DatabasesOld":     false,
+		"Shared":                true,
+		"Other/Shared":          false,
+	}
+	for path, want := range tests {
+		if got := selector(path); got != want {
+			t.Errorf("%v: got %v, want %v", path, got, want)
+		}
+	}
+	if !SyncFolderPaths("/")("anything/below") || !SyncFolderPaths("/")("") {
+		t.Errorf("\"/\" does not select everything")
+	}
+	if selector("") {
+		t.Errorf("the root is selected")
+	}
+}
+
+func TestSyncStateFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "sync.json")
+	state, err := ReadSyncStateFile(name)
+	if err != nil {
+		t.Fatalf("ReadSyncStateFile missing file: %v", err)
+	}
+	if !reflect.DeepEqual(state, NewSyncState()) {
+		t.Errorf("got %+v", state)
+	}
+
+	state.Folders["f1"] = SyncStateEntry{ID: "f2", Hash: syncHash("Ops")}
+	state.Resources["r1"] = SyncStateEntry{ID: "r2", Modified: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Hash: "abc"}
+	err = WriteSyncStateFile(name, state)
+	if err != nil {
+		t.Fatalf("WriteSyncStateFile: %v", err)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("got mode %v", info.Mode().Perm())
+	}
+	got, err := ReadSyncStateFile(name)
+	if err != nil {
+		t.Fatalf("ReadSyncStateFile: %v", err)
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Errorf("got %+v, want %+v", got, state)
+	}
+
+	err = os.WriteFile(name, []byte(`{"version": 2}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadSyncStateFile(name)
+	if !errors.Is(err, ErrSyncStateVersion) {
+		t.Errorf("got %v, want ErrSyncStateVersion", err)
+	}
+}
+
+func TestSyncHash(t *testing.T) {
+	a := map[string]any{"name": "db", "uris": []any{"https://db"}, "password": "s3cret"}
+	b := map[string]any{"password": "s3cret", "uris": []any{"https://db"}, "name": "db"}
+	if syncHash(a) != syncHash(b) {
+		t.Errorf("equal data has different hashes")
+	}
+	b["password"] = "changed"
+	if syncHash(a) == syncHash(b) {
+		t.Errorf("different data has equal hashes")
+	}
+}
+
+func TestCmpFolderPaths(t *testing.T) {
+	paths := [][]string{{"b", "c"}, {"b"}, {"a", "z"}, {"a"}}
+	slices.SortFunc(paths, cmpFolderPaths)
+	want := [][]string{{"a"}, {"b"}, {"a", "z"}, {"b", "c"}}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %v", paths)
+	}
+}
@@ -0,0 +1,232 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// Tag is a Tag with its Slug decrypted
+type Tag struct {
+	ID   string
+	Slug string
+	// IsShared Tags are visible to everyone with access to the Resource, their Slug starts with #
+	IsShared bool
+}
+
+// GetTags gets all Tags the current User can see
+func GetTags(ctx context.Context, c *api.Client) ([]Tag, error) {
+	tags, err := c.GetTags(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Tags: %w", err)
+	}
+	return decryptTags(ctx, c, tags)
+}
+
+// GetResourceTags gets the Tags attached to a Resource
+func GetResourceTags(ctx context.Context, c *api.Client, resourceID string) ([]Tag, error) {
+	tags, err := getResourceTags(ctx, c, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return decryptTags(ctx, c, tags)
+}
+
+// AddResourceTags adds Tags to a Resource, Tags the Resource already has are kept.
+// Slugs starting with # are shared Tags, all others are personal Tags
+func AddResourceTags(ctx context.Context, c *api.Client, resourceID string, slugs ...string) error {
+	current, err := GetResourceTags(ctx, c, resourceID)
+	if err != nil {
+		return err
+	}
+	return setResourceTags(ctx, c, resourceID, mergeTagSlugs(tagSlugs(current), slugs))
+}
+
+// RemoveResourceTags removes Tags from a Resource, the Tags themselves are not deleted
+func RemoveResourceTags(ctx context.Context, c *api.Client, resourceID string, slugs ...string) error {
+	current, err := GetResourceTags(ctx, c, resourceID)
+	if err != nil {
+		return err
+	}
+	return setResourceTags(ctx, c, resourceID, removeTagSlugs(tagSlugs(current), slugs))
+}
+
+// RenameTag renames a Tag on all Resources it is attached to.
+// A shared Tag stays shared and a personal Tag stays personal, so the # prefix of the new slug has to match
+func RenameTag(ctx context.Context, c *api.Client, tagID, slug string) error {
+	tags, err := c.GetTags(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("getting Tags: %w", err)
+	}
+	tag, err := findBy(tags, func(t api.Tag) bool { return t.ID == tagID }, ErrTagNotFound, tagID)
+	if err != nil {
+		return err
+	}
+
+	if tag.IsShared != isSharedTagSlug(slug) {
+		return fmt.Errorf("%w: %q", ErrTagSharedMismatch, slug)
+	}
+
+	newTag := api.Tag{}
+	if tag.Metadata != "" {
+		personal := tag.MetadataKeyType != api.MetadataKeyTypeSharedKey
+		err = setTagMetadata(ctx, c, &newTag, slug, personal)
+		if err != nil {
+			return err
+		}
+	} else {
+		newTag.Slug = slug
+	}
+
+	_, err = c.UpdateTag(ctx, tagID, newTag)
+	if err != nil {
+		return fmt.Errorf("updating Tag: %w", err)
+	}
+	return nil
+}
+
+// DeleteTag Deletes a Tag and removes it from all Resources
+func DeleteTag(ctx context.Context, c *api.Client, tagID string) error {
+	err := c.DeleteTag(ctx, tagID)
+	if err != nil {
+		return fmt.Errorf("deleting Tag: %w", err)
+	}
+	return nil
+}
+
+// setResourceTags replaces the Tags of a Resource using the server's preferred format (v4 or v5)
+func setResourceTags(ctx context.Context, c *api.Client, resourceID string, slugs []string) error {
+	settings := c.MetadataTypeSettings()
+	if settings.DefaultTagType != api.PassboltAPIVersionTypeV5 {
+		if !settings.AllowCreationOfV4Tags {
+			return ErrV4TagCreationDisabled
+		}
+		_, err := c.SetResourceTags(ctx, resourceID, slugs)
+		if err != nil {
+			return fmt.Errorf("setting Resource Tags: %w", err)
+		}
+		return nil
+	}
+
+	// Reuse existing Tags so we don't create duplicates with the same slug
+	existing, err := GetTags(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	tags := []api.Tag{}
+	for _, slug := range slugs {
+		shared := isSharedTagSlug(slug)
+		i := slices.IndexFunc(existing, func(t Tag) bool { return t.Slug == slug && t.IsShared == shared })
+		if i != -1 {
+			tags = append(tags, api.Tag{ID: existing[i].ID})
+			continue
+		}
+
+		if !settings.AllowCreationOfV5Tags {
+			return ErrV5TagCreationDisabled
+		}
+		tag := api.Tag{IsShared: shared}
+		err = setTagMetadata(ctx, c, &tag, slug, !shared)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+	}
+
+	_, err = c.SetResourceTagsV5(ctx, resourceID, tags)
+	if err != nil {
+		return fmt.Errorf("setting Resource Tags: %w", err)
+	}
+	return nil
+}
+
+// getResourceTags gets the raw Tags of a Resource, the resource view endpoint does not support containing tags
+func getResourceTags(ctx context.Context, c *api.Client, resourceID string) ([]api.Tag, error) {
+	resources, err := c.GetResources(ctx, &api.GetResourcesOptions{
+		FilterHasID: []string{resourceID},
+		ContainTags: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting Resource: %w", err)
+	}
+	resource, err := findBy(resources, func(r api.Resource) bool { return r.ID == resourceID }, ErrResourceNotFound, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Tags, nil
+}
+
+// setTagMetadata encrypts slug with the personal or shared metadata key and sets it on tag
+func setTagMetadata(ctx context.Context, c *api.Client, tag *api.Tag, slug string, personal bool) error {
+	metadataKeyID, metadataKeyType, encMetadata, err := encryptForeignMetadata(ctx, c, api.TagMetadata{
+		ObjectType: api.PassboltObjectTypeTagMetadata,
+		Slug:       slug,
+	}, personal)
+	if err != nil {
+		return fmt.Errorf("encrypting Tag Metadata: %w", err)
+	}
+
+	tag.MetadataKeyID = metadataKeyID
+	tag.MetadataKeyType = metadataKeyType
+	tag.Metadata = encMetadata
+	return nil
+}
+
+func decryptTags(ctx context.Context, c *api.Client, tags []api.Tag) ([]Tag, error) {
+	result := make([]Tag, 0, len(tags))
+	for _, t := range tags {
+		slug := t.Slug
+		if t.Metadata != "" {
+			metadata, err := GetTagMetadata(ctx, c, &t)
+			if err != nil {
+				return nil, fmt.Errorf("getting Tag %v Metadata: %w", t.ID, err)
+			}
+			slug = metadata.Slug
+		}
+		result = append(result, Tag{
+			ID:       t.ID,
+			Slug:     slug,
+			IsShared: t.IsShared,
+		})
+	}
+	return result, nil
+}
+
+// isSharedTagSlug reports whether slug names a shared Tag
+func isSharedTagSlug(slug string) bool {
+	return strings.HasPrefix(slug, "#")
+}
+
+func tagSlugs(tags []Tag) []string {
+	slugs := make([]string, 0, len(tags))
+	for _, t := range tags {
+		slugs = append(slugs, t.Slug)
+	}
+	return slugs
+}
+
+// mergeTagSlugs appends the slugs in add that are not already in current, keeping the order
+func mergeTagSlugs(current, add []string) []string {
+	result := slices.Clone(current)
+	for _, slug := range add {
+		if slug != "" && !slices.Contains(result, slug) {
+			result = append(result, slug)
+		}
+	}
+	return result
+}
+
+// removeTagSlugs returns current without the slugs in remove
+func removeTagSlugs(current, remove []string) []string {
+	result := []string{}
+	for _, slug := range current {
+		if !slices.Contains(remove, slug) {
+			result = append(result, slug)
+		}
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+//go:build integration
+
+package helper
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// TestResourceTags adds, renames and removes tags on a resource and checks
+// the decrypted slugs read back match, independent of the server's tag type.
+func TestResourceTags(t *testing.T) {
+	if client == nil {
+		t.SkipNow()
+	}
+	ctx := context.TODO()
+
+	id, err := CreateResource(ctx, client, "", "tagged", "username", "https://url.lan", "password123", "")
+	if err != nil {
+		t.Fatalf("Creating Resource %v", err)
+	}
+	defer func() { _ = DeleteResource(ctx, client, id) }()
+
+	err = AddResourceTags(ctx, client, id, "mine", "#shared")
+	if err != nil {
+		t.Fatalf("Adding Tags %v", err)
+	}
+
+	tags, err := GetResourceTags(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Tags %v", err)
+	}
+	slugs := tagSlugs(tags)
+	slices.Sort(slugs)
+	if !slices.Equal(slugs, []string{"#shared", "mine"}) {
+		t.Fatalf("Tags are %v", slugs)
+	}
+
+	var mine Tag
+	for _, tag := range tags {
+		if tag.Slug == "mine" {
+			mine = tag
+		}
+	}
+	err = RenameTag(ctx, client, mine.ID, "renamed")
+	if err != nil {
+		t.Fatalf("Renaming Tag %v", err)
+	}
+
+	err = RemoveResourceTags(ctx, client, id, "#shared")
+	if err != nil {
+		t.Fatalf("Removing Tags %v", err)
+	}
+
+	tags, err = GetResourceTags(ctx, client, id)
+	if err != nil {
+		t.Fatalf("Getting Tags %v", err)
+	}
+	if !slices.Equal(tagSlugs(tags), []string{"renamed"}) {
+		t.Fatalf("Tags are %v", tagSlugs(tags))
+	}
+}
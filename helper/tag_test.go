@@ -0,0 +1,77 @@
+package helper
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// AddResourceTags and RemoveResourceTags replace the full tag list on
+// the server, so the merge helpers decide which tags survive. A
+// regression that dropped or duplicated a slug would silently untag a
+// resource or create a second tag with the same name.
+
+func TestMergeTagSlugs(t *testing.T) {
+	t.Parallel()
+
+	got := mergeTagSlugs([]string{"#prod", "mine"}, []string{"mine", "", "new", "new"})
+	want := []string{"#prod", "mine", "new"}
+	if !slices.Equal(got, want) {
+		t.Errorf("mergeTagSlugs = %v, want %v", got, want)
+	}
+}
+
+func TestMergeTagSlugs_DoesNotAliasCurrent(t *testing.T) {
+	t.Parallel()
+
+	current := make([]string, 1, 4)
+	current[0] = "a"
+	_ = mergeTagSlugs(current, []string{"b"})
+	if got := current[:2][1]; got != "" {
+		t.Errorf("mergeTagSlugs wrote %q into the caller's backing array", got)
+	}
+}
+
+func TestRemoveTagSlugs(t *testing.T) {
+	t.Parallel()
+
+	got := removeTagSlugs([]string{"#prod", "mine", "other"}, []string{"mine", "absent"})
+	want := []string{"#prod", "other"}
+	if !slices.Equal(got, want) {
+		t.Errorf("removeTagSlugs = %v, want %v", got, want)
+	}
+
+	// Removing everything must yield an empty, non-nil list so the server
+	// receives "tags": [] instead of "tags": null.
+	if got := removeTagSlugs([]string{"a"}, []string{"a"}); got == nil || len(got) != 0 {
+		t.Errorf("removeTagSlugs(all) = %#v, want empty slice", got)
+	}
+}
+
+func TestIsSharedTagSlug(t *testing.T) {
+	t.Parallel()
+
+	if !isSharedTagSlug("#prod") {
+		t.Error("#prod should be a shared tag")
+	}
+	if isSharedTagSlug("prod#") {
+		t.Error("prod# should be a personal tag")
+	}
+}
+
+func TestParseTagMetadata(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseTagMetadata(`{"object_type":"PASSBOLT_TAG_METADATA","slug":"#prod"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Slug != "#prod" {
+		t.Errorf("Slug = %q, want #prod", got.Slug)
+	}
+
+	_, err = parseTagMetadata(`{"object_type":"PASSBOLT_FOLDER_METADATA","name":"x"}`)
+	if !errors.Is(err, ErrInvalidMetadataObjectType) {
+		t.Errorf("err = %v, want wrap of ErrInvalidMetadataObjectType", err)
+	}
+}
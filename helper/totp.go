@@ -7,8 +7,12 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/passbolt/go-passbolt/api"
 )
 
 const (
@@ -61,3 +65,62 @@ func GenerateOTPCode(token string, when time.Time) (string, error) {
 
 	return fmt.Sprintf(format, modulo), nil
 }
+
+// ParseOTPAuthURI parses an otpauth://totp URI as used by authenticator apps and KeePassXC.
+// Missing parameters default to SHA1, 6 digits and a 30 second period
+func ParseOTPAuthURI(uri string) (*api.SecretDataTOTP, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOTPAuthURI, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		return nil, fmt.Errorf("%w: only otpauth://totp is supported", ErrInvalidOTPAuthURI)
+	}
+	query := u.Query()
+
+	totp := &api.SecretDataTOTP{
+		Algorithm: "SHA1",
+		SecretKey: strings.ToUpper(strings.ReplaceAll(query.Get("secret"), " ", "")),
+		Digits:    codeLength,
+		Period:    timeSplitInSeconds,
+	}
+	if totp.SecretKey == "" {
+		return nil, fmt.Errorf("%w: missing secret", ErrInvalidOTPAuthURI)
+	}
+	if v := query.Get("algorithm"); v != "" {
+		totp.Algorithm = strings.ToUpper(strings.ReplaceAll(v, "-", ""))
+		if totp.Algorithm != "SHA1" && totp.Algorithm != "SHA256" && totp.Algorithm != "SHA512" {
+			return nil, fmt.Errorf("%w: algorithm %q", ErrInvalidOTPAuthURI, v)
+		}
+	}
+	if v := query.Get("digits"); v != "" {
+		totp.Digits, err = strconv.Atoi(v)
+		if err != nil || totp.Digits < 6 || totp.Digits > 8 {
+			return nil, fmt.Errorf("%w: digits %q", ErrInvalidOTPAuthURI, v)
+		}
+	}
+	if v := query.Get("period"); v != "" {
+		totp.Period, err = strconv.Atoi(v)
+		if err != nil || totp.Period <= 0 {
+			return nil, fmt.Errorf("%w: period %q", ErrInvalidOTPAuthURI, v)
+		}
+	}
+	return totp, nil
+}
+
+// OTPAuthURI formats a TOTP as an otpauth://totp URI with label as account name
+func OTPAuthURI(totp api.SecretDataTOTP, label string) string {
+	query := url.Values{}
+	query.Set("secret", totp.SecretKey)
+	if totp.Algorithm != "" {
+		query.Set("algorithm", totp.Algorithm)
+	}
+	if totp.Digits != 0 {
+		query.Set("digits", strconv.Itoa(totp.Digits))
+	}
+	if totp.Period != 0 {
+		query.Set("period", strconv.Itoa(totp.Period))
+	}
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
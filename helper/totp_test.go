@@ -1,8 +1,11 @@
 package helper
 
 import (
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/passbolt/go-passbolt/api"
 )
 
 // rfc6238SharedKey is the base32 encoding of the ASCII secret
@@ -106,3 +109,48 @@ func TestGenerateOTPCode_RejectsInvalidBase32(t *testing.T) {
 		t.Fatal("expected base32 decode error, got nil")
 	}
 }
+
+// TestParseOTPAuthURI covers the defaults, parameter normalization and
+// a round trip through OTPAuthURI
+func TestParseOTPAuthURI(t *testing.T) {
+	t.Parallel()
+
+	totp, err := ParseOTPAuthURI("otpauth://totp/Mail:alice?secret=jbsw%20y3dp&issuer=Mail")
+	if err != nil {
+		t.Fatalf("ParseOTPAuthURI: %v", err)
+	}
+	want := api.SecretDataTOTP{Algorithm: "SHA1", SecretKey: "JBSWY3DP", Digits: 6, Period: 30}
+	if *totp != want {
+		t.Errorf("got %+v, want %+v", *totp, want)
+	}
+
+	totp, err = ParseOTPAuthURI("otpauth://totp/x?secret=JBSWY3DP&algorithm=sha-256&digits=8&period=60")
+	if err != nil {
+		t.Fatalf("ParseOTPAuthURI: %v", err)
+	}
+	want = api.SecretDataTOTP{Algorithm: "SHA256", SecretKey: "JBSWY3DP", Digits: 8, Period: 60}
+	if *totp != want {
+		t.Errorf("got %+v, want %+v", *totp, want)
+	}
+
+	roundTrip, err := ParseOTPAuthURI(OTPAuthURI(want, "Mail"))
+	if err != nil {
+		t.Fatalf("ParseOTPAuthURI(OTPAuthURI): %v", err)
+	}
+	if *roundTrip != want {
+		t.Errorf("round trip got %+v, want %+v", *roundTrip, want)
+	}
+
+	for _, uri := range []string{
+		"otpauth://hotp/x?secret=JBSWY3DP",
+		"https://totp/x?secret=JBSWY3DP",
+		"otpauth://totp/x",
+		"otpauth://totp/x?secret=JBSWY3DP&digits=4",
+		"otpauth://totp/x?secret=JBSWY3DP&algorithm=MD5",
+		"otpauth://totp/x?secret=JBSWY3DP&period=0",
+	} {
+		if _, err := ParseOTPAuthURI(uri); !errors.Is(err, ErrInvalidOTPAuthURI) {
+			t.Errorf("ParseOTPAuthURI(%q) = %v, want ErrInvalidOTPAuthURI", uri, err)
+		}
+	}
+}
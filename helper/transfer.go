@@ -0,0 +1,132 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// ownershipTransfer is how ownership is handed over when deleting a User or Group. Where the new owner already
+// has a permission or membership the server promotes it on deletion, otherwise the client shares the resource
+// or folder, encrypting the secret for the new owner, or adds the group manager itself
+type ownershipTransfer struct {
+	transfer       api.DeleteTransfer
+	shareResources []string
+	shareFolders   []string
+	addManager     []string
+
+	newOwnerARO       string
+	newOwnerID        string
+	newGroupManagerID string
+}
+
+// planOwnershipTransfer decides for each blocker whether the server can promote an existing permission or membership
+func planOwnershipTransfer(blockers *api.DeleteDryRunBlockers, newOwnerARO, newOwnerID, newGroupManagerID string) (*ownershipTransfer, error) {
+	plan := &ownershipTransfer{
+		newOwnerARO:       newOwnerARO,
+		newOwnerID:        newOwnerID,
+		newGroupManagerID: newGroupManagerID,
+	}
+	if len(blockers.Resources.SoleOwner) != 0 || len(blockers.Folders.SoleOwner) != 0 {
+		if newOwnerID == "" || (newOwnerARO != "User" && newOwnerARO != "Group") {
+			return nil, ErrNoNewOwner
+		}
+	}
+	if len(blockers.Groups.SoleManager) != 0 && newGroupManagerID == "" {
+		return nil, ErrNoNewGroupManager
+	}
+
+	findPermission := func(permissions []api.Permission) *api.Permission {
+		for _, p := range permissions {
+			if p.ARO == newOwnerARO && p.AROForeignKey == newOwnerID {
+				return &p
+			}
+		}
+		return nil
+	}
+
+	for _, resource := range blockers.Resources.SoleOwner {
+		if p := findPermission(resource.Permissions); p != nil {
+			plan.transfer.Owners = append(plan.transfer.Owners, api.TransferOwner{ID: p.ID, ACOForeignKey: resource.ID})
+		} else {
+			plan.shareResources = append(plan.shareResources, resource.ID)
+		}
+	}
+	for _, folder := range blockers.Folders.SoleOwner {
+		if p := findPermission(folder.Permissions); p != nil {
+			plan.transfer.Owners = append(plan.transfer.Owners, api.TransferOwner{ID: p.ID, ACOForeignKey: folder.ID})
+		} else {
+			plan.shareFolders = append(plan.shareFolders, folder.ID)
+		}
+	}
+	for _, group := range blockers.Groups.SoleManager {
+		membership, err := getMembershipByUserID(group.GroupUsers, newGroupManagerID)
+		if err == nil {
+			plan.transfer.Managers = append(plan.transfer.Managers, api.TransferManager{ID: membership.ID, GroupID: group.ID})
+		} else {
+			plan.addManager = append(plan.addManager, group.ID)
+		}
+	}
+	return plan, nil
+}
+
+// inline moves the promotions left to the server into the client side shares and memberships
+func (p *ownershipTransfer) inline(blockers *api.DeleteDryRunBlockers) {
+	for _, owner := range p.transfer.Owners {
+		if isBlockingFolder(blockers, owner.ACOForeignKey) {
+			p.shareFolders = append(p.shareFolders, owner.ACOForeignKey)
+		} else {
+			p.shareResources = append(p.shareResources, owner.ACOForeignKey)
+		}
+	}
+	for _, manager := range p.transfer.Managers {
+		p.addManager = append(p.addManager, manager.GroupID)
+	}
+	p.transfer = api.DeleteTransfer{}
+}
+
+// apply does the client side part of the transfer. It returns the IDs of all transferred Resources, Folders and Groups,
+// including those the server promotes on deletion
+func (p *ownershipTransfer) apply(ctx context.Context, c *api.Client, blockers *api.DeleteDryRunBlockers) (resources, folders, groups []string, err error) {
+	resources, folders, groups = []string{}, []string{}, []string{}
+	for _, owner := range p.transfer.Owners {
+		if isBlockingFolder(blockers, owner.ACOForeignKey) {
+			folders = append(folders, owner.ACOForeignKey)
+		} else {
+			resources = append(resources, owner.ACOForeignKey)
+		}
+	}
+	for _, manager := range p.transfer.Managers {
+		groups = append(groups, manager.GroupID)
+	}
+
+	newOwner := []ShareOperation{{Type: 15, ARO: p.newOwnerARO, AROID: p.newOwnerID}}
+	for _, resourceID := range p.shareResources {
+		err = ShareResource(ctx, c, resourceID, newOwner)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("transferring Resource %v: %w", resourceID, err)
+		}
+		resources = append(resources, resourceID)
+	}
+	for _, folderID := range p.shareFolders {
+		err = ShareFolder(ctx, c, folderID, newOwner)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("transferring Folder %v: %w", folderID, err)
+		}
+		folders = append(folders, folderID)
+	}
+	for _, groupID := range p.addManager {
+		err = UpdateGroup(ctx, c, groupID, "", []GroupMembershipOperation{{UserID: p.newGroupManagerID, IsGroupManager: true}})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("transferring Group %v: %w", groupID, err)
+		}
+		groups = append(groups, groupID)
+	}
+	return resources, folders, groups, nil
+}
+
+func isBlockingFolder(blockers *api.DeleteDryRunBlockers, id string) bool {
+	return slices.ContainsFunc(blockers.Folders.SoleOwner, func(f api.Folder) bool { return f.ID == id })
+}
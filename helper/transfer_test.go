@@ -0,0 +1,89 @@
+package helper
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/passbolt/go-passbolt/api"
+)
+
+// Where the new owner already has access the server can promote it on deletion,
+// everything else has to be shared by the client before the user is gone.
+
+func testTransferBlockers() *api.DeleteDryRunBlockers {
+	return &api.DeleteDryRunBlockers{
+		Resources: api.SoleOwnerResources{SoleOwner: []api.Resource{
+			{ID: "r-shared", Permissions: []api.Permission{{ID: "p1", ARO: "User", AROForeignKey: "new"}}},
+			{ID: "r-private"},
+		}},
+		Folders: api.SoleOwnerFolders{SoleOwner: []api.Folder{
+			{ID: "f-shared", Permissions: []api.Permission{{ID: "p2", ARO: "User", AROForeignKey: "new"}}},
+			{ID: "f-other", Permissions: []api.Permission{{ID: "p3", ARO: "Group", AROForeignKey: "new"}}},
+		}},
+		Groups: api.SoleManagerGroups{SoleManager: []api.Group{
+			{ID: "g-member", GroupUsers: []api.GroupMembership{{ID: "m1", UserID: "new"}}},
+			{ID: "g-other"},
+		}},
+	}
+}
+
+func TestPlanOwnershipTransfer(t *testing.T) {
+	t.Parallel()
+
+	plan, err := planOwnershipTransfer(testTransferBlockers(), "User", "new", "new")
+	if err != nil {
+		t.Fatalf("planOwnershipTransfer: %v", err)
+	}
+
+	wantOwners := []api.TransferOwner{{ID: "p1", ACOForeignKey: "r-shared"}, {ID: "p2", ACOForeignKey: "f-shared"}}
+	if !slices.Equal(plan.transfer.Owners, wantOwners) {
+		t.Errorf("owners = %+v, want %+v", plan.transfer.Owners, wantOwners)
+	}
+	wantManagers := []api.TransferManager{{ID: "m1", GroupID: "g-member"}}
+	if !slices.Equal(plan.transfer.Managers, wantManagers) {
+		t.Errorf("managers = %+v, want %+v", plan.transfer.Managers, wantManagers)
+	}
+	if !slices.Equal(plan.shareResources, []string{"r-private"}) {
+		t.Errorf("shareResources = %v", plan.shareResources)
+	}
+	// A Group permission with the same ID is not the new owner User
+	if !slices.Equal(plan.shareFolders, []string{"f-other"}) {
+		t.Errorf("shareFolders = %v", plan.shareFolders)
+	}
+	if !slices.Equal(plan.addManager, []string{"g-other"}) {
+		t.Errorf("addManager = %v", plan.addManager)
+	}
+
+	plan.inline(testTransferBlockers())
+	if len(plan.transfer.Owners) != 0 || len(plan.transfer.Managers) != 0 {
+		t.Errorf("transfer left after inline: %+v", plan.transfer)
+	}
+	if !slices.Equal(plan.shareResources, []string{"r-private", "r-shared"}) {
+		t.Errorf("inlined shareResources = %v", plan.shareResources)
+	}
+	if !slices.Equal(plan.shareFolders, []string{"f-other", "f-shared"}) {
+		t.Errorf("inlined shareFolders = %v", plan.shareFolders)
+	}
+	if !slices.Equal(plan.addManager, []string{"g-other", "g-member"}) {
+		t.Errorf("inlined addManager = %v", plan.addManager)
+	}
+}
+
+func TestPlanOwnershipTransfer_MissingOwner(t *testing.T) {
+	t.Parallel()
+
+	_, err := planOwnershipTransfer(testTransferBlockers(), "", "", "new")
+	if !errors.Is(err, ErrNoNewOwner) {
+		t.Errorf("got %v, want %v", err, ErrNoNewOwner)
+	}
+	_, err = planOwnershipTransfer(testTransferBlockers(), "Group", "new", "")
+	if !errors.Is(err, ErrNoNewGroupManager) {
+		t.Errorf("got %v, want %v", err, ErrNoNewGroupManager)
+	}
+	// Nothing to transfer needs no new owner
+	_, err = planOwnershipTransfer(&api.DeleteDryRunBlockers{}, "", "", "")
+	if err != nil {
+		t.Errorf("got %v for no blockers", err)
+	}
+}